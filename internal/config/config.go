@@ -18,6 +18,7 @@ type Config struct {
 	Env                string
 	IsProd             bool
 	SecretKey          []byte
+	SecretGenerated    bool
 	DBPath             string
 	StaticDir          string
 	UploadsDir         string
@@ -44,8 +45,42 @@ type Config struct {
 	MCPEnabled         bool
 	MCPToken           string
 	MCPReadOnly        bool
+	BackupInterval     time.Duration
+	BackupDir          string
+	BackupKeep         int
+	BackupKeepDaily    int
+	BackupKeepWeekly   int
+	PINCSigningKey     string
+	APDeliveryInterval time.Duration
+	EncryptionKey      string
+	DomainRecheck      time.Duration
+	DomainRecheckGrace time.Duration
+	OutboundTimeout    time.Duration
+	OutboundUserAgent  string
+	AllowPrivateHosts  bool
+	OAuthProviders     []OAuthProvider
 }
 
+// OAuthProvider configures a generic OAuth2/OIDC social verification provider. With Issuer set,
+// endpoints missing here are read from the issuer's OpenID discovery document.
+type OAuthProvider struct {
+	Name          string
+	Label         string
+	ClientID      string
+	ClientSecret  string
+	Issuer        string
+	AuthURL       string
+	TokenURL      string
+	UserinfoURL   string
+	Scopes        []string
+	UsernameClaim string
+	// ProfileURL is a template where {username} is replaced; empty uses the "profile" claim.
+	ProfileURL string
+}
+
+// builtinOAuthProviders have dedicated settings and cannot be redefined as generic providers.
+var builtinOAuthProviders = map[string]bool{"github": true, "reddit": true, "bluesky": true}
+
 // LoadConfig reads environment variables, applies defaults, and validates required settings.
 func LoadConfig() (Config, error) {
 	env := strings.ToLower(strings.TrimSpace(getEnv("PIN_ENV", "development")))
@@ -55,7 +90,8 @@ func LoadConfig() (Config, error) {
 	if secret == "" && isProd {
 		return Config{}, errors.New("PIN_SECRET_KEY is required in production")
 	}
-	if secret == "" {
+	secretGenerated := secret == ""
+	if secretGenerated {
 		secret = randomSecret(32)
 	}
 
@@ -74,12 +110,18 @@ func LoadConfig() (Config, error) {
 		}
 	}
 
+	oauthProviders, err := loadOAuthProviders()
+	if err != nil {
+		return Config{}, err
+	}
+
 	uploadsDir := getEnv("PIN_UPLOADS_DIR", filepath.Join(getBaseDir(), "static", "uploads"))
 
 	return Config{
 		Env:                env,
 		IsProd:             isProd,
 		SecretKey:          []byte(secret),
+		SecretGenerated:    secretGenerated,
 		DBPath:             getEnv("PIN_DB_PATH", filepath.Join(getBaseDir(), "identity.db")),
 		StaticDir:          filepath.Join(getBaseDir(), "static"),
 		UploadsDir:         uploadsDir,
@@ -106,9 +148,79 @@ func LoadConfig() (Config, error) {
 		MCPEnabled:         envBool("PIN_MCP_ENABLED", true),
 		MCPToken:           os.Getenv("PIN_MCP_TOKEN"),
 		MCPReadOnly:        envBool("PIN_MCP_READONLY", true),
+		BackupInterval:     envDuration("PIN_BACKUP_INTERVAL", 0),
+		BackupDir:          getEnv("PIN_BACKUP_DIR", filepath.Join(getBaseDir(), "backups")),
+		BackupKeep:         envInt("PIN_BACKUP_KEEP", 7),
+		BackupKeepDaily:    envInt("PIN_BACKUP_KEEP_DAILY", 0),
+		BackupKeepWeekly:   envInt("PIN_BACKUP_KEEP_WEEKLY", 0),
+		PINCSigningKey:     os.Getenv("PIN_PINC_SIGNING_KEY"),
+		APDeliveryInterval: envDuration("PIN_ACTIVITYPUB_DELIVERY_INTERVAL", 0),
+		EncryptionKey:      os.Getenv("PIN_ENCRYPTION_KEY"),
+		DomainRecheck:      envDuration("PIN_DOMAIN_RECHECK_INTERVAL", 0),
+		DomainRecheckGrace: envDuration("PIN_DOMAIN_RECHECK_GRACE", 72*time.Hour),
+		OutboundTimeout:    envDuration("PIN_OUTBOUND_TIMEOUT", 10*time.Second),
+		OutboundUserAgent:  getEnv("PIN_OUTBOUND_USER_AGENT", "pin/1.0"),
+		AllowPrivateHosts:  envBool("PIN_OUTBOUND_ALLOW_PRIVATE", false),
+		OAuthProviders:     oauthProviders,
 	}, nil
 }
 
+// loadOAuthProviders reads the generic providers named in PIN_OAUTH_PROVIDERS, each configured
+// by PIN_OAUTH_{NAME}_* variables.
+func loadOAuthProviders() ([]OAuthProvider, error) {
+	var out []OAuthProvider
+	seen := map[string]bool{}
+	for _, name := range strings.FieldsFunc(strings.ToLower(os.Getenv("PIN_OAUTH_PROVIDERS")), func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		if !validProviderName(name) {
+			return nil, fmt.Errorf("PIN_OAUTH_PROVIDERS: invalid provider name %q", name)
+		}
+		if builtinOAuthProviders[name] {
+			return nil, fmt.Errorf("PIN_OAUTH_PROVIDERS: %q is a built-in provider", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		prefix := "PIN_OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OAuthProvider{
+			Name:          name,
+			Label:         getEnv(prefix+"LABEL", name),
+			ClientID:      os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret:  os.Getenv(prefix + "CLIENT_SECRET"),
+			Issuer:        strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			AuthURL:       os.Getenv(prefix + "AUTH_URL"),
+			TokenURL:      os.Getenv(prefix + "TOKEN_URL"),
+			UserinfoURL:   os.Getenv(prefix + "USERINFO_URL"),
+			Scopes:        strings.Fields(strings.ReplaceAll(getEnv(prefix+"SCOPES", "openid profile"), ",", " ")),
+			UsernameClaim: getEnv(prefix+"USERNAME_CLAIM", "preferred_username"),
+			ProfileURL:    os.Getenv(prefix + "PROFILE_URL"),
+		}
+		if provider.ClientID == "" {
+			return nil, fmt.Errorf("%sCLIENT_ID is required", prefix)
+		}
+		if provider.Issuer == "" && (provider.AuthURL == "" || provider.TokenURL == "" || provider.UserinfoURL == "") {
+			return nil, fmt.Errorf("%sISSUER or the AUTH_URL, TOKEN_URL and USERINFO_URL endpoints are required", prefix)
+		}
+		out = append(out, provider)
+	}
+	return out, nil
+}
+
+// validProviderName reports whether name is usable as a route segment and env var infix.
+func validProviderName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
 // getBaseDir returns the working directory or executable directory as a fallback.
 func getBaseDir() string {
 	if wd, err := os.Getwd(); err == nil {
@@ -153,3 +265,29 @@ func envBool(name string, fallback bool) bool {
 		return fallback
 	}
 }
+
+// envInt parses a non-negative integer env value and falls back when empty/invalid.
+func envInt(name string, fallback int) int {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < 0 {
+		return fallback
+	}
+	return parsed
+}
+
+// envDuration parses a Go duration env value and falls back when empty/invalid.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed < 0 {
+		return fallback
+	}
+	return parsed
+}
@@ -0,0 +1,5 @@
+package config
+
+// Version identifies the PIN build; release builds override it with
+// -ldflags "-X pin/internal/config.Version=v1.2.3".
+var Version = "dev"
@@ -0,0 +1,26 @@
+package activitypub
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// Repository defines persistence operations for ActivityPub actors, followers and deliveries.
+type Repository interface {
+	GetActivityPubActor(ctx context.Context, identityID int) (domain.ActivityPubActor, error)
+	ListActivityPubActors(ctx context.Context) ([]domain.ActivityPubActor, error)
+	CreateActivityPubActor(ctx context.Context, actor domain.ActivityPubActor) error
+	UpdateActivityPubActorKeys(ctx context.Context, actor domain.ActivityPubActor) error
+	UpdateActivityPubActorState(ctx context.Context, identityID int, baseURL, publishedHash string) error
+	AddActivityPubFollower(ctx context.Context, follower domain.ActivityPubFollower) error
+	RemoveActivityPubFollower(ctx context.Context, identityID int, actorID string) error
+	ListActivityPubFollowers(ctx context.Context, identityID int) ([]domain.ActivityPubFollower, error)
+	CreateActivityPubActivity(ctx context.Context, activity domain.ActivityPubActivity, inboxes []string) (int64, error)
+	ListActivityPubOutbox(ctx context.Context, identityID, limit int) ([]domain.ActivityPubActivity, int, error)
+	ListDueActivityPubDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.ActivityPubDelivery, error)
+	MarkActivityPubDelivered(ctx context.Context, id int, deliveredAt time.Time) error
+	RecordActivityPubDeliveryFailure(ctx context.Context, id int, nextAttemptAt sql.NullTime, lastError string) error
+}
@@ -0,0 +1,17 @@
+package apitokens
+
+import (
+	"context"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// Repository defines persistence operations for personal API tokens.
+type Repository interface {
+	CreateAPIToken(ctx context.Context, token domain.APIToken) (int64, error)
+	ListAPITokens(ctx context.Context, userID int) ([]domain.APIToken, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error)
+	TouchAPIToken(ctx context.Context, id int, usedAt time.Time) error
+	RevokeAPIToken(ctx context.Context, userID, id int) error
+}
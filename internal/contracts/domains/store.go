@@ -2,6 +2,8 @@ package domains
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"pin/internal/domain"
 )
@@ -12,7 +14,10 @@ type Repository interface {
 	ListVerifiedDomains(ctx context.Context, identityID int) ([]string, error)
 	UpsertDomainVerification(ctx context.Context, identityID int, domain, token string) error
 	DeleteDomainVerification(ctx context.Context, identityID int, domain string) error
-	MarkDomainVerified(ctx context.Context, identityID int, domain string) error
+	MarkDomainVerified(ctx context.Context, identityID int, domain, method string) error
+	ListVerifiedDomainVerifications(ctx context.Context) ([]domain.DomainVerification, error)
+	RecordDomainCheck(ctx context.Context, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error
+	DemoteDomainVerification(ctx context.Context, id int, checkedAt time.Time, lastError string) error
 	UpdateIdentityVerifiedDomains(ctx context.Context, identityID int, domains []string) error
 	HasDomainVerification(ctx context.Context, identityID int, domain string) (bool, error)
 	ProtectedDomain(ctx context.Context) string
@@ -20,4 +20,7 @@ type Repository interface {
 	UpdatePrivateToken(ctx context.Context, identityID int, token string) error
 	CheckHandleCollision(ctx context.Context, handle string, excludeID int) error
 	DeleteIdentity(ctx context.Context, identityID int) error
+	CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error)
+	ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error)
+	GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error)
 }
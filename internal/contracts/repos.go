@@ -1,6 +1,8 @@
 package contracts
 
 import (
+	"pin/internal/contracts/activitypub"
+	"pin/internal/contracts/apitokens"
 	"pin/internal/contracts/audit"
 	"pin/internal/contracts/domains"
 	"pin/internal/contracts/identities"
@@ -21,4 +23,6 @@ type Repos struct {
 	Domains         domains.Repository
 	ProfilePictures profilepictures.Repository
 	Settings        settings.Repository
+	APITokens       apitokens.Repository
+	ActivityPub     activitypub.Repository
 }
@@ -45,10 +45,22 @@ type Identity struct {
 	ATProtoHandle       string
 	ATProtoDID          string
 	Timezone            string
+	NostrRelaysJSON     string
 	ProfilePictureID    sql.NullInt64
 	UpdatedAt           time.Time
 }
 
+// IdentityRevision is an immutable snapshot of an identity keyed by its PINC rev.
+type IdentityRevision struct {
+	ID         int
+	IdentityID int
+	Rev        string
+	Snapshot   string
+	ActorID    sql.NullInt64
+	Note       string
+	CreatedAt  time.Time
+}
+
 type Invite struct {
 	ID         int
 	Token      string
@@ -70,6 +82,19 @@ type Passkey struct {
 	LastUsedAt     sql.NullTime
 }
 
+type APIToken struct {
+	ID         int
+	UserID     int
+	Name       string
+	TokenHash  string
+	Prefix     string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
 type AuditLog struct {
 	ID        int
 	ActorID   sql.NullInt64
@@ -94,27 +119,91 @@ type DomainVerification struct {
 	Domain     string
 	Token      string
 	VerifiedAt sql.NullTime
+	// Method is how the domain was last verified: "http" or "dns".
+	Method string
+	// LastCheckedAt and LastError record the latest periodic re-check; FailingSince is when
+	// the current run of failed re-checks began and drives the demotion grace period.
+	LastCheckedAt sql.NullTime
+	LastError     string
+	FailingSince  sql.NullTime
+	CreatedAt     time.Time
+}
+
+// ActivityPubActor holds the server-managed keys and publish state of an identity's actor.
+// Private keys are sealed; only the server's encryption key can open them.
+type ActivityPubActor struct {
+	IdentityID        int
+	PublicKeyPEM      string
+	PrivateKey        string
+	Ed25519PublicKey  string
+	Ed25519PrivateKey string
+	BaseURL           string
+	PublishedHash     string
+	CreatedAt         time.Time
+	RotatedAt         sql.NullTime
+}
+
+// ActivityPubFollower is a remote actor following an identity.
+type ActivityPubFollower struct {
+	ID          int
+	IdentityID  int
+	ActorID     string
+	Inbox       string
+	SharedInbox string
+	FollowID    string
+	CreatedAt   time.Time
+}
+
+// ActivityPubActivity is an activity published by an identity's actor.
+type ActivityPubActivity struct {
+	ID         int
+	IdentityID int
+	ActivityID string
+	Type       string
+	Payload    string
+	Public     bool
 	CreatedAt  time.Time
 }
 
+// ActivityPubDelivery is a queued POST of an activity to a remote inbox.
+type ActivityPubDelivery struct {
+	ID            int
+	IdentityID    int
+	ActivityID    int
+	Inbox         string
+	Payload       string
+	Attempts      int
+	NextAttemptAt sql.NullTime
+	LastError     string
+	DeliveredAt   sql.NullTime
+	CreatedAt     time.Time
+}
+
 // Visibility represents a keyed visibility entry stored on the user.
 type Visibility struct {
 	Key        string `json:"key"`
 	Visibility string `json:"visibility"`
 }
 
-// Link is a label + URL pair serialized into JSON for storage.
+// Link is a label + URL pair serialized into JSON for storage. Verified is set once the linked
+// page has been found to link back to the profile with rel="me"; VerifiedAt is the RFC3339 time
+// of that check.
 type Link struct {
-	Label string `json:"label"`
-	URL   string `json:"url"`
+	Label      string `json:"label"`
+	URL        string `json:"url"`
+	Verified   bool   `json:"verified,omitempty"`
+	VerifiedAt string `json:"verified_at,omitempty"`
 }
 
 // SocialProfile represents a social link with optional verification metadata.
+// Profiles verified through an OAuth provider carry the provider key; rel="me" verification
+// leaves Provider empty and records VerifiedAt.
 type SocialProfile struct {
-	Label    string `json:"label"`
-	URL      string `json:"url"`
-	Provider string `json:"provider,omitempty"`
-	Verified bool   `json:"verified"`
+	Label      string `json:"label"`
+	URL        string `json:"url"`
+	Provider   string `json:"provider,omitempty"`
+	Verified   bool   `json:"verified"`
+	VerifiedAt string `json:"verified_at,omitempty"`
 }
 
 // CustomField represents a user-defined custom field in key-value format.
@@ -2,16 +2,21 @@ package admin
 
 import (
 	"context"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/sessions"
 	"pin/internal/config"
 	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/history"
 	featuresettings "pin/internal/features/settings"
 )
 
 type Dependencies interface {
 	featuresettings.Store
+	history.Store
+	apitokens.Store
 	Config() config.Config
 	GetSession(r *http.Request, name string) (*sessions.Session, error)
 	EnsureCSRF(session *sessions.Session) string
@@ -37,14 +42,17 @@ type Dependencies interface {
 	ListPasskeys(ctx context.Context, userID int) ([]domain.Passkey, error)
 	ListProfilePictures(ctx context.Context, identityID int) ([]domain.ProfilePicture, error)
 	CreateProfilePicture(ctx context.Context, identityID int, filename, alt string) (int64, error)
+	GetActivityPubActor(ctx context.Context, identityID int) (domain.ActivityPubActor, error)
 	ListDomainVerifications(ctx context.Context, identityID int) ([]domain.DomainVerification, error)
 	UpsertDomainVerification(ctx context.Context, identityID int, domainName, token string) error
 	DeleteDomainVerification(ctx context.Context, identityID int, domainName string) error
-	MarkDomainVerified(ctx context.Context, identityID int, domainName string) error
+	MarkDomainVerified(ctx context.Context, identityID int, domainName, method string) error
 	ProtectedDomain(ctx context.Context) string
+	HTTPClient() *http.Client
 	RenderTemplate(w http.ResponseWriter, name string, data interface{}) error
 	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
 	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
+	WriteBackup(ctx context.Context, w io.Writer) error
 }
 
 type Handler struct {
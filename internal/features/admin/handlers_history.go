@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"pin/internal/features/history"
+	featuresettings "pin/internal/features/settings"
+)
+
+// ProfileHistory lists identity revisions and restores a selected revision.
+func (h Handler) ProfileHistory(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.deps.GetSession(r, "pin_session")
+
+	current, err := h.deps.CurrentUser(r)
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	currentIdentity, err := h.deps.CurrentIdentity(r)
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	historySvc := history.NewService(h.deps)
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if !h.deps.ValidateCSRF(session, r.FormValue("csrf_token")) {
+			http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+			return
+		}
+		revisionID, err := strconv.Atoi(strings.TrimSpace(r.FormValue("revision_id")))
+		if err != nil || revisionID <= 0 {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		meta := map[string]string{"revision_id": strconv.Itoa(revisionID)}
+		h.deps.AuditAttempt(r.Context(), current.ID, "profile.restore", currentIdentity.Handle, meta)
+		restored, err := historySvc.Restore(r.Context(), h.deps.BaseURL(r), current.ID, currentIdentity.ID, revisionID)
+		h.deps.AuditOutcome(r.Context(), current.ID, "profile.restore", currentIdentity.Handle, err, meta)
+		if err != nil {
+			if errors.Is(err, history.ErrHandleTaken) {
+				http.Redirect(w, r, "/settings/profile/history?toast="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+				return
+			}
+			http.Error(w, "Failed to restore revision", http.StatusInternalServerError)
+			return
+		}
+		toast := "Restored revision #" + strconv.Itoa(revisionID) + " as @" + restored.Handle + "."
+		http.Redirect(w, r, "/settings/profile/history?toast="+url.QueryEscape(toast), http.StatusSeeOther)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := historySvc.List(r.Context(), currentIdentity.ID, history.DefaultLimit)
+	if err != nil {
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+
+	settingsSvc := featuresettings.NewService(h.deps)
+	isAdminUser := isAdmin(current)
+	data := map[string]interface{}{
+		"User":              currentIdentity,
+		"IsAdmin":           isAdminUser,
+		"Title":             "Settings - Profile history",
+		"SectionTitle":      "Profile history",
+		"SectionLayout":     "narrow",
+		"Message":           r.URL.Query().Get("toast"),
+		"CSRFToken":         h.deps.EnsureCSRF(session),
+		"Theme":             settingsSvc.ThemeSettings(r.Context(), &current),
+		"ShowAppearanceNav": isAdminUser || settingsSvc.ServerThemePolicy(r.Context()).AllowUserTheme,
+		"Entries":           entries,
+		"PublicHistoryURL":  "/" + url.PathEscape(currentIdentity.Handle) + "/history.json",
+	}
+
+	if err := session.Save(r, w); err != nil {
+		http.Error(w, "Session error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.deps.RenderTemplate(w, "settings_profile_history.html", data); err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"pin/internal/config"
 	"pin/internal/domain"
 	"pin/internal/features/domains"
+	"pin/internal/features/history"
 	"pin/internal/features/identity"
+	"pin/internal/features/oauth"
 	featuresettings "pin/internal/features/settings"
 	"pin/internal/features/users"
 	"pin/internal/platform/core"
@@ -52,6 +54,7 @@ type profileFormData struct {
 	wallets          map[string]string
 	walletVisibility map[string]string
 	publicKeys       map[string]string
+	nostrRelays      []string
 	verifiedDomains  []string
 	domainVisibility map[string]string
 }
@@ -145,10 +148,10 @@ func (h Handler) Profile(w http.ResponseWriter, r *http.Request) {
 		"Wallets":                wallets,
 		"WalletEntries":          users.BuildWalletEntries(wallets, visibility),
 		"PublicKeys":             publicKeys,
+		"NostrRelays":            users.NostrRelaysToText(currentIdentity.NostrRelaysJSON),
 		"VerifiedDomains":        users.VerifiedDomainsToText(currentIdentity.VerifiedDomainsJSON),
 		"DomainVerifications":    []domain.DomainVerification{},
-		"GitHubOAuthEnabled":     cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" && cfg.BaseURL != "",
-		"RedditOAuthEnabled":     cfg.RedditClientID != "" && cfg.RedditClientSecret != "" && cfg.BaseURL != "",
+		"OAuthProviders":         oauth.RegistryFromConfig(oauth.NewConfig(cfg)).Providers(),
 		"BlueskyEnabled":         cfg.BlueskyPDS != "",
 		"IsAdmin":                isAdminUser,
 		"IsSelf":                 true,
@@ -181,7 +184,7 @@ func (h Handler) Profile(w http.ResponseWriter, r *http.Request) {
 	}
 	rows, err := h.deps.ListDomainVerifications(r.Context(), currentIdentity.ID)
 	if err == nil && len(rows) == 0 {
-		rows = domains.NewService(h.deps).SeedDomains(r.Context(), currentIdentity.ID, identity.DecodeStringSlice(currentIdentity.VerifiedDomainsJSON), func() string {
+		rows = domains.NewService(h.deps, h.deps.HTTPClient()).SeedDomains(r.Context(), currentIdentity.ID, identity.DecodeStringSlice(currentIdentity.VerifiedDomainsJSON), func() string {
 			return domains.RandomTokenURL(12)
 		})
 	}
@@ -247,7 +250,7 @@ func (h Handler) Profile(w http.ResponseWriter, r *http.Request) {
 		if domainsJSON, err := json.Marshal(verified); err == nil {
 			currentIdentity.VerifiedDomainsJSON = string(domainsJSON)
 		}
-		if err := h.deps.UpdateIdentity(r.Context(), currentIdentity); err != nil {
+		if err := history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, currentIdentity); err != nil {
 			h.deps.AuditOutcome(r.Context(), current.ID, "profile.update", currentIdentity.Handle, err, nil)
 			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 			return
@@ -263,6 +266,7 @@ func (h Handler) Profile(w http.ResponseWriter, r *http.Request) {
 		data["SocialProfiles"] = users.BuildSocialEntries(form.social, visibility)
 		data["Wallets"] = identity.DecodeStringMap(currentIdentity.WalletsJSON)
 		data["PublicKeys"] = identity.DecodeStringMap(currentIdentity.PublicKeysJSON)
+		data["NostrRelays"] = users.NostrRelaysToText(currentIdentity.NostrRelaysJSON)
 		data["DomainVerifications"] = domainRows
 		data["VerifiedDomains"] = identity.DomainsToText(domainRows)
 		data["ATProtoHandleVerified"] = identity.IsATProtoHandleVerified(currentIdentity.ATProtoHandle, identity.VerifiedDomains(domainRows))
@@ -305,28 +309,9 @@ func (h Handler) parseProfileForm(r *http.Request, currentIdentity domain.Identi
 		atprotoDID:    strings.TrimSpace(r.FormValue("atproto_did")),
 	}
 	form.links, form.linkVisibility = users.ParseLinksForm(r.Form["link_label"], r.Form["link_url"], r.Form["link_visibility"])
+	form.links = identity.MergeLinks(form.links, identity.DecodeLinks(currentIdentity.LinksJSON))
 	form.customFields = users.ParseCustomFieldsForm(r.Form["custom_key"], r.Form["custom_value"])
-	form.fieldVisibility = users.ParseVisibilityForm(r.Form, []string{
-		"display_name",
-		"bio",
-		"email",
-		"organization",
-		"job_title",
-		"birthdate",
-		"languages",
-		"phone",
-		"address",
-		"location",
-		"website",
-		"pronouns",
-		"timezone",
-		"atproto_handle",
-		"atproto_did",
-		"key_pgp",
-		"key_ssh",
-		"key_age",
-		"key_activitypub",
-	})
+	form.fieldVisibility = users.ParseVisibilityForm(r.Form, users.ProfileVisibilityFields)
 	form.customVisibility = users.ParseCustomVisibilityForm(r.Form["custom_key"], r.Form["custom_value"], r.Form["custom_visibility"])
 	form.social, form.socialVisibility = identity.ParseSocialForm(r.Form["social_label"], r.Form["social_url"], r.Form["social_visibility"])
 	form.social = identity.MergeSocialProfiles(form.social, socialProfiles)
@@ -347,6 +332,11 @@ func (h Handler) parseProfileForm(r *http.Request, currentIdentity domain.Identi
 		"ssh":         strings.TrimSpace(r.FormValue("key_ssh")),
 		"age":         strings.TrimSpace(r.FormValue("key_age")),
 		"activitypub": strings.TrimSpace(r.FormValue("key_activitypub")),
+		"nostr":       strings.TrimSpace(r.FormValue("key_nostr")),
+	}
+	form.nostrRelays, err = users.ParseNostrForm(form.publicKeys["nostr"], r.FormValue("nostr_relays"))
+	if err != nil {
+		return profileFormData{}, err
 	}
 	form.verifiedDomains = users.ParseVerifiedDomainsText(r.FormValue("verified_domains"))
 	form.domainVisibility = users.ParseVerifiedDomainVisibilityForm(r.Form["verified_domain"], r.Form["verified_domain_visibility"])
@@ -356,7 +346,7 @@ func (h Handler) parseProfileForm(r *http.Request, currentIdentity domain.Identi
 // syncProfileDomains reconciles verified domains with user input.
 func (h Handler) syncProfileDomains(ctx context.Context, current domain.User, currentIdentity domain.Identity, verifiedDomains []string) ([]domain.DomainVerification, []string, error) {
 	h.deps.AuditAttempt(ctx, current.ID, "domain.sync", currentIdentity.Handle, nil)
-	domainRows, verified, err := domains.NewService(h.deps).CreateDomains(ctx, currentIdentity.ID, verifiedDomains, func() string {
+	domainRows, verified, err := domains.NewService(h.deps, h.deps.HTTPClient()).CreateDomains(ctx, currentIdentity.ID, verifiedDomains, func() string {
 		return domains.RandomTokenURL(12)
 	})
 	if err != nil {
@@ -423,6 +413,7 @@ func applyProfileForm(identityRecord domain.Identity, form profileFormData) doma
 	identityRecord.Timezone = form.timezone
 	identityRecord.ATProtoHandle = form.atprotoHandle
 	identityRecord.ATProtoDID = form.atprotoDID
+	identityRecord.NostrRelaysJSON = identity.EncodeStringSlice(form.nostrRelays)
 	identityRecord.LinksJSON = identity.EncodeLinks(form.links)
 	if customJSON, err := json.Marshal(form.customFields); err == nil {
 		identityRecord.CustomFieldsJSON = string(customJSON)
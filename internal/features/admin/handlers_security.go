@@ -4,8 +4,11 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/federation"
 	featuresettings "pin/internal/features/settings"
 	"pin/internal/platform/core"
 )
@@ -32,6 +35,7 @@ func (h Handler) Security(w http.ResponseWriter, r *http.Request) {
 	}
 
 	passkeys, _ := h.deps.ListPasskeys(r.Context(), current.ID)
+	apiTokens, _ := apitokens.NewService(h.deps).List(r.Context(), current.ID)
 	settingsSvc := featuresettings.NewService(h.deps)
 	theme := settingsSvc.ThemeSettings(r.Context(), &current)
 	isAdminUser := isAdmin(current)
@@ -41,6 +45,10 @@ func (h Handler) Security(w http.ResponseWriter, r *http.Request) {
 		"User":               currentIdentity,
 		"IsAdmin":            isAdminUser,
 		"Passkeys":           passkeys,
+		"APITokens":          apiTokens,
+		"APITokenScopes":     apitokens.Scopes,
+		"APITokenExpiryDays": apitokens.ExpiryDays,
+		"Now":                time.Now().UTC(),
 		"Title":              "Settings - Privacy & security",
 		"SectionTitle":       "Privacy & security",
 		"SectionLayout":      "narrow",
@@ -50,10 +58,19 @@ func (h Handler) Security(w http.ResponseWriter, r *http.Request) {
 		"Theme":              theme,
 		"ShowAppearanceNav":  showAppearanceNav,
 	}
+	// Actor keys are created when the actor is first fetched, so the section stays empty until then.
+	if actor, err := h.deps.GetActivityPubActor(r.Context(), currentIdentity.ID); err == nil {
+		data["ActivityPubActor"] = actor
+		data["ActivityPubFingerprint"] = federation.KeyFingerprint(actor.PublicKeyPEM)
+	}
 	if toast := r.URL.Query().Get("toast"); toast != "" {
 		message = toast
 		data["Message"] = message
 	}
+	// A freshly created token is rendered into the create response once and never shown again.
+	if token := apitokens.CreatedToken(r.Context()); token != "" {
+		data["NewAPIToken"] = token
+	}
 
 	if r.Method == http.MethodPost {
 		if err := r.ParseForm(); err != nil {
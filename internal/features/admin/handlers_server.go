@@ -16,7 +16,9 @@ import (
 	"time"
 
 	"pin/internal/domain"
+	"pin/internal/features/backup"
 	featuresettings "pin/internal/features/settings"
+	"pin/internal/platform/storage"
 )
 
 // Paging defaults for the admin server view.
@@ -160,6 +162,8 @@ func (h Handler) Server(w http.ResponseWriter, r *http.Request) {
 	auditPrevPage, auditNextPage, auditTotalPages = pageBounds(auditPage, auditPageSize, auditTotal)
 	auditHasMore = auditPage < auditTotalPages
 
+	backupStatus, _ := backup.LoadStatus(r.Context(), h.deps)
+
 	if err := session.Save(r, w); err != nil {
 		http.Error(w, "Session error", http.StatusInternalServerError)
 		return
@@ -209,6 +213,9 @@ func (h Handler) Server(w http.ResponseWriter, r *http.Request) {
 		"AuditHasMore":         auditHasMore,
 		"AuditTotal":           auditTotal,
 		"AuditTotalPages":      auditTotalPages,
+		"BackupInterval":       h.deps.Config().BackupInterval,
+		"BackupDir":            h.deps.Config().BackupDir,
+		"BackupStatus":         backupStatus,
 	}
 
 	if err := h.deps.RenderTemplate(w, "settings_admin.html", data); err != nil {
@@ -249,6 +256,11 @@ func (h Handler) handleServerAction(w http.ResponseWriter, r *http.Request, sett
 			return serverActionResult{}, err
 		}
 		return serverActionResult{wroteResponse: true}, nil
+	case "backup-download":
+		if err := h.downloadBackup(w, r); err != nil {
+			return serverActionResult{}, err
+		}
+		return serverActionResult{wroteResponse: true}, nil
 	case "landing":
 		message, err := h.saveLandingSettings(r, settingsSvc, landing)
 		return serverActionResult{message: message}, err
@@ -296,6 +308,39 @@ func (h Handler) exportUsersCSV(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// downloadBackup streams a fresh backup archive to the admin.
+func (h Handler) downloadBackup(w http.ResponseWriter, r *http.Request) error {
+	current, _ := h.deps.CurrentUser(r)
+	filename := storage.BackupFilename(time.Now())
+	h.deps.AuditAttempt(r.Context(), current.ID, "server.backup.download", filename, nil)
+	// Build the archive in a temp file first so a failed snapshot still yields a proper error page.
+	tmp, err := os.CreateTemp("", "pin-backup-*.zip")
+	if err != nil {
+		h.deps.AuditOutcome(r.Context(), current.ID, "server.backup.download", filename, err, nil)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	err = h.deps.WriteBackup(r.Context(), tmp)
+	h.deps.AuditOutcome(r.Context(), current.ID, "server.backup.download", filename, err, nil)
+	if err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = io.Copy(w, tmp)
+	return nil
+}
+
 // saveLandingSettings updates the landing mode and optional custom HTML asset.
 func (h Handler) saveLandingSettings(r *http.Request, settingsSvc featuresettings.Service, landing featuresettings.LandingSettings) (string, error) {
 	landingMode := strings.TrimSpace(r.FormValue("landing_mode"))
@@ -26,6 +26,7 @@ func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies) {
 	register("/settings/", http.HandlerFunc(requireLogin(handler.Root)))
 	register("/settings/identity", http.HandlerFunc(requireLogin(handler.Profile)))
 	register("/settings/profile", http.HandlerFunc(requireLogin(handler.Profile)))
+	register("/settings/profile/history", http.HandlerFunc(requireLogin(handler.ProfileHistory)))
 	register("/settings/security", http.HandlerFunc(requireLogin(handler.Security)))
 	register("/settings/appearance", http.HandlerFunc(requireLogin(handler.Appearance)))
 	register("/settings/admin/audit-log/download", http.HandlerFunc(requireLogin(auditHandler.Download)))
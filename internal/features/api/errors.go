@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Error codes returned in the "code" member of API errors.
+const (
+	CodeInvalidJSON        = "invalid_json"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodePreconditionFailed = "precondition_failed"
+	CodeInternal           = "internal_error"
+)
+
+// FieldError describes a problem with one value in the request body.
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error is the body of every non-2xx API response.
+type Error struct {
+	Status  int          `json:"status"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// writeError writes err as {"error": {...}}.
+func writeError(w http.ResponseWriter, apiErr Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(apiErr.Status)
+	_ = json.NewEncoder(w).Encode(map[string]Error{"error": apiErr})
+}
+
+// decodeError maps a JSON decoding failure to an API error with a field path when one is known.
+func decodeError(err error) Error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Error{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeValidationFailed,
+			Message: "Request body has invalid fields",
+			Fields:  []FieldError{{Path: typeErr.Field, Message: "must be " + typeErr.Type.String()}},
+		}
+	}
+	// encoding/json has no typed error for unknown fields.
+	if msg := err.Error(); strings.HasPrefix(msg, "json: unknown field ") {
+		field := strings.Trim(strings.TrimPrefix(msg, "json: unknown field "), `"`)
+		return Error{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeValidationFailed,
+			Message: "Request body has invalid fields",
+			Fields:  []FieldError{{Path: field, Message: "unknown field"}},
+		}
+	}
+	return Error{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Message: "Request body must be a JSON object"}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/history"
+	"pin/internal/features/identity"
+)
+
+// maxBodyBytes bounds PATCH/PUT bodies.
+const maxBodyBytes = 1 << 20
+
+type Dependencies interface {
+	apitokens.Store
+	history.Store
+	GetIdentityByHandle(ctx context.Context, handle string) (domain.Identity, error)
+	GetUserByID(ctx context.Context, id int) (domain.User, error)
+	Reserved() map[string]struct{}
+	BaseURL(r *http.Request) string
+	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
+	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
+}
+
+// Handler serves the versioned JSON API.
+type Handler struct {
+	deps Dependencies
+}
+
+// NewHandler constructs a new handler.
+func NewHandler(deps Dependencies) Handler {
+	return Handler{deps: deps}
+}
+
+// Identity serves GET, PATCH and PUT on /api/v1/identities/{handle}.
+func (h Handler) Identity(w http.ResponseWriter, r *http.Request) {
+	handle := strings.TrimPrefix(r.URL.Path, "/api/v1/identities/")
+	if handle == "" || strings.Contains(handle, "/") {
+		writeError(w, Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Identity not found"})
+		return
+	}
+	scope := apitokens.ScopeIdentityWrite
+	action := "identity.update"
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		scope = apitokens.ScopeIdentityReadPrivate
+		action = "identity.read"
+	case http.MethodPatch, http.MethodPut:
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PATCH, PUT")
+		writeError(w, Error{Status: http.StatusMethodNotAllowed, Code: CodeMethodNotAllowed, Message: "Method not allowed"})
+		return
+	}
+
+	token, apiErr := h.authorize(r, scope, action, handle)
+	if apiErr != nil {
+		writeError(w, *apiErr)
+		return
+	}
+	record, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if err != nil || !identity.MatchesIdentity(record, handle) {
+		writeError(w, Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Identity not found"})
+		return
+	}
+	if !h.canAccess(r.Context(), token, record) {
+		writeError(w, Error{Status: http.StatusForbidden, Code: CodeForbidden, Message: "Token cannot access this identity"})
+		return
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		h.writeIdentity(w, r, record, http.StatusOK)
+		return
+	}
+	h.update(w, r, token, record)
+}
+
+// update applies a PATCH or PUT body after checking If-Match against the current rev.
+func (h Handler) update(w http.ResponseWriter, r *http.Request, token domain.APIToken, record domain.Identity) {
+	historySvc := history.NewService(h.deps)
+	baseURL := h.deps.BaseURL(r)
+	if ifMatch := strings.TrimSpace(r.Header.Get("If-Match")); ifMatch != "" && !revMatches(ifMatch, historySvc.Rev(r.Context(), baseURL, record)) {
+		writeError(w, Error{Status: http.StatusPreconditionFailed, Code: CodePreconditionFailed, Message: "Identity has changed since the given rev; fetch it again"})
+		return
+	}
+
+	var input identityInput
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		writeError(w, decodeError(err))
+		return
+	}
+	fields := input.merge(fieldsFromIdentity(record), r.Method == http.MethodPut)
+	if problems := validate(r.Context(), fields, record.ID, h.deps.Reserved(), h.deps.CheckHandleCollision); len(problems) > 0 {
+		writeError(w, Error{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: "Request body has invalid fields", Fields: problems})
+		return
+	}
+
+	updated := apply(record, fields)
+	meta := map[string]string{"via": "api", "token": token.Name}
+	h.deps.AuditAttempt(r.Context(), token.UserID, "profile.update", record.Handle, meta)
+	err := historySvc.Save(r.Context(), baseURL, token.UserID, updated)
+	h.deps.AuditOutcome(r.Context(), token.UserID, "profile.update", updated.Handle, err, meta)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Failed to update identity"})
+		return
+	}
+	saved, err := h.deps.GetIdentityByID(r.Context(), record.ID)
+	if err != nil {
+		writeError(w, Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Failed to load identity"})
+		return
+	}
+	h.writeIdentity(w, r, saved, http.StatusOK)
+}
+
+// authorize resolves the bearer token and checks it grants scope.
+func (h Handler) authorize(r *http.Request, scope, action, target string) (domain.APIToken, *Error) {
+	raw := apitokens.BearerToken(r)
+	if raw == "" {
+		return domain.APIToken{}, &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: "A bearer API token is required"}
+	}
+	token, err := apitokens.NewService(h.deps).Authenticate(r.Context(), raw, scope, action, target)
+	switch {
+	case err == nil:
+		return token, nil
+	case errors.Is(err, apitokens.ErrInsufficientScope):
+		return domain.APIToken{}, &Error{Status: http.StatusForbidden, Code: CodeForbidden, Message: "Token lacks the " + scope + " scope"}
+	case errors.Is(err, apitokens.ErrInvalidToken):
+		return domain.APIToken{}, &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: err.Error()}
+	default:
+		return domain.APIToken{}, &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Failed to check token"}
+	}
+}
+
+// canAccess reports whether the token's user owns the identity or administers the server.
+func (h Handler) canAccess(ctx context.Context, token domain.APIToken, record domain.Identity) bool {
+	if record.UserID == token.UserID {
+		return true
+	}
+	user, err := h.deps.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(user.Role, "admin") || strings.EqualFold(user.Role, "owner")
+}
+
+// writeIdentity writes the resource with the export ETag of its rev, so it matches /{handle}.json.
+func (h Handler) writeIdentity(w http.ResponseWriter, r *http.Request, record domain.Identity, status int) {
+	rev := history.NewService(h.deps).Rev(r.Context(), h.deps.BaseURL(r), record)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if etag := identity.IdentityETag(rev, "json"); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resourceFromIdentity(record, rev))
+}
+
+// revMatches compares an If-Match header against the current rev or its export ETag.
+func revMatches(header, rev string) bool {
+	etag := identity.IdentityETag(rev, "json")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (etag != "" && candidate == etag) || (rev != "" && strings.Trim(candidate, `"`) == rev) {
+			return true
+		}
+	}
+	return false
+}
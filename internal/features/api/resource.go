@@ -0,0 +1,452 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/features/users"
+)
+
+// publicKeyTypes are the public key slots the profile form offers.
+var publicKeyTypes = []string{"pgp", "ssh", "age", "activitypub", "nostr"}
+
+type linkEntry struct {
+	Label      string `json:"label"`
+	URL        string `json:"url"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+type customFieldEntry struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+type walletEntry struct {
+	Label      string `json:"label"`
+	Address    string `json:"address"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// identityFields are the editable identity values, as served and accepted by the API.
+type identityFields struct {
+	Handle        string             `json:"handle"`
+	DisplayName   string             `json:"display_name"`
+	Email         string             `json:"email"`
+	Bio           string             `json:"bio"`
+	Organization  string             `json:"organization"`
+	JobTitle      string             `json:"job_title"`
+	Birthdate     string             `json:"birthdate"`
+	Languages     string             `json:"languages"`
+	Phone         string             `json:"phone"`
+	Address       string             `json:"address"`
+	Location      string             `json:"location"`
+	Website       string             `json:"website"`
+	Pronouns      string             `json:"pronouns"`
+	Timezone      string             `json:"timezone"`
+	ATProtoHandle string             `json:"atproto_handle"`
+	ATProtoDID    string             `json:"atproto_did"`
+	Links         []linkEntry        `json:"links"`
+	CustomFields  []customFieldEntry `json:"custom_fields"`
+	Wallets       []walletEntry      `json:"wallets"`
+	PublicKeys    map[string]string  `json:"public_keys"`
+	NostrRelays   []string           `json:"nostr_relays"`
+	Visibility    map[string]string  `json:"visibility"`
+}
+
+// identityResource is the GET representation; social profiles and verified domains are read-only.
+type identityResource struct {
+	identityFields
+	Social          []domain.SocialProfile `json:"social"`
+	VerifiedDomains []string               `json:"verified_domains"`
+	UpdatedAt       string                 `json:"updated_at"`
+	Rev             string                 `json:"rev"`
+}
+
+// identityInput is a PATCH/PUT body. Absent members are nil so PATCH can leave them untouched.
+// Read-only members are accepted so a fetched resource can be sent back unchanged.
+type identityInput struct {
+	Handle          *string             `json:"handle"`
+	DisplayName     *string             `json:"display_name"`
+	Email           *string             `json:"email"`
+	Bio             *string             `json:"bio"`
+	Organization    *string             `json:"organization"`
+	JobTitle        *string             `json:"job_title"`
+	Birthdate       *string             `json:"birthdate"`
+	Languages       *string             `json:"languages"`
+	Phone           *string             `json:"phone"`
+	Address         *string             `json:"address"`
+	Location        *string             `json:"location"`
+	Website         *string             `json:"website"`
+	Pronouns        *string             `json:"pronouns"`
+	Timezone        *string             `json:"timezone"`
+	ATProtoHandle   *string             `json:"atproto_handle"`
+	ATProtoDID      *string             `json:"atproto_did"`
+	Links           *[]linkEntry        `json:"links"`
+	CustomFields    *[]customFieldEntry `json:"custom_fields"`
+	Wallets         *[]walletEntry      `json:"wallets"`
+	PublicKeys      *map[string]string  `json:"public_keys"`
+	NostrRelays     *[]string           `json:"nostr_relays"`
+	Visibility      map[string]string   `json:"visibility"`
+	Social          json.RawMessage     `json:"social"`
+	VerifiedDomains json.RawMessage     `json:"verified_domains"`
+	UpdatedAt       json.RawMessage     `json:"updated_at"`
+	Rev             json.RawMessage     `json:"rev"`
+}
+
+// fieldsFromIdentity builds the editable view of an identity, including visibility.
+func fieldsFromIdentity(record domain.Identity) identityFields {
+	visibility := identity.DecodeVisibilityMap(record.VisibilityJSON)
+	fields := identityFields{
+		Handle:        record.Handle,
+		DisplayName:   record.DisplayName,
+		Email:         record.Email,
+		Bio:           record.Bio,
+		Organization:  record.Organization,
+		JobTitle:      record.JobTitle,
+		Birthdate:     record.Birthdate,
+		Languages:     record.Languages,
+		Phone:         record.Phone,
+		Address:       record.Address,
+		Location:      record.Location,
+		Website:       record.Website,
+		Pronouns:      record.Pronouns,
+		Timezone:      record.Timezone,
+		ATProtoHandle: record.ATProtoHandle,
+		ATProtoDID:    record.ATProtoDID,
+		Links:         []linkEntry{},
+		CustomFields:  []customFieldEntry{},
+		Wallets:       []walletEntry{},
+		PublicKeys:    map[string]string{},
+		NostrRelays:   identity.DecodeStringSlice(record.NostrRelaysJSON),
+		Visibility:    map[string]string{},
+	}
+	if fields.NostrRelays == nil {
+		fields.NostrRelays = []string{}
+	}
+	for _, field := range users.ProfileVisibilityFields {
+		fields.Visibility[field] = users.NormalizeVisibility(visibility[field])
+	}
+	for _, entry := range users.BuildLinkEntries(identity.DecodeLinks(record.LinksJSON), visibility) {
+		fields.Links = append(fields.Links, linkEntry{Label: entry.Label, URL: entry.URL, Visibility: entry.Visibility})
+	}
+	customFields := identity.DecodeStringMap(record.CustomFieldsJSON)
+	customVisibility := users.VisibilityCustomMap(visibility)
+	for _, key := range sortedKeys(customFields) {
+		fields.CustomFields = append(fields.CustomFields, customFieldEntry{
+			Key:        key,
+			Value:      customFields[key],
+			Visibility: users.NormalizeVisibility(customVisibility[key]),
+		})
+	}
+	for _, entry := range users.BuildWalletEntries(identity.DecodeStringMap(record.WalletsJSON), visibility) {
+		fields.Wallets = append(fields.Wallets, walletEntry{Label: entry.Label, Address: entry.Address, Visibility: entry.Visibility})
+	}
+	for key, value := range identity.DecodeStringMap(record.PublicKeysJSON) {
+		if strings.TrimSpace(value) != "" {
+			fields.PublicKeys[key] = value
+		}
+	}
+	return fields
+}
+
+// resourceFromIdentity builds the full GET representation.
+func resourceFromIdentity(record domain.Identity, rev string) identityResource {
+	social := identity.DecodeSocialProfiles(record.SocialProfilesJSON)
+	if social == nil {
+		social = []domain.SocialProfile{}
+	}
+	verified := identity.DecodeStringSlice(record.VerifiedDomainsJSON)
+	if verified == nil {
+		verified = []string{}
+	}
+	return identityResource{
+		identityFields:  fieldsFromIdentity(record),
+		Social:          social,
+		VerifiedDomains: verified,
+		UpdatedAt:       record.UpdatedAt.UTC().Format(time.RFC3339),
+		Rev:             rev,
+	}
+}
+
+// merge overlays input onto base. With replace set (PUT) absent members are cleared, but
+// visibility the body leaves out keeps its stored value.
+func (in identityInput) merge(base identityFields, replace bool) identityFields {
+	out := base
+	if replace {
+		out = identityFields{Visibility: base.Visibility}
+	}
+	setString := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+	setString(&out.Handle, in.Handle)
+	setString(&out.DisplayName, in.DisplayName)
+	setString(&out.Email, in.Email)
+	setString(&out.Bio, in.Bio)
+	setString(&out.Organization, in.Organization)
+	setString(&out.JobTitle, in.JobTitle)
+	setString(&out.Birthdate, in.Birthdate)
+	setString(&out.Languages, in.Languages)
+	setString(&out.Phone, in.Phone)
+	setString(&out.Address, in.Address)
+	setString(&out.Location, in.Location)
+	setString(&out.Website, in.Website)
+	setString(&out.Pronouns, in.Pronouns)
+	setString(&out.Timezone, in.Timezone)
+	setString(&out.ATProtoHandle, in.ATProtoHandle)
+	setString(&out.ATProtoDID, in.ATProtoDID)
+	if in.Links != nil {
+		stored := map[string]string{}
+		for _, link := range base.Links {
+			stored[strings.ToLower(strings.TrimSpace(link.URL))] = link.Visibility
+		}
+		out.Links = nil
+		for _, link := range *in.Links {
+			if normalizeVisibility(link.Visibility) == "" {
+				link.Visibility = stored[strings.ToLower(strings.TrimSpace(link.URL))]
+			}
+			out.Links = append(out.Links, link)
+		}
+	}
+	if in.CustomFields != nil {
+		stored := map[string]string{}
+		for _, field := range base.CustomFields {
+			stored[strings.TrimSpace(field.Key)] = field.Visibility
+		}
+		out.CustomFields = nil
+		for _, field := range *in.CustomFields {
+			if normalizeVisibility(field.Visibility) == "" {
+				field.Visibility = stored[strings.TrimSpace(field.Key)]
+			}
+			out.CustomFields = append(out.CustomFields, field)
+		}
+	}
+	if in.Wallets != nil {
+		stored := map[string]string{}
+		for _, wallet := range base.Wallets {
+			stored[strings.ToUpper(strings.TrimSpace(wallet.Label))] = wallet.Visibility
+		}
+		out.Wallets = nil
+		for _, wallet := range *in.Wallets {
+			if normalizeVisibility(wallet.Visibility) == "" {
+				wallet.Visibility = stored[strings.ToUpper(strings.TrimSpace(wallet.Label))]
+			}
+			out.Wallets = append(out.Wallets, wallet)
+		}
+	}
+	if in.PublicKeys != nil {
+		out.PublicKeys = *in.PublicKeys
+	}
+	if in.NostrRelays != nil {
+		out.NostrRelays = *in.NostrRelays
+	}
+	// Visibility merges per key so PATCH can flip a single field.
+	visibility := map[string]string{}
+	for key, value := range out.Visibility {
+		visibility[key] = value
+	}
+	for key, value := range in.Visibility {
+		visibility[key] = value
+	}
+	out.Visibility = visibility
+	return out
+}
+
+// validate checks fields with the same rules as the profile settings form.
+func validate(ctx context.Context, fields identityFields, identityID int, reserved map[string]struct{}, checkCollision func(context.Context, string, int) error) []FieldError {
+	var problems []FieldError
+	add := func(path, message string) {
+		problems = append(problems, FieldError{Path: path, Message: message})
+	}
+	checkVisibility := func(path, value string) {
+		if !validVisibility(value) {
+			add(path, `must be "public" or "private"`)
+		}
+	}
+
+	if err := identity.ValidateHandle(ctx, fields.Handle, identityID, reserved, checkCollision); err != nil {
+		add("handle", err.Error())
+	}
+	allowed := map[string]bool{}
+	for _, field := range users.ProfileVisibilityFields {
+		allowed[field] = true
+	}
+	for _, key := range sortedKeys(fields.Visibility) {
+		if !allowed[key] {
+			add("visibility."+key, "unknown field")
+			continue
+		}
+		checkVisibility("visibility."+key, fields.Visibility[key])
+	}
+	for i, link := range fields.Links {
+		path := fmt.Sprintf("links[%d]", i)
+		if strings.TrimSpace(link.Label) == "" {
+			add(path+".label", "is required")
+		}
+		if strings.TrimSpace(link.URL) == "" {
+			add(path+".url", "is required")
+		}
+		checkVisibility(path+".visibility", link.Visibility)
+	}
+	seenCustom := map[string]bool{}
+	for i, field := range fields.CustomFields {
+		path := fmt.Sprintf("custom_fields[%d]", i)
+		key := strings.TrimSpace(field.Key)
+		if key == "" {
+			add(path+".key", "is required")
+		} else if seenCustom[key] {
+			add(path+".key", "Custom field keys must be unique")
+		}
+		seenCustom[key] = true
+		if strings.TrimSpace(field.Value) == "" {
+			add(path+".value", "is required")
+		}
+		checkVisibility(path+".visibility", field.Visibility)
+	}
+	seenWallet := map[string]bool{}
+	for i, wallet := range fields.Wallets {
+		path := fmt.Sprintf("wallets[%d]", i)
+		label := strings.ToUpper(strings.TrimSpace(wallet.Label))
+		if label == "" {
+			add(path+".label", "is required")
+		} else if seenWallet[label] {
+			add(path+".label", "Wallet labels must be unique")
+		}
+		seenWallet[label] = true
+		if strings.TrimSpace(wallet.Address) == "" {
+			add(path+".address", "is required")
+		}
+		checkVisibility(path+".visibility", wallet.Visibility)
+	}
+	knownKeys := map[string]bool{}
+	for _, keyType := range publicKeyTypes {
+		knownKeys[keyType] = true
+	}
+	for _, key := range sortedKeys(fields.PublicKeys) {
+		if !knownKeys[key] {
+			add("public_keys."+key, "unknown key type")
+		}
+	}
+	if _, err := users.ParseNostrForm(fields.PublicKeys["nostr"], strings.Join(fields.NostrRelays, "\n")); err != nil {
+		add("nostr_relays", err.Error())
+	}
+	return problems
+}
+
+// apply writes validated fields onto the identity, encoding them exactly as the profile form does.
+func apply(record domain.Identity, fields identityFields) domain.Identity {
+	record.Handle = strings.TrimSpace(fields.Handle)
+	record.DisplayName = strings.TrimSpace(fields.DisplayName)
+	record.Email = strings.TrimSpace(fields.Email)
+	record.Bio = strings.TrimSpace(fields.Bio)
+	record.Organization = strings.TrimSpace(fields.Organization)
+	record.JobTitle = strings.TrimSpace(fields.JobTitle)
+	record.Birthdate = strings.TrimSpace(fields.Birthdate)
+	record.Languages = strings.TrimSpace(fields.Languages)
+	record.Phone = strings.TrimSpace(fields.Phone)
+	record.Address = strings.TrimSpace(fields.Address)
+	record.Location = strings.TrimSpace(fields.Location)
+	record.Website = strings.TrimSpace(fields.Website)
+	record.Pronouns = strings.TrimSpace(fields.Pronouns)
+	record.Timezone = strings.TrimSpace(fields.Timezone)
+	record.ATProtoHandle = strings.TrimSpace(fields.ATProtoHandle)
+	record.ATProtoDID = strings.TrimSpace(fields.ATProtoDID)
+
+	var linkLabels, linkURLs, linkVisibilities []string
+	for _, link := range fields.Links {
+		linkLabels = append(linkLabels, link.Label)
+		linkURLs = append(linkURLs, link.URL)
+		linkVisibilities = append(linkVisibilities, normalizeVisibility(link.Visibility))
+	}
+	links, linkVisibility := users.ParseLinksForm(linkLabels, linkURLs, linkVisibilities)
+	links = identity.MergeLinks(links, identity.DecodeLinks(record.LinksJSON))
+	record.LinksJSON = identity.EncodeLinks(links)
+
+	var customKeys, customValues, customVisibilities []string
+	for _, field := range fields.CustomFields {
+		customKeys = append(customKeys, field.Key)
+		customValues = append(customValues, field.Value)
+		customVisibilities = append(customVisibilities, normalizeVisibility(field.Visibility))
+	}
+	customFields := users.ParseCustomFieldsForm(customKeys, customValues)
+	customVisibility := users.ParseCustomVisibilityForm(customKeys, customValues, customVisibilities)
+	if customJSON, err := json.Marshal(customFields); err == nil {
+		record.CustomFieldsJSON = string(customJSON)
+	}
+
+	var walletLabels, walletAddresses, walletVisibilities []string
+	for _, wallet := range fields.Wallets {
+		walletLabels = append(walletLabels, wallet.Label)
+		walletAddresses = append(walletAddresses, wallet.Address)
+		walletVisibilities = append(walletVisibilities, normalizeVisibility(wallet.Visibility))
+	}
+	// Duplicate labels were rejected by validate, so the error is unreachable here.
+	wallets, walletVisibility, _ := users.ParseWalletForm(walletLabels, walletAddresses, walletVisibilities)
+	if walletsJSON, err := json.Marshal(identity.StripEmptyMap(wallets)); err == nil {
+		record.WalletsJSON = string(walletsJSON)
+	}
+
+	publicKeys := map[string]string{}
+	for _, keyType := range publicKeyTypes {
+		publicKeys[keyType] = strings.TrimSpace(fields.PublicKeys[keyType])
+	}
+	if keysJSON, err := json.Marshal(identity.StripEmptyMap(publicKeys)); err == nil {
+		record.PublicKeysJSON = string(keysJSON)
+	}
+	// Invalid relays were rejected by validate.
+	relays, _ := users.ParseNostrForm("", strings.Join(fields.NostrRelays, "\n"))
+	record.NostrRelaysJSON = identity.EncodeStringSlice(relays)
+
+	fieldVisibility := map[string]string{}
+	for _, field := range users.ProfileVisibilityFields {
+		fieldVisibility[field] = users.NormalizeVisibility(normalizeVisibility(fields.Visibility[field]))
+	}
+	for key, value := range walletVisibility {
+		fieldVisibility[key] = value
+	}
+	visibility := users.BuildVisibilityMap(fieldVisibility, users.FilterCustomVisibility(customFields, customVisibility))
+	for key, value := range linkVisibility {
+		visibility[key] = value
+	}
+	// Social profiles and verified domains are not editable here; keep their visibility.
+	for key, value := range identity.DecodeVisibilityMap(record.VisibilityJSON) {
+		if strings.HasPrefix(key, "social:") || strings.HasPrefix(key, "verified_domain:") {
+			visibility[key] = value
+		}
+	}
+	record.VisibilityJSON = identity.EncodeVisibilityMap(visibility)
+	return record
+}
+
+// normalizeVisibility lowercases and trims a visibility value.
+func normalizeVisibility(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// validVisibility reports whether value is empty or a known visibility.
+func validVisibility(value string) bool {
+	switch normalizeVisibility(value) {
+	case "", "public", "private":
+		return true
+	default:
+		return false
+	}
+}
+
+// sortedKeys returns map keys in ascending order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"net/http"
+
+	"pin/internal/platform/transport"
+)
+
+// Register registers routes and handlers.
+func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies) {
+	handler := NewHandler(deps)
+	reg.RegisterRoute(mux, "/api/v1/identities/", http.HandlerFunc(handler.Identity))
+}
@@ -0,0 +1,141 @@
+package apitokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"pin/internal/domain"
+)
+
+// createdTokenKey is the request context key carrying a newly created token to the security page.
+type createdTokenKey struct{}
+
+// Expiry choices offered on the settings page, in days; zero never expires.
+var ExpiryDays = []int{30, 90, 365, 0}
+
+type Dependencies interface {
+	Store
+	GetSession(r *http.Request, name string) (*sessions.Session, error)
+	ValidateCSRF(session *sessions.Session, token string) bool
+	CurrentUser(r *http.Request) (domain.User, error)
+	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
+	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
+}
+
+// Handler manages personal API tokens from the security settings page.
+type Handler struct {
+	deps Dependencies
+	page http.HandlerFunc
+}
+
+// NewHandler constructs a new handler. page renders the security settings page.
+func NewHandler(deps Dependencies, page http.HandlerFunc) Handler {
+	return Handler{deps: deps, page: page}
+}
+
+// CreatedToken returns the plaintext of a token created by this request, if any.
+func CreatedToken(ctx context.Context) string {
+	raw, _ := ctx.Value(createdTokenKey{}).(string)
+	return raw
+}
+
+// Create issues a token and renders its plaintext into the security page once.
+func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.authorizeForm(w, r)
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(r.FormValue("expires_days")))
+	if err != nil || days < 0 {
+		days = 0
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	meta := map[string]string{"token": name, "scopes": strings.Join(r.Form["scope"], " ")}
+	h.deps.AuditAttempt(r.Context(), current.ID, "api_token.create", name, meta)
+	raw, _, err := NewService(h.deps).Create(r.Context(), current.ID, name, r.Form["scope"], time.Duration(days)*24*time.Hour)
+	h.deps.AuditOutcome(r.Context(), current.ID, "api_token.create", name, err, meta)
+	if err != nil {
+		if isInputError(err) {
+			redirectToSection(w, r, err.Error())
+			return
+		}
+		http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+	// The plaintext goes into this response only, never into a cookie or a redirect.
+	page := r.Clone(context.WithValue(r.Context(), createdTokenKey{}, raw))
+	page.Method = http.MethodGet
+	page.URL.RawQuery = url.Values{"toast": {"Token created. Copy it now; it will not be shown again."}}.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	h.page(w, page)
+}
+
+// Revoke disables a token immediately.
+func (h Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.authorizeForm(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(r.FormValue("token_id")))
+	if err != nil || id <= 0 {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	meta := map[string]string{"token_id": strconv.Itoa(id)}
+	h.deps.AuditAttempt(r.Context(), current.ID, "api_token.revoke", "", meta)
+	err = NewService(h.deps).Revoke(r.Context(), current.ID, id)
+	h.deps.AuditOutcome(r.Context(), current.ID, "api_token.revoke", "", err, meta)
+	if err != nil {
+		redirectToSection(w, r, "Token not found or already revoked.")
+		return
+	}
+	redirectToSection(w, r, "Token revoked.")
+}
+
+// authorizeForm checks method, CSRF and session for token form posts.
+func (h Handler) authorizeForm(w http.ResponseWriter, r *http.Request) (domain.User, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return domain.User{}, false
+	}
+	session, _ := h.deps.GetSession(r, "pin_session")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return domain.User{}, false
+	}
+	if !h.deps.ValidateCSRF(session, r.FormValue("csrf_token")) {
+		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		return domain.User{}, false
+	}
+	current, err := h.deps.CurrentUser(r)
+	if err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return domain.User{}, false
+	}
+	return current, true
+}
+
+// BearerToken returns the token from an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(auth[7:])
+}
+
+// isInputError reports whether err should be shown back to the user.
+func isInputError(err error) bool {
+	return errors.Is(err, ErrNameRequired) || errors.Is(err, ErrNameTooLong) || errors.Is(err, ErrUnknownScope) || errors.Is(err, ErrNoScopes)
+}
+
+// redirectToSection returns to the API tokens section with a toast.
+func redirectToSection(w http.ResponseWriter, r *http.Request, toast string) {
+	http.Redirect(w, r, "/settings/security?toast="+url.QueryEscape(toast)+"#section-api-tokens", http.StatusFound)
+}
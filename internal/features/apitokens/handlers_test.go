@@ -0,0 +1,68 @@
+package apitokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"pin/internal/domain"
+)
+
+// handlerDeps adds a cookie session and a logged-in user to fakeStore.
+type handlerDeps struct {
+	*fakeStore
+	sessions sessions.Store
+}
+
+// GetSession returns the named cookie session.
+func (d handlerDeps) GetSession(r *http.Request, name string) (*sessions.Session, error) {
+	return d.sessions.Get(r, name)
+}
+
+// ValidateCSRF accepts any token.
+func (d handlerDeps) ValidateCSRF(session *sessions.Session, token string) bool { return true }
+
+// CurrentUser returns the logged-in user.
+func (d handlerDeps) CurrentUser(r *http.Request) (domain.User, error) {
+	return domain.User{ID: 1}, nil
+}
+
+// AuditAttempt discards the entry.
+func (d handlerDeps) AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string) {
+}
+
+// AuditOutcome discards the entry.
+func (d handlerDeps) AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string) {
+}
+
+// TestCreateRendersTokenOnce verifies the plaintext token is rendered into the create response
+// and never written to a cookie.
+func TestCreateRendersTokenOnce(t *testing.T) {
+	deps := handlerDeps{fakeStore: &fakeStore{}, sessions: sessions.NewCookieStore([]byte("test-secret"))}
+	page := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected the page to be rendered as a GET, got %s", r.Method)
+		}
+		_, _ = w.Write([]byte(CreatedToken(r.Context())))
+	}
+	form := url.Values{"name": {"ci"}, "scope": {ScopeIdentityWrite}, "expires_days": {"30"}}
+	req := httptest.NewRequest(http.MethodPost, "/settings/security/tokens", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	NewHandler(deps, page).Create(rec, req)
+
+	raw := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(raw, TokenPrefix) || HashToken(raw) != deps.tokens[0].TokenHash {
+		t.Fatalf("expected the new token in the response, got %d %q", rec.Code, raw)
+	}
+	if cookies := rec.Header().Values("Set-Cookie"); len(cookies) > 0 {
+		t.Fatalf("expected no cookies, got %v", cookies)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("expected the response to be uncacheable")
+	}
+}
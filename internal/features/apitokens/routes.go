@@ -0,0 +1,21 @@
+package apitokens
+
+import (
+	"net/http"
+
+	"pin/internal/platform/transport"
+)
+
+// Register registers routes and handlers. page renders the security settings page.
+func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies, page http.HandlerFunc) {
+	register := func(pattern string, handler http.Handler) {
+		reg.RegisterRoute(mux, pattern, handler)
+	}
+	requireLogin := func(next http.HandlerFunc) http.HandlerFunc {
+		return reg.RequireSession(next, "/login?next=/settings")
+	}
+
+	handler := NewHandler(deps, page)
+	register("/settings/security/tokens", http.HandlerFunc(requireLogin(handler.Create)))
+	register("/settings/security/tokens/revoke", http.HandlerFunc(requireLogin(handler.Revoke)))
+}
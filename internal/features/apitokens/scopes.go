@@ -0,0 +1,72 @@
+package apitokens
+
+import "strings"
+
+// Scopes a personal API token can be granted.
+const (
+	ScopeIdentityReadPrivate = "identity:read-private"
+	ScopeIdentityWrite       = "identity:write"
+	ScopePicturesWrite       = "pictures:write"
+	ScopeDomainsWrite        = "domains:write"
+	ScopeMCP                 = "mcp"
+)
+
+// ScopeInfo describes a scope for the settings page.
+type ScopeInfo struct {
+	Name        string
+	Description string
+}
+
+// Scopes lists the grantable scopes in display order.
+var Scopes = []ScopeInfo{
+	{Name: ScopeIdentityReadPrivate, Description: "Read your full identity, including private fields"},
+	{Name: ScopeIdentityWrite, Description: "Edit your profile fields"},
+	{Name: ScopePicturesWrite, Description: "Upload, select and delete profile pictures"},
+	{Name: ScopeDomainsWrite, Description: "Add, verify and remove domains"},
+	{Name: ScopeMCP, Description: "Connect MCP clients to /mcp; combine with the scopes above for private views and tools"},
+}
+
+// IsScope reports whether name is a known scope.
+func IsScope(name string) bool {
+	for _, scope := range Scopes {
+		if scope.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeScopes trims, validates and de-duplicates scopes, keeping display order.
+func normalizeScopes(requested []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, raw := range requested {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if !IsScope(name) {
+			return nil, ErrUnknownScope
+		}
+		seen[name] = true
+	}
+	var out []string
+	for _, scope := range Scopes {
+		if seen[scope.Name] {
+			out = append(out, scope.Name)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrNoScopes
+	}
+	return out, nil
+}
+
+// HasScope reports whether the token grants scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
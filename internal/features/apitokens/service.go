@@ -0,0 +1,145 @@
+package apitokens
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/platform/core"
+)
+
+// TokenPrefix marks personal API tokens so they are recognizable in logs and secret scanners.
+const TokenPrefix = "pin_pat_"
+
+// MaxNameLength bounds token names.
+const MaxNameLength = 64
+
+var (
+	// ErrInvalidToken covers unknown, malformed, revoked and expired tokens.
+	ErrInvalidToken = errors.New("invalid or expired API token")
+	// ErrInsufficientScope indicates the token lacks the scope an action needs.
+	ErrInsufficientScope = errors.New("API token lacks the required scope")
+	ErrNameRequired      = errors.New("token name is required")
+	ErrNameTooLong       = errors.New("token name is too long")
+	ErrUnknownScope      = errors.New("unknown token scope")
+	ErrNoScopes          = errors.New("select at least one scope")
+)
+
+// Store persists API tokens and records their use.
+type Store interface {
+	CreateAPIToken(ctx context.Context, token domain.APIToken) (int64, error)
+	ListAPITokens(ctx context.Context, userID int) ([]domain.APIToken, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error)
+	TouchAPIToken(ctx context.Context, id int, usedAt time.Time) error
+	RevokeAPIToken(ctx context.Context, userID, id int) error
+	WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error
+}
+
+// Service issues, lists, revokes and authenticates personal API tokens.
+type Service struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewService constructs a new service.
+func NewService(store Store) Service {
+	return Service{store: store, now: time.Now}
+}
+
+// Create issues a token and returns its plaintext value, which is never stored.
+// A zero ttl creates a token that does not expire.
+func (s Service) Create(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (string, domain.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", domain.APIToken{}, ErrNameRequired
+	}
+	if len(name) > MaxNameLength {
+		return "", domain.APIToken{}, ErrNameTooLong
+	}
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", domain.APIToken{}, err
+	}
+	raw := TokenPrefix + core.RandomToken(32)
+	now := s.now().UTC()
+	token := domain.APIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: HashToken(raw),
+		Prefix:    raw[:len(TokenPrefix)+6],
+		Scopes:    normalized,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		token.ExpiresAt = sql.NullTime{Time: now.Add(ttl), Valid: true}
+	}
+	id, err := s.store.CreateAPIToken(ctx, token)
+	if err != nil {
+		return "", domain.APIToken{}, err
+	}
+	token.ID = int(id)
+	return raw, token, nil
+}
+
+// List returns the user's tokens, newest first.
+func (s Service) List(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	return s.store.ListAPITokens(ctx, userID)
+}
+
+// Revoke disables one of the user's tokens.
+func (s Service) Revoke(ctx context.Context, userID, id int) error {
+	return s.store.RevokeAPIToken(ctx, userID, id)
+}
+
+// Authenticate resolves a plaintext token that must grant scope, and audit-logs the use under the token's name.
+// action and target describe what the caller is about to do with the token.
+func (s Service) Authenticate(ctx context.Context, raw, scope, action, target string) (domain.APIToken, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, TokenPrefix) {
+		return domain.APIToken{}, ErrInvalidToken
+	}
+	token, err := s.store.GetAPITokenByHash(ctx, HashToken(raw))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.APIToken{}, ErrInvalidToken
+		}
+		return domain.APIToken{}, err
+	}
+	now := s.now().UTC()
+	if !Active(token, now) {
+		return domain.APIToken{}, ErrInvalidToken
+	}
+	meta := map[string]string{
+		"token":    token.Name,
+		"token_id": strconv.Itoa(token.ID),
+		"scope":    scope,
+		"action":   action,
+	}
+	if !HasScope(token.Scopes, scope) {
+		meta["status"] = "denied"
+		_ = s.store.WriteAuditLog(ctx, token.UserID, "api_token.use", target, meta)
+		return domain.APIToken{}, ErrInsufficientScope
+	}
+	meta["status"] = "allowed"
+	_ = s.store.WriteAuditLog(ctx, token.UserID, "api_token.use", target, meta)
+	_ = s.store.TouchAPIToken(ctx, token.ID, now)
+	token.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+	return token, nil
+}
+
+// Active reports whether the token is neither revoked nor expired at now.
+func Active(token domain.APIToken, now time.Time) bool {
+	if token.RevokedAt.Valid {
+		return false
+	}
+	return !token.ExpiresAt.Valid || now.Before(token.ExpiresAt.Time)
+}
+
+// HashToken returns the at-rest form of a plaintext token.
+func HashToken(raw string) string {
+	return core.Sha256Hex(raw)
+}
@@ -0,0 +1,140 @@
+package apitokens
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// fakeStore keeps API tokens and audit metadata in memory.
+type fakeStore struct {
+	tokens []domain.APIToken
+	audits []map[string]string
+}
+
+// CreateAPIToken appends the token and assigns the next ID.
+func (s *fakeStore) CreateAPIToken(ctx context.Context, token domain.APIToken) (int64, error) {
+	token.ID = len(s.tokens) + 1
+	s.tokens = append(s.tokens, token)
+	return int64(token.ID), nil
+}
+
+// ListAPITokens returns every stored token.
+func (s *fakeStore) ListAPITokens(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	return s.tokens, nil
+}
+
+// GetAPITokenByHash returns the token with the given hash.
+func (s *fakeStore) GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error) {
+	for _, token := range s.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return domain.APIToken{}, sql.ErrNoRows
+}
+
+// TouchAPIToken records the last use time.
+func (s *fakeStore) TouchAPIToken(ctx context.Context, id int, usedAt time.Time) error {
+	s.tokens[id-1].LastUsedAt = sql.NullTime{Time: usedAt, Valid: true}
+	return nil
+}
+
+// RevokeAPIToken marks the user's token revoked.
+func (s *fakeStore) RevokeAPIToken(ctx context.Context, userID, id int) error {
+	if id < 1 || id > len(s.tokens) || s.tokens[id-1].UserID != userID {
+		return sql.ErrNoRows
+	}
+	s.tokens[id-1].RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// WriteAuditLog records the entry metadata.
+func (s *fakeStore) WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error {
+	s.audits = append(s.audits, metadata)
+	return nil
+}
+
+// TestCreateStoresOnlyHash verifies only the token hash is stored, scopes are normalised and
+// unknown or missing scopes are rejected.
+func TestCreateStoresOnlyHash(t *testing.T) {
+	store := &fakeStore{}
+	raw, token, err := NewService(store).Create(context.Background(), 1, " CI ", []string{ScopeIdentityWrite, "IDENTITY:READ-PRIVATE", ScopeIdentityWrite}, 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(raw, TokenPrefix) || !strings.HasPrefix(raw, token.Prefix) {
+		t.Fatalf("unexpected token %q with prefix %q", raw, token.Prefix)
+	}
+	stored := store.tokens[0]
+	if stored.TokenHash == raw || stored.TokenHash != HashToken(raw) {
+		t.Fatalf("expected hashed token at rest")
+	}
+	if stored.Name != "CI" || strings.Join(stored.Scopes, " ") != "identity:read-private identity:write" {
+		t.Fatalf("unexpected stored token: %+v", stored)
+	}
+	if stored.ExpiresAt.Valid {
+		t.Fatalf("expected no expiry")
+	}
+
+	if _, _, err := NewService(store).Create(context.Background(), 1, "x", []string{"admin"}, 0); !errors.Is(err, ErrUnknownScope) {
+		t.Fatalf("expected ErrUnknownScope, got %v", err)
+	}
+	if _, _, err := NewService(store).Create(context.Background(), 1, "x", nil, 0); !errors.Is(err, ErrNoScopes) {
+		t.Fatalf("expected ErrNoScopes, got %v", err)
+	}
+}
+
+// TestAuthenticate verifies scope checks, use tracking and auditing, and that expired and
+// revoked tokens are refused.
+func TestAuthenticate(t *testing.T) {
+	store := &fakeStore{}
+	svc := NewService(store)
+	ctx := context.Background()
+	raw, _, err := svc.Create(ctx, 1, "deploy", []string{ScopeIdentityWrite}, time.Hour)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	token, err := svc.Authenticate(ctx, raw, ScopeIdentityWrite, "identity.update", "alice")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if token.UserID != 1 || !store.tokens[0].LastUsedAt.Valid {
+		t.Fatalf("expected use to be recorded: %+v", store.tokens[0])
+	}
+	if len(store.audits) != 1 || store.audits[0]["token"] != "deploy" || store.audits[0]["status"] != "allowed" {
+		t.Fatalf("unexpected audit: %+v", store.audits)
+	}
+
+	if _, err := svc.Authenticate(ctx, raw, ScopeDomainsWrite, "domain.create", "alice"); !errors.Is(err, ErrInsufficientScope) {
+		t.Fatalf("expected ErrInsufficientScope, got %v", err)
+	}
+	if store.audits[1]["status"] != "denied" {
+		t.Fatalf("expected denied use to be audited: %+v", store.audits[1])
+	}
+	if _, err := svc.Authenticate(ctx, TokenPrefix+"unknown", ScopeIdentityWrite, "identity.update", "alice"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := svc.Authenticate(ctx, raw, ScopeIdentityWrite, "identity.update", "alice"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected expired token to fail, got %v", err)
+	}
+
+	svc.now = time.Now
+	if err := svc.Revoke(ctx, 1, 1); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, raw, ScopeIdentityWrite, "identity.update", "alice"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected revoked token to fail, got %v", err)
+	}
+	if err := svc.Revoke(ctx, 2, 1); err == nil {
+		t.Fatalf("expected revoke by another user to fail")
+	}
+}
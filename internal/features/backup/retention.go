@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"pin/internal/platform/storage"
+)
+
+const (
+	filenamePrefix = "pin-backup-"
+	filenameLayout = "20060102-150405"
+)
+
+// RetentionPolicy selects which archives to keep. A zero policy keeps everything.
+type RetentionPolicy struct {
+	// Keep retains the N most recent archives.
+	Keep int
+	// KeepDaily retains the newest archive for each of the last N days that have one.
+	KeepDaily int
+	// KeepWeekly retains the newest archive for each of the last N ISO weeks that have one.
+	KeepWeekly int
+}
+
+// IsZero reports whether the policy keeps every archive.
+func (p RetentionPolicy) IsZero() bool {
+	return p.Keep <= 0 && p.KeepDaily <= 0 && p.KeepWeekly <= 0
+}
+
+// Filename returns the archive name for a backup taken at t, matching pin backup.
+func Filename(t time.Time) string {
+	return storage.BackupFilename(t)
+}
+
+type archive struct {
+	name  string
+	taken time.Time
+}
+
+// Prune deletes archives in dir that fall outside the policy and returns the removed names.
+func Prune(dir string, policy RetentionPolicy) ([]string, error) {
+	if policy.IsZero() {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var archives []archive
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if taken, ok := parseFilename(entry.Name()); ok {
+			archives = append(archives, archive{name: entry.Name(), taken: taken})
+		}
+	}
+	var removed []string
+	keep := selectRetained(archives, policy)
+	for _, a := range archives {
+		if keep[a.name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, a.name)); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed = append(removed, a.name)
+	}
+	return removed, nil
+}
+
+// selectRetained returns the set of archive names kept by the policy.
+func selectRetained(archives []archive, policy RetentionPolicy) map[string]bool {
+	sort.Slice(archives, func(i, j int) bool { return archives[i].taken.After(archives[j].taken) })
+	keep := map[string]bool{}
+	for i := 0; i < len(archives) && i < policy.Keep; i++ {
+		keep[archives[i].name] = true
+	}
+	keepBuckets(archives, policy.KeepDaily, keep, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepBuckets(archives, policy.KeepWeekly, keep, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	})
+	return keep
+}
+
+// keepBuckets keeps the newest archive in each of the first n distinct buckets.
+func keepBuckets(archives []archive, n int, keep map[string]bool, bucket func(time.Time) string) {
+	seen := map[string]bool{}
+	for _, a := range archives {
+		if len(seen) >= n {
+			return
+		}
+		key := bucket(a.taken)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keep[a.name] = true
+	}
+}
+
+// parseFilename extracts the timestamp from a backup archive name.
+func parseFilename(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filenamePrefix) || !strings.HasSuffix(name, ".zip") {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, filenamePrefix), ".zip")
+	taken, err := time.Parse(filenameLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return taken, true
+}
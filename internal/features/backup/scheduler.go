@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config controls the in-process backup schedule and retention.
+type Config struct {
+	Interval time.Duration
+	Dir      string
+	Policy   RetentionPolicy
+}
+
+// RunFunc writes a backup archive to dest and returns the path written.
+type RunFunc func(ctx context.Context, dest string) (string, error)
+
+// Store persists schedule status and audit entries.
+type Store interface {
+	StatusStore
+	SetSettings(ctx context.Context, values map[string]string) error
+	WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error
+}
+
+// Scheduler runs backups on a fixed interval and prunes old archives.
+type Scheduler struct {
+	cfg   Config
+	run   RunFunc
+	store Store
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewScheduler constructs a new scheduler.
+func NewScheduler(cfg Config, run RunFunc, store Store) *Scheduler {
+	return &Scheduler{cfg: cfg, run: run, store: store, now: time.Now}
+}
+
+// Start runs the schedule in the background until ctx is canceled.
+func (s *Scheduler) Start(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+	go s.loop(ctx)
+}
+
+// loop waits for the next due time, runs a backup, and repeats.
+func (s *Scheduler) loop(ctx context.Context) {
+	delay := s.cfg.Interval
+	// Resume the cadence across restarts instead of waiting a full interval again.
+	if status, err := LoadStatus(ctx, s.store); err == nil && !status.LastRunAt.IsZero() {
+		delay = s.cfg.Interval - s.now().Sub(status.LastRunAt)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Printf("scheduled backup failed: %v", err)
+			}
+			timer.Reset(s.cfg.Interval)
+		}
+	}
+}
+
+// RunOnce takes a backup, applies retention, and records the outcome.
+func (s *Scheduler) RunOnce(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	startedAt := s.now().UTC()
+	dest := filepath.Join(s.cfg.Dir, Filename(startedAt))
+	path, pruned, err := s.backupAndPrune(ctx, dest)
+
+	meta := map[string]string{"status": "success", "source": "schedule"}
+	values := map[string]string{lastRunAtKey: startedAt.Format(time.RFC3339)}
+	if err != nil {
+		meta["status"] = "failure"
+		meta["error"] = err.Error()
+		values[lastFailureAtKey] = startedAt.Format(time.RFC3339)
+		values[lastErrorKey] = err.Error()
+	} else {
+		meta["pruned"] = strconv.Itoa(len(pruned))
+		values[lastSuccessAtKey] = startedAt.Format(time.RFC3339)
+		values[lastSuccessPathKey] = path
+	}
+	_ = s.store.WriteAuditLog(ctx, 0, "server.backup.scheduled", filepath.Base(dest), meta)
+	if saveErr := s.store.SetSettings(ctx, values); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return path, err
+}
+
+// backupAndPrune writes the archive and removes archives outside the retention policy.
+func (s *Scheduler) backupAndPrune(ctx context.Context, dest string) (string, []string, error) {
+	if err := os.MkdirAll(s.cfg.Dir, 0o755); err != nil {
+		return "", nil, err
+	}
+	path, err := s.run(ctx, dest)
+	if err != nil {
+		return "", nil, err
+	}
+	pruned, err := Prune(s.cfg.Dir, s.cfg.Policy)
+	if err != nil {
+		return path, pruned, fmt.Errorf("prune backups: %w", err)
+	}
+	return path, pruned, nil
+}
@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory Store recording audit entries.
+type fakeStore struct {
+	settings map[string]string
+	audits   []map[string]string
+	actions  []string
+}
+
+// newFakeStore returns an empty fake store.
+func newFakeStore() *fakeStore {
+	return &fakeStore{settings: map[string]string{}}
+}
+
+// GetSettings returns the stored values for keys.
+func (s *fakeStore) GetSettings(ctx context.Context, keys ...string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, key := range keys {
+		if value, ok := s.settings[key]; ok {
+			out[key] = value
+		}
+	}
+	return out, nil
+}
+
+// SetSettings stores values.
+func (s *fakeStore) SetSettings(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		s.settings[key] = value
+	}
+	return nil
+}
+
+// WriteAuditLog records the action and its metadata.
+func (s *fakeStore) WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error {
+	s.actions = append(s.actions, action)
+	s.audits = append(s.audits, metadata)
+	return nil
+}
+
+// TestRunOnceRecordsSuccessAndPrunes verifies a successful run records its status, audits it and
+// prunes archives outside the retention policy.
+func TestRunOnceRecordsSuccessAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"pin-backup-20240101-000000.zip",
+		"pin-backup-20240102-000000.zip",
+		"pin-backup-20240103-000000.zip",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	store := newFakeStore()
+	run := func(ctx context.Context, dest string) (string, error) {
+		return dest, os.WriteFile(dest, []byte("zip"), 0o644)
+	}
+	scheduler := NewScheduler(Config{Interval: time.Hour, Dir: dir, Policy: RetentionPolicy{Keep: 2}}, run, store)
+	scheduler.now = func() time.Time { return time.Date(2024, 1, 4, 3, 0, 0, 0, time.UTC) }
+
+	path, err := scheduler.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if filepath.Base(path) != "pin-backup-20240104-030000.zip" {
+		t.Fatalf("unexpected path %q", path)
+	}
+	if got := listDir(t, dir); !equalStrings(got, []string{"notes.txt", "pin-backup-20240103-000000.zip", "pin-backup-20240104-030000.zip"}) {
+		t.Fatalf("unexpected files after prune: %v", got)
+	}
+	if len(store.actions) != 1 || store.actions[0] != "server.backup.scheduled" {
+		t.Fatalf("expected one audit entry, got %v", store.actions)
+	}
+	if store.audits[0]["status"] != "success" || store.audits[0]["pruned"] != "2" {
+		t.Fatalf("unexpected audit metadata: %v", store.audits[0])
+	}
+	status, err := LoadStatus(context.Background(), store)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status.LastSuccessPath != path || status.LastSuccessAt.IsZero() || status.Failing() {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+// TestRunOnceRecordsFailure verifies a failed run records and audits the error.
+func TestRunOnceRecordsFailure(t *testing.T) {
+	store := newFakeStore()
+	run := func(ctx context.Context, dest string) (string, error) {
+		return "", errors.New("disk full")
+	}
+	scheduler := NewScheduler(Config{Interval: time.Hour, Dir: t.TempDir()}, run, store)
+	if _, err := scheduler.RunOnce(context.Background()); err == nil {
+		t.Fatalf("expected error")
+	}
+	if store.audits[0]["status"] != "failure" || store.audits[0]["error"] != "disk full" {
+		t.Fatalf("unexpected audit metadata: %v", store.audits[0])
+	}
+	status, _ := LoadStatus(context.Background(), store)
+	if !status.Failing() || status.LastError != "disk full" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+// TestSelectRetained verifies the keep, daily and weekly policies and their union.
+func TestSelectRetained(t *testing.T) {
+	stamps := []string{
+		"20240101-010000", // Mon, week 1
+		"20240103-010000", // Wed, week 1
+		"20240108-010000", // Mon, week 2
+		"20240110-010000", // Wed, week 2
+		"20240110-120000", // Wed, week 2 (later same day)
+		"20240111-010000", // Thu, week 2
+	}
+	var archives []archive
+	for _, stamp := range stamps {
+		name := "pin-backup-" + stamp + ".zip"
+		taken, ok := parseFilename(name)
+		if !ok {
+			t.Fatalf("parse %q", name)
+		}
+		archives = append(archives, archive{name: name, taken: taken})
+	}
+
+	cases := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{"keep last", RetentionPolicy{Keep: 1}, []string{"20240111-010000"}},
+		{"daily", RetentionPolicy{KeepDaily: 2}, []string{"20240110-120000", "20240111-010000"}},
+		{"weekly", RetentionPolicy{KeepWeekly: 2}, []string{"20240103-010000", "20240111-010000"}},
+		{"union", RetentionPolicy{Keep: 1, KeepDaily: 3, KeepWeekly: 2}, []string{"20240103-010000", "20240108-010000", "20240110-120000", "20240111-010000"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keep := selectRetained(append([]archive(nil), archives...), tc.policy)
+			var got []string
+			for name := range keep {
+				got = append(got, name[len("pin-backup-"):len(name)-len(".zip")])
+			}
+			sort.Strings(got)
+			if !equalStrings(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// listDir returns the sorted names in dir.
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// equalStrings reports whether a and b hold the same strings in order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package backup
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	lastRunAtKey       = "backup_last_run_at"
+	lastSuccessAtKey   = "backup_last_success_at"
+	lastSuccessPathKey = "backup_last_success_path"
+	lastFailureAtKey   = "backup_last_failure_at"
+	lastErrorKey       = "backup_last_error"
+)
+
+// StatusStore reads persisted schedule status.
+type StatusStore interface {
+	GetSettings(ctx context.Context, keys ...string) (map[string]string, error)
+}
+
+// Status summarizes the most recent scheduled backup runs.
+type Status struct {
+	LastRunAt       time.Time
+	LastSuccessAt   time.Time
+	LastSuccessPath string
+	LastFailureAt   time.Time
+	LastError       string
+}
+
+// Failing reports whether the latest run failed.
+func (s Status) Failing() bool {
+	return !s.LastFailureAt.IsZero() && s.LastFailureAt.After(s.LastSuccessAt)
+}
+
+// LoadStatus returns the persisted schedule status.
+func LoadStatus(ctx context.Context, store StatusStore) (Status, error) {
+	values, err := store.GetSettings(ctx, lastRunAtKey, lastSuccessAtKey, lastSuccessPathKey, lastFailureAtKey, lastErrorKey)
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{
+		LastRunAt:       parseTime(values[lastRunAtKey]),
+		LastSuccessAt:   parseTime(values[lastSuccessAtKey]),
+		LastSuccessPath: values[lastSuccessPathKey],
+		LastFailureAt:   parseTime(values[lastFailureAtKey]),
+		LastError:       values[lastErrorKey],
+	}, nil
+}
+
+// parseTime parses an RFC3339 timestamp, returning zero on failure.
+func parseTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
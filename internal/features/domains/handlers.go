@@ -3,21 +3,26 @@ package domains
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/sessions"
 	"pin/internal/domain"
+	"pin/internal/features/history"
 	"pin/internal/features/identity"
 )
 
 type Dependencies interface {
 	Store
+	history.Store
+	BaseURL(r *http.Request) string
 	GetSession(r *http.Request, name string) (*sessions.Session, error)
 	ValidateCSRF(session *sessions.Session, token string) bool
 	CurrentUser(r *http.Request) (domain.User, error)
 	CurrentIdentity(r *http.Request) (domain.Identity, error)
 	UpdateIdentity(ctx context.Context, identity domain.Identity) error
+	HTTPClient() *http.Client
 	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
 	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
 }
@@ -29,7 +34,7 @@ type Handler struct {
 
 // NewHandler constructs a new handler.
 func NewHandler(deps Dependencies) Handler {
-	return Handler{deps: deps, svc: NewService(deps)}
+	return Handler{deps: deps, svc: NewService(deps, deps.HTTPClient())}
 }
 
 // Create accepts domain input and returns verification records or errors.
@@ -74,7 +79,7 @@ func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
 	domainsJSON := identity.EncodeStringSlice(verified)
 	meta := map[string]string{"verified_domains": domainsJSON}
 	currentIdentity.VerifiedDomainsJSON = domainsJSON
-	if err := h.deps.UpdateIdentity(r.Context(), currentIdentity); err != nil {
+	if err := history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, currentIdentity); err != nil {
 		h.deps.AuditOutcome(r.Context(), current.ID, "domain.create", strings.Join(domainList, ","), err, meta)
 		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
 		return
@@ -91,7 +96,8 @@ func (h Handler) Create(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/settings/profile?toast=Domain%20records%20seeded#section-verified-domains", http.StatusFound)
 }
 
-// Verify checks a domain's verification token and returns the result.
+// Verify checks a domain's verification token with the requested method ("http", "dns", or
+// empty for either) and returns the result.
 func (h Handler) Verify(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -121,15 +127,21 @@ func (h Handler) Verify(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid domain", http.StatusBadRequest)
 		return
 	}
-	h.deps.AuditAttempt(r.Context(), current.ID, "domain.verify", domain, nil)
-	rows, verified, err := h.svc.VerifyDomain(r.Context(), currentIdentity.ID, domain)
+	method, err := parseMethod(r.FormValue("method"))
 	if err != nil {
-		h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, err, nil)
+		http.Error(w, "Unknown verification method", http.StatusBadRequest)
+		return
+	}
+	meta := map[string]string{"method": method}
+	h.deps.AuditAttempt(r.Context(), current.ID, "domain.verify", domain, meta)
+	rows, verified, err := h.svc.VerifyDomain(r.Context(), currentIdentity.ID, domain, method)
+	if err != nil {
+		h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, err, meta)
 		if err.Error() == "domain not found" {
 			http.Error(w, "Domain not found", http.StatusBadRequest)
 			return
 		}
-		if err.Error() == "token not found" {
+		if errors.Is(err, ErrTokenNotFound) {
 			http.Error(w, "Token not found", http.StatusBadRequest)
 			return
 		}
@@ -139,21 +151,27 @@ func (h Handler) Verify(w http.ResponseWriter, r *http.Request) {
 	var updateErr error
 	if domainsJSON, err := json.Marshal(verified); err == nil {
 		currentIdentity.VerifiedDomainsJSON = string(domainsJSON)
-		updateErr = h.deps.UpdateIdentity(r.Context(), currentIdentity)
+		updateErr = history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, currentIdentity)
 	} else {
 		updateErr = err
 	}
 	if updateErr != nil {
-		h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, updateErr, nil)
+		h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, updateErr, meta)
 		http.Error(w, "Failed to save domains", http.StatusInternalServerError)
 		return
 	}
-	h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, nil, nil)
+	for _, row := range rows {
+		if row.Domain == domain {
+			meta["method"] = row.Method
+		}
+	}
+	h.deps.AuditOutcome(r.Context(), current.ID, "domain.verify", domain, nil, meta)
 	if wantsJSON(r) {
 		writeJSON(w, map[string]interface{}{
 			"ok":       true,
 			"domains":  identity.DomainsToText(rows),
 			"verified": verified,
+			"method":   meta["method"],
 		})
 		return
 	}
@@ -198,7 +216,7 @@ func (h Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	currentIdentity.VerifiedDomainsJSON = identity.EncodeStringSlice(remaining)
-	if err := h.deps.UpdateIdentity(r.Context(), currentIdentity); err != nil {
+	if err := history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, currentIdentity); err != nil {
 		h.deps.AuditOutcome(r.Context(), current.ID, "domain.delete", domain, err, nil)
 		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
 		return
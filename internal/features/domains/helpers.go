@@ -3,12 +3,30 @@ package domains
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 )
 
+// ErrUnknownMethod is returned for a verification method other than MethodHTTP or MethodDNS.
+var ErrUnknownMethod = errors.New("unknown verification method")
+
+// ErrTokenNotFound is returned when no verification method found the domain's token.
+var ErrTokenNotFound = errors.New("token not found")
+
+// parseMethod validates a requested verification method; empty means any method.
+func parseMethod(raw string) (string, error) {
+	switch method := strings.ToLower(strings.TrimSpace(raw)); method {
+	case "", "auto":
+		return "", nil
+	case MethodHTTP, MethodDNS:
+		return method, nil
+	}
+	return "", ErrUnknownMethod
+}
+
 // normalizeDomain normalizes domain into a canonical form.
 func normalizeDomain(raw string) string {
 	raw = strings.TrimSpace(raw)
@@ -0,0 +1,149 @@
+package domains
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domainpkg "pin/internal/domain"
+	"pin/internal/features/history"
+	"pin/internal/features/identity"
+)
+
+// recheckTimeout bounds a single domain's re-check so one slow host cannot stall the run.
+const recheckTimeout = 30 * time.Second
+
+// RecheckConfig controls periodic re-verification of verified domains.
+type RecheckConfig struct {
+	Interval time.Duration
+	// Grace is how long re-checks may keep failing before the domain is demoted.
+	Grace time.Duration
+	// BaseURL is recorded on the identity revision written when a domain is demoted.
+	BaseURL string
+}
+
+// RecheckStore lists verified domains, records re-check outcomes and demotes failing domains.
+type RecheckStore interface {
+	Store
+	history.Store
+	ListVerifiedDomainVerifications(ctx context.Context) ([]domainpkg.DomainVerification, error)
+	RecordDomainCheck(ctx context.Context, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error
+	DemoteDomainVerification(ctx context.Context, id int, checkedAt time.Time, lastError string) error
+	ProtectedDomain(ctx context.Context) string
+	WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error
+	HTTPClient() *http.Client
+}
+
+// Rechecker re-verifies verified domains on an interval and demotes those whose token has been
+// missing for longer than the grace period.
+type Rechecker struct {
+	cfg   RecheckConfig
+	store RecheckStore
+	svc   Service
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewRechecker constructs a new domain rechecker.
+func NewRechecker(cfg RecheckConfig, store RecheckStore) *Rechecker {
+	return &Rechecker{cfg: cfg, store: store, svc: NewService(store, store.HTTPClient()), now: time.Now}
+}
+
+// Start runs the rechecker in the background until ctx is canceled.
+func (r *Rechecker) Start(ctx context.Context) {
+	if r.cfg.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.RunOnce(ctx); err != nil {
+					log.Printf("domain recheck: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce re-checks every verified domain once. The server's own protected domain is skipped.
+func (r *Rechecker) RunOnce(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows, err := r.store.ListVerifiedDomainVerifications(ctx)
+	if err != nil {
+		return err
+	}
+	protected := r.store.ProtectedDomain(ctx)
+	for _, row := range rows {
+		if protected != "" && strings.EqualFold(row.Domain, protected) {
+			continue
+		}
+		if err := r.recheck(ctx, row); err != nil {
+			log.Printf("domain recheck %s: %v", row.Domain, err)
+		}
+	}
+	return nil
+}
+
+// recheck looks for the domain's token with the method that verified it (any method for rows
+// verified before methods were recorded) and records the outcome, demoting the domain once it
+// has failed for longer than the grace period.
+func (r *Rechecker) recheck(ctx context.Context, row domainpkg.DomainVerification) error {
+	checkCtx, cancel := context.WithTimeout(ctx, recheckTimeout)
+	_, checkErr := r.svc.checkDomainVerification(checkCtx, row.Domain, row.Token, row.Method)
+	cancel()
+	checkedAt := r.now().UTC()
+	if checkErr == nil {
+		return r.store.RecordDomainCheck(ctx, row.ID, checkedAt, "", sql.NullTime{})
+	}
+	failingSince := row.FailingSince
+	if !failingSince.Valid {
+		failingSince = sql.NullTime{Time: checkedAt, Valid: true}
+	}
+	if checkedAt.Sub(failingSince.Time) < r.cfg.Grace {
+		return r.store.RecordDomainCheck(ctx, row.ID, checkedAt, checkErr.Error(), failingSince)
+	}
+	return r.demote(ctx, row, checkedAt, failingSince.Time, checkErr)
+}
+
+// demote clears the domain's verification, drops it from the identity's verified domains and
+// records an audit entry.
+func (r *Rechecker) demote(ctx context.Context, row domainpkg.DomainVerification, checkedAt, failingSince time.Time, checkErr error) error {
+	if err := r.store.DemoteDomainVerification(ctx, row.ID, checkedAt, checkErr.Error()); err != nil {
+		return err
+	}
+	meta := map[string]string{
+		"identity_id":   strconv.Itoa(row.IdentityID),
+		"method":        row.Method,
+		"failing_since": failingSince.Format(time.RFC3339),
+		"error":         checkErr.Error(),
+	}
+	_ = r.store.WriteAuditLog(ctx, 0, "domain.demote", row.Domain, meta)
+
+	user, err := r.store.GetIdentityByID(ctx, row.IdentityID)
+	if err != nil {
+		return err
+	}
+	verified := identity.DecodeStringSlice(user.VerifiedDomainsJSON)
+	remaining := make([]string, 0, len(verified))
+	for _, item := range verified {
+		if !strings.EqualFold(strings.TrimSpace(item), row.Domain) {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(remaining) == len(verified) {
+		return nil
+	}
+	user.VerifiedDomainsJSON = identity.EncodeStringSlice(remaining)
+	return history.NewService(r.store).Save(ctx, r.cfg.BaseURL, 0, user)
+}
@@ -0,0 +1,160 @@
+package domains
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	domainpkg "pin/internal/domain"
+)
+
+// recheckStore extends memoryStore with the identity, revision and audit state a recheck touches.
+type recheckStore struct {
+	*memoryStore
+	identity domainpkg.Identity
+	audits   []string
+}
+
+// ListVerifiedDomainVerifications returns the verified rows.
+func (s *recheckStore) ListVerifiedDomainVerifications(ctx context.Context) ([]domainpkg.DomainVerification, error) {
+	var out []domainpkg.DomainVerification
+	for _, row := range s.rows {
+		if row.VerifiedAt.Valid {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+// RecordDomainCheck stores the check outcome on the row.
+func (s *recheckStore) RecordDomainCheck(ctx context.Context, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error {
+	for i := range s.rows {
+		if s.rows[i].ID == id {
+			s.rows[i].LastCheckedAt = sql.NullTime{Time: checkedAt, Valid: true}
+			s.rows[i].LastError = lastError
+			s.rows[i].FailingSince = failingSince
+		}
+	}
+	return nil
+}
+
+// DemoteDomainVerification clears the row's verification.
+func (s *recheckStore) DemoteDomainVerification(ctx context.Context, id int, checkedAt time.Time, lastError string) error {
+	for i := range s.rows {
+		if s.rows[i].ID == id {
+			s.rows[i].VerifiedAt = sql.NullTime{}
+			s.rows[i].Method = ""
+			s.rows[i].FailingSince = sql.NullTime{}
+			s.rows[i].LastCheckedAt = sql.NullTime{Time: checkedAt, Valid: true}
+			s.rows[i].LastError = lastError
+		}
+	}
+	return nil
+}
+
+// ProtectedDomain returns the server's own domain.
+func (s *recheckStore) ProtectedDomain(ctx context.Context) string {
+	return "pin.example"
+}
+
+// WriteAuditLog records the action and target.
+func (s *recheckStore) WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error {
+	s.audits = append(s.audits, action+" "+target)
+	return nil
+}
+
+// GetIdentityByID returns the stored identity.
+func (s *recheckStore) GetIdentityByID(ctx context.Context, id int) (domainpkg.Identity, error) {
+	return s.identity, nil
+}
+
+// UpdateIdentity stores the identity.
+func (s *recheckStore) UpdateIdentity(ctx context.Context, identity domainpkg.Identity) error {
+	s.identity = identity
+	return nil
+}
+
+// CheckHandleCollision reports no collision.
+func (s *recheckStore) CheckHandleCollision(ctx context.Context, handle string, excludeID int) error {
+	return nil
+}
+
+// CreateIdentityRevision discards the revision.
+func (s *recheckStore) CreateIdentityRevision(ctx context.Context, revision domainpkg.IdentityRevision) (int64, error) {
+	return 1, nil
+}
+
+// ListIdentityRevisions returns no revisions.
+func (s *recheckStore) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domainpkg.IdentityRevision, error) {
+	return nil, nil
+}
+
+// GetIdentityRevision reports a missing revision.
+func (s *recheckStore) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domainpkg.IdentityRevision, error) {
+	return domainpkg.IdentityRevision{}, sql.ErrNoRows
+}
+
+// GetProfilePictureAlt returns no alt text.
+func (s *recheckStore) GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error) {
+	return "", nil
+}
+
+// HTTPClient returns the default client; these tests only use the DNS method.
+func (s *recheckStore) HTTPClient() *http.Client {
+	return http.DefaultClient
+}
+
+// TestRecheckerDemotesAfterGrace verifies passing checks clear errors, failures are recorded
+// during the grace period, and a domain is demoted once the grace period has passed.
+func TestRecheckerDemotesAfterGrace(t *testing.T) {
+	ctx := context.Background()
+	verified := sql.NullTime{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+	store := &recheckStore{
+		memoryStore: &memoryStore{rows: []domainpkg.DomainVerification{
+			{ID: 1, IdentityID: 1, Domain: "alice.example", Token: "pin:abc", VerifiedAt: verified, Method: MethodDNS},
+			{ID: 2, IdentityID: 1, Domain: "pin.example", Token: "pin:srv", VerifiedAt: verified, Method: MethodDNS},
+		}},
+		identity: domainpkg.Identity{ID: 1, Handle: "alice", VerifiedDomainsJSON: `["alice.example","pin.example"]`},
+	}
+	resolver := stubResolver{"_pin-verify.alice.example": {"pin-verify=pin:abc"}}
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rechecker := NewRechecker(RecheckConfig{Interval: time.Hour, Grace: 48 * time.Hour}, store)
+	rechecker.svc.resolver = resolver
+	rechecker.now = func() time.Time { return now }
+
+	if err := rechecker.RunOnce(ctx); err != nil {
+		t.Fatalf("recheck: %v", err)
+	}
+	if row := store.rows[0]; !row.LastCheckedAt.Time.Equal(now) || row.LastError != "" || row.FailingSince.Valid {
+		t.Fatalf("expected a passing check to be recorded, got %+v", row)
+	}
+	if store.rows[1].LastCheckedAt.Valid {
+		t.Fatalf("expected the protected server domain to be skipped")
+	}
+
+	delete(resolver, "_pin-verify.alice.example")
+	now = now.Add(24 * time.Hour)
+	if err := rechecker.RunOnce(ctx); err != nil {
+		t.Fatalf("recheck: %v", err)
+	}
+	row := store.rows[0]
+	if !row.VerifiedAt.Valid || row.LastError == "" || !row.FailingSince.Time.Equal(now) {
+		t.Fatalf("expected a failure within the grace period to be recorded, got %+v", row)
+	}
+
+	now = now.Add(48 * time.Hour)
+	if err := rechecker.RunOnce(ctx); err != nil {
+		t.Fatalf("recheck: %v", err)
+	}
+	if row := store.rows[0]; row.VerifiedAt.Valid || row.LastError == "" {
+		t.Fatalf("expected the domain to be demoted, got %+v", row)
+	}
+	if store.identity.VerifiedDomainsJSON != `["pin.example"]` {
+		t.Fatalf("expected the domain to leave the identity's verified domains, got %s", store.identity.VerifiedDomainsJSON)
+	}
+	if len(store.audits) != 1 || store.audits[0] != "domain.demote alice.example" {
+		t.Fatalf("expected a demotion audit entry, got %v", store.audits)
+	}
+}
@@ -3,7 +3,9 @@ package domains
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 
@@ -15,9 +17,23 @@ type Store interface {
 	ListDomainVerifications(ctx context.Context, identityID int) ([]domainpkg.DomainVerification, error)
 	UpsertDomainVerification(ctx context.Context, identityID int, domain, token string) error
 	DeleteDomainVerification(ctx context.Context, identityID int, domain string) error
-	MarkDomainVerified(ctx context.Context, identityID int, domain string) error
+	MarkDomainVerified(ctx context.Context, identityID int, domain, method string) error
 }
 
+// Resolver looks up DNS TXT records; *net.Resolver satisfies it and tests stub it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Verification methods a domain can be verified with.
+const (
+	MethodHTTP = "http"
+	MethodDNS  = "dns"
+)
+
+// dnsRecordPrefix names the TXT record checked by the DNS method, e.g. _pin-verify.example.com.
+const dnsRecordPrefix = "_pin-verify."
+
 type Protector interface {
 	HasDomainVerification(ctx context.Context, identityID int, domain string) (bool, error)
 	ProtectedDomain(ctx context.Context) string
@@ -25,16 +41,19 @@ type Protector interface {
 }
 
 type Service struct {
-	store Store
+	store    Store
+	resolver Resolver
+	client   *http.Client
 }
 
-// NewService constructs a new service.
-func NewService(store Store) Service {
-	return Service{store: store}
+// NewService constructs a new service fetching /.well-known/pin-verify with client.
+func NewService(store Store, client *http.Client) Service {
+	return Service{store: store, resolver: net.DefaultResolver, client: client}
 }
 
-// VerifyDomain checks /.well-known/pin-verify for the stored token and marks the domain verified.
-func (s Service) VerifyDomain(ctx context.Context, identityID int, domain string) ([]domainpkg.DomainVerification, []string, error) {
+// VerifyDomain checks for the stored token with method, or with every method when method is
+// empty, and marks the domain verified with the method that found it.
+func (s Service) VerifyDomain(ctx context.Context, identityID int, domain, method string) ([]domainpkg.DomainVerification, []string, error) {
 	rows, err := s.store.ListDomainVerifications(ctx, identityID)
 	if err != nil {
 		return nil, nil, err
@@ -50,14 +69,11 @@ func (s Service) VerifyDomain(ctx context.Context, identityID int, domain string
 	if token == "" {
 		return nil, nil, errors.New("domain not found")
 	}
-	ok, err := checkDomainVerification(ctx, domain, token)
+	verifiedBy, err := s.checkDomainVerification(ctx, domain, token, method)
 	if err != nil {
 		return nil, nil, err
 	}
-	if !ok {
-		return nil, nil, errors.New("token not found")
-	}
-	if err := s.store.MarkDomainVerified(ctx, identityID, domain); err != nil {
+	if err := s.store.MarkDomainVerified(ctx, identityID, domain, verifiedBy); err != nil {
 		return nil, nil, err
 	}
 	rows, err = s.store.ListDomainVerifications(ctx, identityID)
@@ -180,30 +196,82 @@ func (s Service) EnsureServerDomainVerification(ctx context.Context, baseURL str
 	}
 }
 
-// checkDomainVerification fetches /.well-known/pin-verify and searches for the token.
-func checkDomainVerification(ctx context.Context, domain, token string) (bool, error) {
+// checkDomainVerification looks for the token with method, trying DNS then HTTP when method is
+// empty, and returns the method that found it. When none did, the error wraps ErrTokenNotFound
+// with what each method saw.
+func (s Service) checkDomainVerification(ctx context.Context, domain, token, method string) (string, error) {
 	domain = normalizeDomain(domain)
 	if domain == "" || strings.TrimSpace(token) == "" {
-		return false, nil
+		return "", ErrTokenNotFound
 	}
+	methods := []string{MethodDNS, MethodHTTP}
+	if method != "" {
+		methods = []string{method}
+	}
+	var reasons []string
+	for _, candidate := range methods {
+		var err error
+		switch candidate {
+		case MethodDNS:
+			err = s.checkDNSVerification(ctx, domain, token)
+		case MethodHTTP:
+			err = s.checkHTTPVerification(ctx, domain, token)
+		default:
+			return "", ErrUnknownMethod
+		}
+		if err == nil {
+			return candidate, nil
+		}
+		reasons = append(reasons, candidate+": "+err.Error())
+	}
+	return "", fmt.Errorf("%w (%s)", ErrTokenNotFound, strings.Join(reasons, "; "))
+}
+
+// checkDNSVerification looks for a "pin-verify=<token>" TXT record on _pin-verify.{domain}.
+func (s Service) checkDNSVerification(ctx context.Context, domain, token string) error {
+	// Strip any port; DNS names have none.
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	records, err := s.resolver.LookupTXT(ctx, dnsRecordPrefix+domain)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == "pin-verify="+token {
+			return nil
+		}
+	}
+	return errors.New("no matching TXT record on " + dnsRecordPrefix + domain)
+}
+
+// checkHTTPVerification fetches /.well-known/pin-verify and searches for the token.
+func (s Service) checkHTTPVerification(ctx context.Context, domain, token string) error {
+	var lastErr error
 	for _, scheme := range []string{"https", "http"} {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+domain+"/.well-known/pin-verify", nil)
+		target := scheme + "://" + domain + "/.well-known/pin-verify"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 		if err != nil {
+			lastErr = err
 			continue
 		}
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := s.client.Do(req)
 		if err != nil {
+			lastErr = err
 			continue
 		}
 		body, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			for _, line := range strings.Split(string(body), "\n") {
-				if strings.TrimSpace(line) == token {
-					return true, nil
-				}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			lastErr = fmt.Errorf("%s returned %s", target, resp.Status)
+			continue
+		}
+		for _, line := range strings.Split(string(body), "\n") {
+			if strings.TrimSpace(line) == token {
+				return nil
 			}
 		}
+		lastErr = errors.New("token missing from " + target)
 	}
-	return false, nil
+	return lastErr
 }
@@ -0,0 +1,137 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	domainpkg "pin/internal/domain"
+	"pin/internal/platform/outbound"
+)
+
+// stubResolver serves fixed TXT records by name.
+type stubResolver map[string][]string
+
+// LookupTXT returns the records for name or a not-found error.
+func (r stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+// memoryStore keeps domain verification rows for one identity in memory.
+type memoryStore struct {
+	rows []domainpkg.DomainVerification
+}
+
+// ListDomainVerifications returns the stored rows.
+func (s *memoryStore) ListDomainVerifications(ctx context.Context, identityID int) ([]domainpkg.DomainVerification, error) {
+	return append([]domainpkg.DomainVerification(nil), s.rows...), nil
+}
+
+// UpsertDomainVerification adds a row.
+func (s *memoryStore) UpsertDomainVerification(ctx context.Context, identityID int, domain, token string) error {
+	s.rows = append(s.rows, domainpkg.DomainVerification{IdentityID: identityID, Domain: domain, Token: token})
+	return nil
+}
+
+// DeleteDomainVerification is a no-op.
+func (s *memoryStore) DeleteDomainVerification(ctx context.Context, identityID int, domain string) error {
+	return nil
+}
+
+// MarkDomainVerified marks the row verified with method.
+func (s *memoryStore) MarkDomainVerified(ctx context.Context, identityID int, domain, method string) error {
+	for i := range s.rows {
+		if s.rows[i].Domain == domain {
+			s.rows[i].VerifiedAt.Valid = true
+			s.rows[i].Method = method
+		}
+	}
+	return nil
+}
+
+// TestVerifyDomainDNS verifies the TXT record method and that the method is recorded.
+func TestVerifyDomainDNS(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	_ = store.UpsertDomainVerification(ctx, 1, "example.com", "pin:abc")
+	svc := Service{store: store, resolver: stubResolver{
+		"_pin-verify.example.com": {"v=spf1 -all", "pin-verify=pin:abc"},
+	}}
+
+	rows, verified, err := svc.VerifyDomain(ctx, 1, "example.com", MethodDNS)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if len(verified) != 1 || verified[0] != "example.com" {
+		t.Fatalf("expected example.com to be verified, got %v", verified)
+	}
+	if rows[0].Method != MethodDNS {
+		t.Fatalf("expected the dns method to be recorded, got %q", rows[0].Method)
+	}
+}
+
+// TestCheckDomainVerificationMethods verifies method selection and token matching.
+func TestCheckDomainVerificationMethods(t *testing.T) {
+	ctx := context.Background()
+	svc := Service{store: &memoryStore{}, resolver: stubResolver{
+		"_pin-verify.example.com": {"pin-verify=pin:abc"},
+	}}
+	if method, err := svc.checkDomainVerification(ctx, "example.com", "pin:abc", ""); err != nil || method != MethodDNS {
+		t.Fatalf("expected any method to find the TXT record, got %q, %v", method, err)
+	}
+	if method, _ := svc.checkDomainVerification(ctx, "example.com", "pin:other", MethodDNS); method != "" {
+		t.Fatalf("expected a different token not to verify, got %q", method)
+	}
+	if method, _ := svc.checkDomainVerification(ctx, "other.example", "pin:abc", MethodDNS); method != "" {
+		t.Fatalf("expected a missing record not to verify, got %q", method)
+	}
+	if _, err := svc.checkDomainVerification(ctx, "example.com", "pin:abc", "smtp"); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("expected an unknown method error, got %v", err)
+	}
+}
+
+// TestCheckHTTPVerification verifies the well-known file method through the outbound client and
+// that loopback hosts are refused unless private destinations are allowed.
+func TestCheckHTTPVerification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/pin-verify" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("pin:other\npin:abc\n"))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	ctx := context.Background()
+
+	svc := NewService(&memoryStore{}, outbound.New(outbound.Config{AllowPrivate: true}))
+	if method, err := svc.checkDomainVerification(ctx, host, "pin:abc", MethodHTTP); err != nil || method != MethodHTTP {
+		t.Fatalf("expected the token file to verify, got %q, %v", method, err)
+	}
+	if _, err := svc.checkDomainVerification(ctx, host, "pin:missing", MethodHTTP); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected a missing token to fail, got %v", err)
+	}
+	guarded := NewService(&memoryStore{}, outbound.New(outbound.Config{}))
+	if _, err := guarded.checkDomainVerification(ctx, host, "pin:abc", MethodHTTP); !errors.Is(err, ErrTokenNotFound) || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected a loopback host to be refused, got %v", err)
+	}
+}
+
+// TestParseMethod verifies accepted verification methods.
+func TestParseMethod(t *testing.T) {
+	for raw, want := range map[string]string{"": "", "auto": "", "DNS": MethodDNS, " http ": MethodHTTP} {
+		if got, err := parseMethod(raw); err != nil || got != want {
+			t.Fatalf("parseMethod(%q) = %q, %v", raw, got, err)
+		}
+	}
+	if _, err := parseMethod("smtp"); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("expected smtp to be rejected")
+	}
+}
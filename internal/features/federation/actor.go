@@ -0,0 +1,125 @@
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/platform/core"
+)
+
+// activityStreamsPublic addresses an activity to everyone.
+const activityStreamsPublic = "https://www.w3.org/ns/activitystreams#Public"
+
+// Store persists ActivityPub actor keys, followers, published activities and queued deliveries.
+type Store interface {
+	GetIdentityByID(ctx context.Context, id int) (domain.Identity, error)
+	GetActivityPubActor(ctx context.Context, identityID int) (domain.ActivityPubActor, error)
+	ListActivityPubActors(ctx context.Context) ([]domain.ActivityPubActor, error)
+	CreateActivityPubActor(ctx context.Context, actor domain.ActivityPubActor) error
+	UpdateActivityPubActorKeys(ctx context.Context, actor domain.ActivityPubActor) error
+	UpdateActivityPubActorState(ctx context.Context, identityID int, baseURL, publishedHash string) error
+	AddActivityPubFollower(ctx context.Context, follower domain.ActivityPubFollower) error
+	RemoveActivityPubFollower(ctx context.Context, identityID int, actorID string) error
+	ListActivityPubFollowers(ctx context.Context, identityID int) ([]domain.ActivityPubFollower, error)
+	CreateActivityPubActivity(ctx context.Context, activity domain.ActivityPubActivity, inboxes []string) (int64, error)
+	ListActivityPubOutbox(ctx context.Context, identityID, limit int) ([]domain.ActivityPubActivity, int, error)
+	ListDueActivityPubDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.ActivityPubDelivery, error)
+	MarkActivityPubDelivered(ctx context.Context, id int, deliveredAt time.Time) error
+	RecordActivityPubDeliveryFailure(ctx context.Context, id int, nextAttemptAt sql.NullTime, lastError string) error
+	SealSecret(plaintext []byte) (string, error)
+	OpenSecret(value string) ([]byte, error)
+	HTTPClient() *http.Client
+}
+
+// baseURL prefers PIN_BASE_URL so actor IDs stay stable whatever Host a request arrives with.
+func (h Handler) baseURL(r *http.Request) string {
+	if h.cfg.BaseURL != "" {
+		return h.cfg.BaseURL
+	}
+	return core.BaseURL(r)
+}
+
+// actorURLFor returns the actor ID of handle under baseURL.
+func actorURLFor(baseURL, handle string) string {
+	return baseURL + "/users/" + handle
+}
+
+// actorDocument builds the Person document for an identity's public view and its published keys.
+func actorDocument(baseURL string, user domain.Identity, keys domain.ActivityPubActor) map[string]interface{} {
+	publicUser, _ := identity.VisibleIdentity(user, false)
+	actorURL := actorURLFor(baseURL, publicUser.Handle)
+	profilePictureURL := baseURL + "/" + publicUser.Handle + "/profile-picture"
+
+	contexts := []string{
+		"https://www.w3.org/ns/activitystreams",
+		"https://w3id.org/security/v1",
+	}
+	document := map[string]interface{}{
+		"id":                        actorURL,
+		"type":                      "Person",
+		"preferredUsername":         publicUser.Handle,
+		"name":                      identity.FirstNonEmpty(publicUser.DisplayName, publicUser.Handle),
+		"summary":                   publicUser.Bio,
+		"inbox":                     actorURL + "/inbox",
+		"outbox":                    actorURL + "/outbox",
+		"followers":                 actorURL + "/followers",
+		"manuallyApprovesFollowers": false,
+		"url":                       baseURL,
+		"icon": map[string]string{
+			"type":      "Image",
+			"mediaType": "image/png",
+			"url":       profilePictureURL,
+		},
+	}
+	if keys.PublicKeyPEM != "" {
+		document["publicKey"] = map[string]string{
+			"id":           actorURL + "#main-key",
+			"owner":        actorURL,
+			"publicKeyPem": keys.PublicKeyPEM,
+		}
+	}
+	// The Ed25519 key is published as a FEP-521a Multikey for servers that verify with it.
+	if keys.Ed25519PublicKey != "" {
+		contexts = append(contexts, "https://w3id.org/security/multikey/v1")
+		document["assertionMethod"] = []map[string]string{{
+			"id":                 actorURL + "#ed25519-key",
+			"type":               "Multikey",
+			"controller":         actorURL,
+			"publicKeyMultibase": keys.Ed25519PublicKey,
+		}}
+	}
+	document["@context"] = contexts
+	var socialProfiles []domain.SocialProfile
+	if publicUser.SocialProfilesJSON != "" {
+		_ = json.Unmarshal([]byte(publicUser.SocialProfilesJSON), &socialProfiles)
+	}
+	if attachment := identity.BuildAttachments(publicUser, identity.DecodeStringMap(publicUser.WalletsJSON), identity.DecodeStringMap(publicUser.PublicKeysJSON), identity.DecodeStringSlice(publicUser.VerifiedDomainsJSON), socialProfiles); len(attachment) > 0 {
+		document["attachment"] = attachment
+	}
+	return document
+}
+
+// documentHash fingerprints an actor document so unchanged profiles are not re-published.
+func documentHash(document map[string]interface{}) string {
+	payload, _ := json.Marshal(document)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// newActivityID mints a unique activity ID under the actor.
+func newActivityID(actorURL string) string {
+	return actorURL + "/activities/" + core.RandomTokenURL(16)
+}
+
+// writeActivityJSON writes an ActivityStreams JSON response.
+func writeActivityJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/activity+json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
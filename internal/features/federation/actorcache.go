@@ -0,0 +1,66 @@
+package federation
+
+import (
+	"sync"
+	"time"
+)
+
+// actorCacheTTL is how long a verified remote actor is reused before its key is refetched.
+const actorCacheTTL = 15 * time.Minute
+
+// actorCacheSize caps how many remote actors are remembered at once.
+const actorCacheSize = 1024
+
+// actorCache memoizes verified remote actors by keyId so each inbox POST does not refetch them.
+type actorCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]cachedActor
+}
+
+// cachedActor is a remote actor and when it stops being reused.
+type cachedActor struct {
+	actor   remoteActor
+	expires time.Time
+}
+
+// newActorCache constructs an empty cache.
+func newActorCache() *actorCache {
+	return &actorCache{now: time.Now, entries: map[string]cachedActor{}}
+}
+
+// get returns the cached actor for keyID while it is fresh.
+func (c *actorCache) get(keyID string) (remoteActor, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[keyID]
+	if !ok || !c.now().Before(entry.expires) {
+		return remoteActor{}, false
+	}
+	return entry.actor, true
+}
+
+// put stores actor under keyID, dropping expired entries, or everything, when the cache is full.
+func (c *actorCache) put(keyID string, actor remoteActor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= actorCacheSize {
+		for id, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= actorCacheSize {
+			c.entries = map[string]cachedActor{}
+		}
+	}
+	c.entries[keyID] = cachedActor{actor: actor, expires: now.Add(actorCacheTTL)}
+}
+
+// forget drops the cached actor for keyID.
+func (c *actorCache) forget(keyID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, keyID)
+}
@@ -0,0 +1,157 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// defaultPLCDirectory resolves did:plc identifiers.
+const defaultPLCDirectory = "https://plc.directory"
+
+// Errors returned by CheckATProtoHandle.
+var (
+	ErrATProtoNotConfigured = errors.New("identity has no AT handle and DID")
+	ErrATProtoDIDMismatch   = errors.New("DID document belongs to a different DID")
+	ErrATProtoHandleMissing = errors.New("DID document does not list the handle in alsoKnownAs")
+)
+
+// AtprotoDID serves the atproto DID of the identity a host names: {handle}.{PIN_BASE_URL host}
+// resolves to that identity, anything else to the owner.
+func (h Handler) AtprotoDID(w http.ResponseWriter, r *http.Request) {
+	user, err := h.atprotoIdentity(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	did := strings.TrimSpace(user.ATProtoDID)
+	if did == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(did))
+}
+
+// atprotoIdentity picks the identity whose handle is the first label of a subdomain of the base host.
+func (h Handler) atprotoIdentity(r *http.Request) (domain.Identity, error) {
+	if handle, ok := subdomainHandle(r.Host, h.cfg.BaseURL); ok {
+		user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+		if err != nil || !identity.MatchesIdentity(user, handle) {
+			return domain.Identity{}, errors.New("unknown handle")
+		}
+		return user, nil
+	}
+	return h.deps.GetOwnerIdentity(r.Context())
+}
+
+// subdomainHandle returns "alice" for host alice.pin.example when baseURL is https://pin.example.
+func subdomainHandle(host, baseURL string) (string, bool) {
+	if baseURL == "" {
+		return "", false
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Hostname() == "" {
+		return "", false
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(base.Hostname()))
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// DIDDocument is the part of an atproto DID document the handle check reads.
+type DIDDocument struct {
+	ID          string   `json:"id"`
+	AlsoKnownAs []string `json:"alsoKnownAs"`
+}
+
+// DIDResolver fetches DID documents.
+type DIDResolver interface {
+	ResolveDID(ctx context.Context, did string) (DIDDocument, error)
+}
+
+// HTTPDIDResolver resolves did:plc through a PLC directory and did:web over HTTPS.
+type HTTPDIDResolver struct {
+	Client       *http.Client
+	PLCDirectory string
+}
+
+// NewDIDResolver returns a resolver fetching with client from plcDirectory, or plc.directory when empty.
+func NewDIDResolver(client *http.Client, plcDirectory string) HTTPDIDResolver {
+	if plcDirectory == "" {
+		plcDirectory = defaultPLCDirectory
+	}
+	return HTTPDIDResolver{Client: client, PLCDirectory: strings.TrimRight(plcDirectory, "/")}
+}
+
+// ResolveDID fetches the DID document for did.
+func (res HTTPDIDResolver) ResolveDID(ctx context.Context, did string) (DIDDocument, error) {
+	var target string
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		target = res.PLCDirectory + "/" + url.PathEscape(did)
+	case strings.HasPrefix(did, "did:web:"):
+		host, err := url.PathUnescape(strings.TrimPrefix(did, "did:web:"))
+		if err != nil || host == "" || strings.Contains(host, ":") {
+			return DIDDocument{}, fmt.Errorf("unsupported did:web %q", did)
+		}
+		target = "https://" + host + "/.well-known/did.json"
+	default:
+		return DIDDocument{}, fmt.Errorf("unsupported DID method in %q", did)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+	req.Header.Set("Accept", "application/did+ld+json, application/json")
+	resp, err := res.Client.Do(req)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return DIDDocument{}, fmt.Errorf("DID lookup answered %d", resp.StatusCode)
+	}
+	var document DIDDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteBytes)).Decode(&document); err != nil {
+		return DIDDocument{}, err
+	}
+	return document, nil
+}
+
+// CheckATProtoHandle confirms the identity's DID document claims its AT handle through alsoKnownAs.
+func CheckATProtoHandle(ctx context.Context, resolver DIDResolver, user domain.Identity) error {
+	handle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(user.ATProtoHandle), "@"))
+	did := strings.TrimSpace(user.ATProtoDID)
+	if handle == "" || did == "" {
+		return ErrATProtoNotConfigured
+	}
+	document, err := resolver.ResolveDID(ctx, did)
+	if err != nil {
+		return err
+	}
+	if document.ID != did {
+		return ErrATProtoDIDMismatch
+	}
+	for _, aka := range document.AlsoKnownAs {
+		if strings.EqualFold(aka, "at://"+handle) {
+			return nil
+		}
+	}
+	return ErrATProtoHandleMissing
+}
@@ -0,0 +1,87 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pin/internal/domain"
+	"pin/internal/platform/outbound"
+)
+
+type stubResolver struct {
+	document DIDDocument
+	err      error
+}
+
+func (s stubResolver) ResolveDID(ctx context.Context, did string) (DIDDocument, error) {
+	return s.document, s.err
+}
+
+// TestSubdomainHandle verifies which hosts name an identity.
+func TestSubdomainHandle(t *testing.T) {
+	cases := []struct {
+		host, base, handle string
+		ok                 bool
+	}{
+		{"alice.pin.example", "https://pin.example", "alice", true},
+		{"Alice.PIN.example:8443", "https://pin.example:8443", "alice", true},
+		{"pin.example", "https://pin.example", "", false},
+		{"a.b.pin.example", "https://pin.example", "", false},
+		{"alice.other.example", "https://pin.example", "", false},
+		{"alice.pin.example", "", "", false},
+	}
+	for _, tc := range cases {
+		handle, ok := subdomainHandle(tc.host, tc.base)
+		if handle != tc.handle || ok != tc.ok {
+			t.Fatalf("subdomainHandle(%q, %q) = %q %v", tc.host, tc.base, handle, ok)
+		}
+	}
+}
+
+// TestCheckATProtoHandle verifies the alsoKnownAs back-reference check.
+func TestCheckATProtoHandle(t *testing.T) {
+	ctx := context.Background()
+	user := domain.Identity{ATProtoHandle: "alice.pin.example", ATProtoDID: "did:plc:abc"}
+	good := stubResolver{document: DIDDocument{ID: "did:plc:abc", AlsoKnownAs: []string{"at://alice.pin.example"}}}
+	if err := CheckATProtoHandle(ctx, good, user); err != nil {
+		t.Fatalf("expected the handle to check out, got %v", err)
+	}
+	missing := stubResolver{document: DIDDocument{ID: "did:plc:abc", AlsoKnownAs: []string{"at://someone.else"}}}
+	if err := CheckATProtoHandle(ctx, missing, user); !errors.Is(err, ErrATProtoHandleMissing) {
+		t.Fatalf("expected ErrATProtoHandleMissing, got %v", err)
+	}
+	other := stubResolver{document: DIDDocument{ID: "did:plc:xyz", AlsoKnownAs: []string{"at://alice.pin.example"}}}
+	if err := CheckATProtoHandle(ctx, other, user); !errors.Is(err, ErrATProtoDIDMismatch) {
+		t.Fatalf("expected ErrATProtoDIDMismatch, got %v", err)
+	}
+	if err := CheckATProtoHandle(ctx, good, domain.Identity{}); !errors.Is(err, ErrATProtoNotConfigured) {
+		t.Fatalf("expected ErrATProtoNotConfigured, got %v", err)
+	}
+}
+
+// TestHTTPDIDResolverPLC verifies did:plc lookups through a directory.
+func TestHTTPDIDResolverPLC(t *testing.T) {
+	directory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/did:plc:abc" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"did:plc:abc","alsoKnownAs":["at://alice.pin.example"]}`))
+	}))
+	defer directory.Close()
+
+	resolver := NewDIDResolver(outbound.New(outbound.Config{AllowPrivate: true}), directory.URL)
+	document, err := resolver.ResolveDID(context.Background(), "did:plc:abc")
+	if err != nil || document.ID != "did:plc:abc" || len(document.AlsoKnownAs) != 1 {
+		t.Fatalf("unexpected document %+v (%v)", document, err)
+	}
+	if _, err := resolver.ResolveDID(context.Background(), "did:plc:missing"); err == nil {
+		t.Fatalf("expected a missing DID to fail")
+	}
+	if _, err := resolver.ResolveDID(context.Background(), "did:key:z6Mk"); err == nil {
+		t.Fatalf("expected an unsupported method to fail")
+	}
+}
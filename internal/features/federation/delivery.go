@@ -0,0 +1,240 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// Delivery defaults; a delivery that keeps failing is given up after about two days.
+const (
+	defaultMaxAttempts = 8
+	deliveryBatchSize  = 50
+	firstRetryDelay    = time.Minute
+	maxRetryDelay      = 24 * time.Hour
+)
+
+// WorkerConfig controls the background publisher and delivery queue.
+type WorkerConfig struct {
+	Interval time.Duration
+	// BaseURL overrides the base URL recorded when an actor was first served.
+	BaseURL     string
+	MaxAttempts int
+}
+
+// Worker publishes Update activities when an actor changes and delivers queued activities with retries.
+type Worker struct {
+	cfg   WorkerConfig
+	store Store
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+// NewWorker constructs a new delivery worker.
+func NewWorker(cfg WorkerConfig, store Store) *Worker {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	return &Worker{cfg: cfg, store: store, now: time.Now}
+}
+
+// Start runs the worker in the background until ctx is canceled.
+func (w *Worker) Start(ctx context.Context) {
+	if w.cfg.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.RunOnce(ctx); err != nil {
+					log.Printf("activitypub delivery: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce queues Update activities for changed actors, then attempts every due delivery.
+func (w *Worker) RunOnce(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.publishUpdates(ctx); err != nil {
+		return err
+	}
+	return w.deliverDue(ctx)
+}
+
+// publishUpdates compares each actor document with the one last published and announces changes to followers.
+func (w *Worker) publishUpdates(ctx context.Context) error {
+	actors, err := w.store.ListActivityPubActors(ctx)
+	if err != nil {
+		return err
+	}
+	for _, actor := range actors {
+		baseURL := actor.BaseURL
+		if w.cfg.BaseURL != "" {
+			baseURL = w.cfg.BaseURL
+		}
+		if baseURL == "" {
+			continue
+		}
+		user, err := w.store.GetIdentityByID(ctx, actor.IdentityID)
+		if err != nil {
+			continue
+		}
+		if err := publishActor(ctx, w.store, actor, baseURL, user, w.now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishActor queues an Update to followers when the actor document differs from the one last
+// published, then records the document as published.
+func publishActor(ctx context.Context, store Store, actor domain.ActivityPubActor, baseURL string, user domain.Identity, now time.Time) error {
+	document := actorDocument(baseURL, user, actor)
+	hash := documentHash(document)
+	if hash == actor.PublishedHash {
+		return nil
+	}
+	followers, err := store.ListActivityPubFollowers(ctx, actor.IdentityID)
+	if err != nil {
+		return err
+	}
+	if inboxes := followerInboxes(followers); len(inboxes) > 0 {
+		actorURL := actorURLFor(baseURL, user.Handle)
+		update := map[string]interface{}{
+			"@context":  document["@context"],
+			"id":        newActivityID(actorURL),
+			"type":      "Update",
+			"actor":     actorURL,
+			"to":        []string{activityStreamsPublic},
+			"cc":        []string{actorURL + "/followers"},
+			"published": now.UTC().Format(time.RFC3339),
+			"object":    document,
+		}
+		if err := enqueue(ctx, store, actor.IdentityID, update, true, inboxes); err != nil {
+			return err
+		}
+	}
+	return store.UpdateActivityPubActorState(ctx, actor.IdentityID, baseURL, hash)
+}
+
+// deliverDue attempts each due delivery once, scheduling a retry or giving up on failure.
+func (w *Worker) deliverDue(ctx context.Context) error {
+	deliveries, err := w.store.ListDueActivityPubDeliveries(ctx, w.now(), deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		err := w.deliver(ctx, delivery.IdentityID, delivery.Inbox, []byte(delivery.Payload))
+		if err == nil {
+			if err := w.store.MarkActivityPubDelivered(ctx, delivery.ID, w.now()); err != nil {
+				return err
+			}
+			continue
+		}
+		var next sql.NullTime
+		attempts := delivery.Attempts + 1
+		if retryable(err) && attempts < w.cfg.MaxAttempts {
+			next = sql.NullTime{Time: w.now().Add(retryDelay(attempts)), Valid: true}
+		}
+		if err := w.store.RecordActivityPubDeliveryFailure(ctx, delivery.ID, next, err.Error()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver POSTs payload to inbox signed with the identity's current actor key.
+func (w *Worker) deliver(ctx context.Context, identityID int, inbox string, payload []byte) error {
+	var envelope struct {
+		Actor string `json:"actor"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/activity+json")
+	req.Header.Set("Accept", activityAccept)
+	if err := NewService(w.store).signAs(ctx, req, payload, identityID, envelope.Actor+"#main-key"); err != nil {
+		return err
+	}
+	resp, err := w.store.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxRemoteBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return deliveryError{status: resp.StatusCode}
+	}
+	return nil
+}
+
+// deliveryError is a non-2xx answer from a remote inbox.
+type deliveryError struct {
+	status int
+}
+
+// Error describes the rejected delivery.
+func (e deliveryError) Error() string {
+	return fmt.Sprintf("inbox answered %d", e.status)
+}
+
+// retryable reports whether a failed delivery may succeed later; client errors other
+// than timeouts and rate limits mean the inbox rejected the activity for good.
+func retryable(err error) bool {
+	failure, ok := err.(deliveryError)
+	if !ok || failure.status < 400 || failure.status > 499 {
+		return true
+	}
+	return failure.status == http.StatusRequestTimeout || failure.status == http.StatusTooManyRequests
+}
+
+// retryDelay backs off exponentially from a minute, capped at a day.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 4
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// followerInboxes returns each follower's delivery inbox once, preferring shared inboxes.
+func followerInboxes(followers []domain.ActivityPubFollower) []string {
+	seen := map[string]bool{}
+	var inboxes []string
+	for _, follower := range followers {
+		inbox := follower.SharedInbox
+		if inbox == "" {
+			inbox = follower.Inbox
+		}
+		if inbox == "" || seen[inbox] {
+			continue
+		}
+		seen[inbox] = true
+		inboxes = append(inboxes, inbox)
+	}
+	return inboxes
+}
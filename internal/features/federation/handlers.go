@@ -2,57 +2,66 @@ package federation
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/gorilla/sessions"
 	"pin/internal/config"
 	"pin/internal/domain"
 	"pin/internal/features/identity"
+	"pin/internal/features/identity/did"
+	"pin/internal/features/identity/export"
 	"pin/internal/platform/core"
 )
 
+// Dependencies lists what the federation handlers need from the host application.
 type Dependencies interface {
+	Store
 	GetIdentityByHandle(ctx context.Context, handle string) (domain.Identity, error)
+	ListIdentities(ctx context.Context) ([]domain.Identity, error)
 	GetOwnerIdentity(ctx context.Context) (domain.Identity, error)
+	PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error)
+	GetSession(r *http.Request, name string) (*sessions.Session, error)
+	ValidateCSRF(session *sessions.Session, token string) bool
+	CurrentUser(r *http.Request) (domain.User, error)
+	CurrentIdentity(r *http.Request) (domain.Identity, error)
+	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
+	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
 }
 
 // Handler hosts federation and well-known endpoints.
 type Handler struct {
-	cfg  config.Config
-	deps Dependencies
+	cfg    config.Config
+	deps   Dependencies
+	actors *actorCache
 }
 
 // NewHandler creates a federation handler with required dependencies.
 func NewHandler(cfg config.Config, deps Dependencies) Handler {
-	return Handler{cfg: cfg, deps: deps}
+	return Handler{cfg: cfg, deps: deps, actors: newActorCache()}
 }
 
-// Actor handles the HTTP request.
+// Actor serves an identity's ActivityPub actor, its inbox, outbox and followers collection.
 func (h Handler) Actor(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	if path == "" {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	handle, collection, _ := strings.Cut(path, "/")
+	if handle == "" {
 		http.NotFound(w, r)
 		return
 	}
-
-	if strings.HasSuffix(path, "/inbox") {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		w.WriteHeader(http.StatusAccepted)
-		return
+	method := http.MethodGet
+	if collection == "inbox" {
+		method = http.MethodPost
 	}
-
-	if r.Method != http.MethodGet {
+	if r.Method != method {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	handle := strings.Trim(path, "/")
 	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
 	if err != nil {
 		http.NotFound(w, r)
@@ -63,124 +72,85 @@ func (h Handler) Actor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicUser, _ := identity.VisibleIdentity(user, false)
-	baseURL := core.BaseURL(r)
-	actorURL := baseURL + "/users/" + publicUser.Handle
-	inboxURL := baseURL + "/users/" + publicUser.Handle + "/inbox"
-	profilePictureURL := baseURL + "/" + publicUser.Handle + "/profile-picture"
-
-	response := map[string]interface{}{
-		"@context": []string{
-			"https://www.w3.org/ns/activitystreams",
-			"https://w3id.org/security/v1",
-		},
-		"id":                actorURL,
-		"type":              "Person",
-		"preferredUsername": publicUser.Handle,
-		"name":              identity.FirstNonEmpty(publicUser.DisplayName, publicUser.Handle),
-		"summary":           publicUser.Bio,
-		"inbox":             inboxURL,
-		"url":               baseURL,
-		"icon": map[string]string{
-			"type":      "Image",
-			"mediaType": "image/png",
-			"url":       profilePictureURL,
-		},
-	}
-	if key := strings.TrimSpace(identity.DecodeStringMap(publicUser.PublicKeysJSON)["activitypub"]); key != "" {
-		response["publicKey"] = map[string]string{
-			"id":           actorURL + "#main-key",
-			"owner":        actorURL,
-			"publicKeyPem": key,
-		}
-	}
-	var socialProfiles []domain.SocialProfile
-	if publicUser.SocialProfilesJSON != "" {
-		_ = json.Unmarshal([]byte(publicUser.SocialProfilesJSON), &socialProfiles)
-	}
-	if attachment := identity.BuildAttachments(publicUser, identity.DecodeStringMap(publicUser.WalletsJSON), identity.DecodeStringMap(publicUser.PublicKeysJSON), identity.DecodeStringSlice(publicUser.VerifiedDomainsJSON), socialProfiles); len(attachment) > 0 {
-		response["attachment"] = attachment
+	switch collection {
+	case "":
+	case "inbox":
+		h.inbox(w, r, user)
+		return
+	case "outbox":
+		h.outbox(w, r, user)
+		return
+	case "followers":
+		h.followers(w, r, user)
+		return
+	default:
+		http.NotFound(w, r)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/activity+json")
-	_ = json.NewEncoder(w).Encode(response)
-}
-
-// WellKnownPinVerify serves .well-known/pin-verify from the static directory.
-func (h Handler) WellKnownPinVerify(w http.ResponseWriter, r *http.Request) {
-	path := filepath.Join(h.cfg.StaticDir, ".well-known", "pin-verify")
-	if _, err := os.Stat(path); err != nil {
-		http.NotFound(w, r)
+	baseURL := h.baseURL(r)
+	actor, err := NewService(h.deps).ensureActor(r.Context(), user, baseURL)
+	if err != nil {
+		http.Error(w, "Failed to load actor", http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	http.ServeFile(w, r, path)
+	writeActivityJSON(w, actorDocument(baseURL, user, actor))
 }
 
-// Webfinger handles the HTTP request.
-func (h Handler) Webfinger(w http.ResponseWriter, r *http.Request) {
-	resource := r.URL.Query().Get("resource")
-	if !strings.HasPrefix(resource, "acct:") {
-		http.Error(w, "resource must start with acct:", http.StatusBadRequest)
+// RotateKeys replaces the current identity's actor keys and announces the new ones to followers.
+func (h Handler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	acct := strings.TrimPrefix(resource, "acct:")
-	parts := strings.SplitN(acct, "@", 2)
-	if len(parts) != 2 {
-		http.Error(w, "invalid resource", http.StatusBadRequest)
+	session, _ := h.deps.GetSession(r, "pin_session")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
-	handle := parts[0]
-
-	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if !h.deps.ValidateCSRF(session, r.FormValue("csrf_token")) {
+		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		return
+	}
+	current, err := h.deps.CurrentUser(r)
 	if err != nil {
-		http.NotFound(w, r)
+		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
-	if !identity.MatchesIdentity(user, handle) {
-		http.NotFound(w, r)
+	currentIdentity, err := h.deps.CurrentIdentity(r)
+	if err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
-
-	baseURL := core.BaseURL(r)
-	actorURL := baseURL + "/users/" + user.Handle
-	profileURL := baseURL
-
-	response := map[string]interface{}{
-		"subject": resource,
-		"aliases": []string{actorURL, profileURL},
-		"links": []map[string]string{
-			{
-				"rel":  "self",
-				"type": "application/activity+json",
-				"href": actorURL,
-			},
-			{
-				"rel":  "http://webfinger.net/rel/profile-page",
-				"type": "text/html",
-				"href": profileURL,
-			},
-		},
+	h.deps.AuditAttempt(r.Context(), current.ID, "activitypub.key.rotate", currentIdentity.Handle, nil)
+	err = NewService(h.deps).RotateKeys(r.Context(), currentIdentity, h.baseURL(r))
+	h.deps.AuditOutcome(r.Context(), current.ID, "activitypub.key.rotate", currentIdentity.Handle, err, nil)
+	if err != nil {
+		http.Error(w, "Failed to rotate keys", http.StatusInternalServerError)
+		return
 	}
+	http.Redirect(w, r, "/settings/security?toast=Federation%20keys%20rotated#section-activitypub", http.StatusFound)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
+// WellKnownPinVerify serves .well-known/pin-verify from the static directory.
+func (h Handler) WellKnownPinVerify(w http.ResponseWriter, r *http.Request) {
+	path := filepath.Join(h.cfg.StaticDir, ".well-known", "pin-verify")
+	if _, err := os.Stat(path); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeFile(w, r, path)
 }
 
-// AtprotoDID returns the configured atproto DID if present.
-func (h Handler) AtprotoDID(w http.ResponseWriter, r *http.Request) {
+// OwnerDID serves the owner's did:web document at /.well-known/did.json.
+func (h Handler) OwnerDID(w http.ResponseWriter, r *http.Request) {
 	user, err := h.deps.GetOwnerIdentity(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
-		return
-	}
-	did := strings.TrimSpace(user.ATProtoDID)
-	if did == "" {
 		http.NotFound(w, r)
 		return
 	}
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	_, _ = w.Write([]byte(did))
+	did.Write(w, did.Document(h.baseURL(r), user, true))
 }
 
 // PincCapability handles HTTP requests for capability.
@@ -192,6 +162,23 @@ func (h Handler) PincCapability(w http.ResponseWriter, r *http.Request) {
 		"export_formats": []string{"json", "xml", "txt", "vcf"},
 		"views":          []string{"public", "private"},
 		"media_formats":  []string{"webp", "png", "jpeg"},
+		"did": map[string]interface{}{
+			"method":   "web",
+			"owner":    base + "/.well-known/did.json",
+			"identity": base + "/{handle}/did.json",
+		},
+	}
+	// Publish the node key so relayed or cached PINC JSON can be verified offline.
+	if key, err := h.deps.PINCSigningKey(r.Context()); err == nil && key != nil {
+		if pub, ok := key.Public().(ed25519.PublicKey); ok {
+			payload["signing"] = map[string]interface{}{
+				"alg":              export.SignatureAlgorithm,
+				"format":           "jws-detached",
+				"location":         "meta.signature",
+				"canonicalization": "sorted-keys-compact",
+				"keys":             []map[string]string{export.PublicJWK(pub)},
+			}
+		}
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	_ = json.NewEncoder(w).Encode(payload)
@@ -215,9 +202,10 @@ func (h Handler) PincIdentitySchema(w http.ResponseWriter, r *http.Request) {
 						"type": "string",
 						"enum": []string{"public", "private"},
 					},
-					"subject": map[string]interface{}{"type": "string"},
-					"rev":     map[string]interface{}{"type": "string"},
-					"self":    map[string]interface{}{"type": "string"},
+					"subject":   map[string]interface{}{"type": "string"},
+					"rev":       map[string]interface{}{"type": "string"},
+					"self":      map[string]interface{}{"type": "string"},
+					"signature": map[string]interface{}{"type": "string"},
 				},
 				"required": []string{"version", "base_url", "view", "subject", "rev"},
 			},
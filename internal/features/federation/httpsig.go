@@ -0,0 +1,203 @@
+package federation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxSignatureSkew bounds how far a signed Date may drift from the local clock.
+const maxSignatureSkew = time.Hour
+
+// ErrInvalidSignature is returned when a request's HTTP Signature cannot be verified.
+var ErrInvalidSignature = errors.New("invalid HTTP signature")
+
+// KeyLookup resolves an HTTP Signature keyId to its public key.
+type KeyLookup func(ctx context.Context, keyID string) (crypto.PublicKey, error)
+
+// SignRequest adds Date, Digest (when body is not nil) and a draft-cavage Signature header, the
+// form Mastodon and most fediverse servers expect. RSA keys sign as rsa-sha256, Ed25519 keys as hs2019.
+func SignRequest(req *http.Request, body []byte, keyID string, key crypto.Signer) error {
+	algorithm, err := signingAlgorithm(key)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", digestHeader(body))
+		headers = append(headers, "digest")
+	}
+	signingString, err := buildSigningString(req, headers)
+	if err != nil {
+		return err
+	}
+	var sig []byte
+	if algorithm == "rsa-sha256" {
+		hashed := sha256.Sum256([]byte(signingString))
+		sig, err = key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	} else {
+		sig, err = key.Sign(rand.Reader, []byte(signingString), crypto.Hash(0))
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		keyID,
+		algorithm,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(sig),
+	))
+	return nil
+}
+
+// VerifyRequest checks the request's Signature, Date and Digest headers and returns the keyId it
+// was signed with. POST requests must sign their Digest so the body cannot be swapped.
+func VerifyRequest(req *http.Request, body []byte, lookup KeyLookup) (string, error) {
+	params, err := parseSignatureHeader(req.Header.Get("Signature"))
+	if err != nil {
+		return "", err
+	}
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if req.Method == http.MethodPost {
+		required = append(required, "digest")
+	}
+	for _, name := range required {
+		if !containsString(headers, name) {
+			return "", fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, name)
+		}
+	}
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("%w: bad date", ErrInvalidSignature)
+	}
+	if skew := time.Since(date); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return "", fmt.Errorf("%w: date outside the allowed window", ErrInvalidSignature)
+	}
+	if containsString(headers, "digest") && !digestMatches(req.Header.Get("Digest"), body) {
+		return "", fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return "", fmt.Errorf("%w: bad signature encoding", ErrInvalidSignature)
+	}
+	signingString, err := buildSigningString(req, headers)
+	if err != nil {
+		return "", err
+	}
+	keyID := params["keyId"]
+	key, err := lookup(req.Context(), keyID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		hashed := sha256.Sum256([]byte(signingString))
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig) != nil {
+			return "", ErrInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, []byte(signingString), sig) {
+			return "", ErrInvalidSignature
+		}
+	default:
+		return "", fmt.Errorf("%w: unsupported key type", ErrInvalidSignature)
+	}
+	return keyID, nil
+}
+
+// ParsePublicKeyPEM decodes a PKIX or PKCS#1 public key as published in actor documents.
+func ParsePublicKeyPEM(value string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(value)))
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+// buildSigningString assembles the lines covered by a signature.
+func buildSigningString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		switch name {
+		case "(request-target)":
+			lines = append(lines, "(request-target): "+strings.ToLower(req.Method)+" "+req.URL.RequestURI())
+		case "host":
+			host := req.Host
+			if host == "" {
+				host = req.URL.Host
+			}
+			lines = append(lines, "host: "+host)
+		default:
+			values := req.Header.Values(name)
+			if len(values) == 0 {
+				return "", fmt.Errorf("%w: missing %s header", ErrInvalidSignature, name)
+			}
+			lines = append(lines, name+": "+strings.Join(values, ", "))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseSignatureHeader splits a Signature header into its quoted parameters.
+func parseSignatureHeader(value string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[name] = strings.Trim(raw, `"`)
+	}
+	if params["keyId"] == "" || params["signature"] == "" {
+		return nil, fmt.Errorf("%w: missing keyId or signature", ErrInvalidSignature)
+	}
+	return params, nil
+}
+
+// digestHeader returns the SHA-256 Digest header value for body.
+func digestHeader(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// digestMatches reports whether a Digest header carries body's SHA-256.
+func digestMatches(header string, body []byte) bool {
+	want := strings.TrimPrefix(digestHeader(body), "SHA-256=")
+	for _, part := range strings.Split(header, ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(alg, "SHA-256") && value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"pin/internal/domain"
+)
+
+// maxRemoteBytes caps inbox bodies and remote documents.
+const maxRemoteBytes = 1 << 20
+
+// outboxPageSize is how many recent activities the outbox lists.
+const outboxPageSize = 20
+
+// activityAccept is the media type requested when dereferencing remote actors.
+const activityAccept = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+// remoteActor is the subset of a remote actor document used to verify and answer it.
+type remoteActor struct {
+	ID        string `json:"id"`
+	Inbox     string `json:"inbox"`
+	Endpoints struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey struct {
+		ID           string `json:"id"`
+		Owner        string `json:"owner"`
+		PublicKeyPem string `json:"publicKeyPem"`
+	} `json:"publicKey"`
+}
+
+// errRejectedActivity marks an activity the inbox refuses, as opposed to a failure storing it.
+var errRejectedActivity = errors.New("activity rejected")
+
+// inboundActivity is the subset of an incoming activity the inbox acts on.
+type inboundActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  json.RawMessage `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// inbox verifies a signed activity and handles follows and unfollows of the identity.
+func (h Handler) inbox(w http.ResponseWriter, r *http.Request, user domain.Identity) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRemoteBytes))
+	if err != nil {
+		http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var activity inboundActivity
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Type == "" {
+		http.Error(w, "Invalid activity", http.StatusBadRequest)
+		return
+	}
+
+	signer, err := h.verifySigner(r, body)
+	if err != nil {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if objectID(activity.Actor) != signer.ID {
+		http.Error(w, "Actor does not match signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch activity.Type {
+	case "Follow":
+		err = h.acceptFollow(r.Context(), h.baseURL(r), user, signer, activity, body)
+	case "Undo":
+		err = h.undoFollow(r.Context(), user, signer, activity)
+	}
+	if err != nil {
+		log.Printf("activitypub inbox %s from %s: %v", activity.Type, signer.ID, err)
+		if errors.Is(err, errRejectedActivity) {
+			http.Error(w, "Activity rejected", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Failed to process activity", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifySigner checks the request's signature against the signing actor, reusing a cached
+// actor for its keyId. A cached key that no longer verifies is refetched once, so remote key
+// rotations are picked up.
+func (h Handler) verifySigner(r *http.Request, body []byte) (remoteActor, error) {
+	var signer remoteActor
+	cached := false
+	lookup := func(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+		actor, ok := h.actors.get(keyID)
+		if !ok {
+			var err error
+			if actor, err = fetchActor(ctx, h.deps.HTTPClient(), keyID); err != nil {
+				return nil, err
+			}
+		}
+		cached = ok
+		signer = actor
+		return ParsePublicKeyPEM(actor.PublicKey.PublicKeyPem)
+	}
+	keyID, err := VerifyRequest(r, body, lookup)
+	if err != nil && cached {
+		h.actors.forget(keyID)
+		keyID, err = VerifyRequest(r, body, lookup)
+	}
+	if err != nil {
+		return remoteActor{}, err
+	}
+	h.actors.put(keyID, signer)
+	return signer, nil
+}
+
+// acceptFollow stores the follower and queues a signed Accept back to its inbox.
+func (h Handler) acceptFollow(ctx context.Context, baseURL string, user domain.Identity, signer remoteActor, follow inboundActivity, body []byte) error {
+	actorURL := actorURLFor(baseURL, user.Handle)
+	if objectID(follow.Object) != actorURL {
+		return fmt.Errorf("%w: follow object is not this actor", errRejectedActivity)
+	}
+	if _, err := NewService(h.deps).ensureActor(ctx, user, baseURL); err != nil {
+		return err
+	}
+	if err := h.deps.AddActivityPubFollower(ctx, domain.ActivityPubFollower{
+		IdentityID:  user.ID,
+		ActorID:     signer.ID,
+		Inbox:       signer.Inbox,
+		SharedInbox: signer.Endpoints.SharedInbox,
+		FollowID:    follow.ID,
+	}); err != nil {
+		return err
+	}
+	accept := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       newActivityID(actorURL),
+		"type":     "Accept",
+		"actor":    actorURL,
+		"object":   json.RawMessage(body),
+	}
+	return enqueue(ctx, h.deps, user.ID, accept, false, []string{signer.Inbox})
+}
+
+// undoFollow removes the signer from the identity's followers when it undoes its Follow.
+func (h Handler) undoFollow(ctx context.Context, user domain.Identity, signer remoteActor, undo inboundActivity) error {
+	var inner inboundActivity
+	if err := json.Unmarshal(undo.Object, &inner); err == nil {
+		if inner.Type != "Follow" {
+			return nil
+		}
+		if actor := objectID(inner.Actor); actor != "" && actor != signer.ID {
+			return fmt.Errorf("%w: undo of another actor's follow", errRejectedActivity)
+		}
+		return h.deps.RemoveActivityPubFollower(ctx, user.ID, signer.ID)
+	}
+	// A bare ID can only undo the follow it names.
+	followID := objectID(undo.Object)
+	followers, err := h.deps.ListActivityPubFollowers(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, follower := range followers {
+		if follower.ActorID == signer.ID && follower.FollowID != "" && follower.FollowID == followID {
+			return h.deps.RemoveActivityPubFollower(ctx, user.ID, signer.ID)
+		}
+	}
+	return nil
+}
+
+// outbox lists the identity's most recent public activities.
+func (h Handler) outbox(w http.ResponseWriter, r *http.Request, user domain.Identity) {
+	activities, total, err := h.deps.ListActivityPubOutbox(r.Context(), user.ID, outboxPageSize)
+	if err != nil {
+		http.Error(w, "Failed to load outbox", http.StatusInternalServerError)
+		return
+	}
+	items := make([]json.RawMessage, 0, len(activities))
+	for _, activity := range activities {
+		items = append(items, json.RawMessage(activity.Payload))
+	}
+	writeActivityJSON(w, map[string]interface{}{
+		"@context":     "https://www.w3.org/ns/activitystreams",
+		"id":           actorURLFor(h.baseURL(r), user.Handle) + "/outbox",
+		"type":         "OrderedCollection",
+		"totalItems":   total,
+		"orderedItems": items,
+	})
+}
+
+// followers publishes the follower count; the members themselves stay private.
+func (h Handler) followers(w http.ResponseWriter, r *http.Request, user domain.Identity) {
+	followers, err := h.deps.ListActivityPubFollowers(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load followers", http.StatusInternalServerError)
+		return
+	}
+	writeActivityJSON(w, map[string]interface{}{
+		"@context":   "https://www.w3.org/ns/activitystreams",
+		"id":         actorURLFor(h.baseURL(r), user.Handle) + "/followers",
+		"type":       "OrderedCollection",
+		"totalItems": len(followers),
+	})
+}
+
+// enqueue records an activity and queues its delivery to inboxes.
+func enqueue(ctx context.Context, store Store, identityID int, activity map[string]interface{}, public bool, inboxes []string) error {
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	_, err = store.CreateActivityPubActivity(ctx, domain.ActivityPubActivity{
+		IdentityID: identityID,
+		ActivityID: activity["id"].(string),
+		Type:       activity["type"].(string),
+		Payload:    string(payload),
+		Public:     public,
+	}, inboxes)
+	return err
+}
+
+// fetchActor dereferences the actor owning keyID and checks the key really belongs to it.
+func fetchActor(ctx context.Context, client *http.Client, keyID string) (remoteActor, error) {
+	target, _, _ := strings.Cut(keyID, "#")
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return remoteActor{}, fmt.Errorf("unsupported key id %q", keyID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return remoteActor{}, err
+	}
+	req.Header.Set("Accept", activityAccept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return remoteActor{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteActor{}, fmt.Errorf("fetch actor: status %d", resp.StatusCode)
+	}
+	var actor remoteActor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteBytes)).Decode(&actor); err != nil {
+		return remoteActor{}, err
+	}
+	if actor.PublicKey.ID != keyID || actor.PublicKey.Owner != actor.ID || actor.Inbox == "" {
+		return remoteActor{}, errors.New("key does not belong to actor")
+	}
+	// Only the actor's own server may speak for it or choose where it receives deliveries.
+	if !sameOrigin(parsed, resp.Request.URL.String(), actor.ID, actor.Inbox) ||
+		(actor.Endpoints.SharedInbox != "" && !sameOrigin(parsed, actor.Endpoints.SharedInbox)) {
+		return remoteActor{}, errors.New("actor is not on the origin it was fetched from")
+	}
+	return actor, nil
+}
+
+// sameOrigin reports whether every URL in others has base's scheme and host.
+func sameOrigin(base *url.URL, others ...string) bool {
+	for _, other := range others {
+		parsed, err := url.Parse(other)
+		if err != nil || !strings.EqualFold(parsed.Scheme, base.Scheme) || !strings.EqualFold(parsed.Host, base.Host) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectID returns an ActivityStreams reference's ID, whether given as a string or an object.
+func objectID(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		return object.ID
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package federation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// actorKeyBits is the RSA key size Mastodon-compatible servers expect for actor keys.
+const actorKeyBits = 2048
+
+// Service manages identities' actor keys and signs outbound requests with them.
+type Service struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewService constructs a new federation service.
+func NewService(store Store) Service {
+	return Service{store: store, now: time.Now}
+}
+
+// SignRequest signs an outbound request as the identity's actor under baseURL, creating its keys if needed.
+func (s Service) SignRequest(ctx context.Context, req *http.Request, body []byte, user domain.Identity, baseURL string) error {
+	if _, err := s.ensureActor(ctx, user, baseURL); err != nil {
+		return err
+	}
+	return s.signAs(ctx, req, body, user.ID, actorURLFor(baseURL, user.Handle)+"#main-key")
+}
+
+// RotateKeys replaces the identity's keypairs and queues an Update so followers refetch the new public key.
+func (s Service) RotateKeys(ctx context.Context, user domain.Identity, baseURL string) error {
+	actor, err := s.ensureActor(ctx, user, baseURL)
+	if err != nil {
+		return err
+	}
+	fresh, err := s.generateKeys(user.ID)
+	if err != nil {
+		return err
+	}
+	actor.PublicKeyPEM = fresh.PublicKeyPEM
+	actor.PrivateKey = fresh.PrivateKey
+	actor.Ed25519PublicKey = fresh.Ed25519PublicKey
+	actor.Ed25519PrivateKey = fresh.Ed25519PrivateKey
+	actor.RotatedAt = sql.NullTime{Time: s.now().UTC(), Valid: true}
+	if err := s.store.UpdateActivityPubActorKeys(ctx, actor); err != nil {
+		return err
+	}
+	return publishActor(ctx, s.store, actor, baseURL, user, s.now())
+}
+
+// ensureActor returns the identity's actor keys, generating them on first use.
+// A new actor starts with its current document marked as published.
+func (s Service) ensureActor(ctx context.Context, user domain.Identity, baseURL string) (domain.ActivityPubActor, error) {
+	actor, err := s.store.GetActivityPubActor(ctx, user.ID)
+	if err == nil && isLegacyKey(actor.PrivateKey) {
+		return s.sealLegacy(ctx, actor)
+	}
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return actor, err
+	}
+	actor, err = s.generateKeys(user.ID)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	actor.BaseURL = baseURL
+	actor.PublishedHash = documentHash(actorDocument(baseURL, user, actor))
+	if err := s.store.CreateActivityPubActor(ctx, actor); err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	// Re-read so concurrent first requests all settle on the keys that were stored.
+	return s.store.GetActivityPubActor(ctx, user.ID)
+}
+
+// sealLegacy seals a key stored in plain PEM before keys were encrypted and adds the missing Ed25519 key.
+func (s Service) sealLegacy(ctx context.Context, actor domain.ActivityPubActor) (domain.ActivityPubActor, error) {
+	block, _ := pem.Decode([]byte(actor.PrivateKey))
+	if block == nil {
+		return domain.ActivityPubActor{}, errors.New("unreadable legacy actor key")
+	}
+	sealed, err := s.store.SealSecret(block.Bytes)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	fresh, err := s.generateKeys(actor.IdentityID)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	actor.PrivateKey = sealed
+	actor.Ed25519PublicKey = fresh.Ed25519PublicKey
+	actor.Ed25519PrivateKey = fresh.Ed25519PrivateKey
+	if err := s.store.UpdateActivityPubActorKeys(ctx, actor); err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	return actor, nil
+}
+
+// isLegacyKey reports whether a private key was stored as plain PEM.
+func isLegacyKey(value string) bool {
+	return strings.HasPrefix(value, "-----BEGIN")
+}
+
+// generateKeys creates RSA and Ed25519 keypairs with the private halves sealed for storage.
+func (s Service) generateKeys(identityID int) (domain.ActivityPubActor, error) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, actorKeyBits)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	multikey, _ := identity.Multikey(edPublic)
+	sealedRSA, err := s.store.SealSecret(privateDER)
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	sealedEd25519, err := s.store.SealSecret(edPrivate.Seed())
+	if err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	return domain.ActivityPubActor{
+		IdentityID:        identityID,
+		PublicKeyPEM:      string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		PrivateKey:        sealedRSA,
+		Ed25519PublicKey:  multikey,
+		Ed25519PrivateKey: sealedEd25519,
+	}, nil
+}
+
+// signAs signs req with the identity's RSA key under keyID.
+func (s Service) signAs(ctx context.Context, req *http.Request, body []byte, identityID int, keyID string) error {
+	actor, err := s.store.GetActivityPubActor(ctx, identityID)
+	if err != nil {
+		return err
+	}
+	key, err := s.openRSAKey(actor)
+	if err != nil {
+		return err
+	}
+	return SignRequest(req, body, keyID, key)
+}
+
+// openRSAKey unseals an actor's RSA signing key.
+func (s Service) openRSAKey(actor domain.ActivityPubActor) (*rsa.PrivateKey, error) {
+	var der []byte
+	if isLegacyKey(actor.PrivateKey) {
+		block, _ := pem.Decode([]byte(actor.PrivateKey))
+		if block == nil {
+			return nil, errors.New("unreadable legacy actor key")
+		}
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = s.store.OpenSecret(actor.PrivateKey); err != nil {
+			return nil, err
+		}
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("actor key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// KeyFingerprint returns a short SHA-256 fingerprint of a PEM public key for display.
+func KeyFingerprint(publicKeyPEM string) string {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(publicKeyPEM)))
+	if block == nil {
+		return ""
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return "SHA256:" + hex.EncodeToString(sum[:])[:32]
+}
+
+// signingAlgorithm names the Signature algorithm parameter for key.
+func signingAlgorithm(key crypto.Signer) (string, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return "rsa-sha256", nil
+	case ed25519.PublicKey:
+		return "hs2019", nil
+	default:
+		return "", errors.New("unsupported signing key")
+	}
+}
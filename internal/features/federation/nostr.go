@@ -0,0 +1,90 @@
+package federation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// nostrRootName is the NIP-05 name for the bare domain (_@example.com); it maps to the owner.
+const nostrRootName = "_"
+
+// nostrDocument is the NIP-05 nostr.json body.
+type nostrDocument struct {
+	Names  map[string]string   `json:"names"`
+	Relays map[string][]string `json:"relays,omitempty"`
+}
+
+// NostrJSON serves NIP-05 names for identities with a public nostr key. With ?name= it answers
+// for that identity only; without it, for every identity on the node.
+func (h Handler) NostrJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	document := nostrDocument{Names: map[string]string{}, Relays: map[string][]string{}}
+	name := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("name")))
+	if name != "" {
+		if user, err := h.nostrIdentity(r.Context(), name); err == nil {
+			addNostrName(&document, name, user)
+		}
+	} else {
+		users, err := h.deps.ListIdentities(r.Context())
+		if err != nil {
+			http.Error(w, "Failed to load identities", http.StatusInternalServerError)
+			return
+		}
+		for _, listed := range users {
+			user, err := h.deps.GetIdentityByID(r.Context(), listed.ID)
+			if err != nil {
+				continue
+			}
+			addNostrName(&document, strings.ToLower(user.Handle), user)
+		}
+		if owner, err := h.deps.GetOwnerIdentity(r.Context()); err == nil {
+			addNostrName(&document, nostrRootName, owner)
+		}
+	}
+	if len(document.Relays) == 0 {
+		document.Relays = nil
+	}
+
+	// NIP-05 requires CORS so web clients can verify names.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+// nostrIdentity resolves a NIP-05 name to an identity; "_" is the owner.
+func (h Handler) nostrIdentity(ctx context.Context, name string) (domain.Identity, error) {
+	if name == nostrRootName {
+		return h.deps.GetOwnerIdentity(ctx)
+	}
+	user, err := h.deps.GetIdentityByHandle(ctx, name)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	if !identity.MatchesIdentity(user, name) {
+		return domain.Identity{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// addNostrName adds name for the public view of user when its nostr key is public and valid,
+// with the identity's relay hints when those are public too.
+func addNostrName(document *nostrDocument, name string, user domain.Identity) {
+	publicUser, _ := identity.VisibleIdentity(user, false)
+	pubkey, err := identity.ParseNostrKey(identity.DecodeStringMap(publicUser.PublicKeysJSON)["nostr"])
+	if err != nil {
+		return
+	}
+	document.Names[name] = pubkey
+	if relays := identity.DecodeStringSlice(publicUser.NostrRelaysJSON); len(relays) > 0 {
+		document.Relays[pubkey] = relays
+	}
+}
@@ -13,13 +13,20 @@ func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies, pu
 	register := func(pattern string, handler http.Handler) {
 		reg.RegisterRoute(mux, pattern, handler)
 	}
+	requireLogin := func(next http.HandlerFunc) http.HandlerFunc {
+		return reg.RequireSession(next, "/login?next=/settings")
+	}
 
 	handler := NewHandler(cfg, deps)
 	publicHandler := public.NewHandler(publicDeps)
 	register("/.well-known/webfinger", http.HandlerFunc(handler.Webfinger))
 	register("/.well-known/atproto-did", http.HandlerFunc(handler.AtprotoDID))
+	register("/.well-known/did.json", http.HandlerFunc(handler.OwnerDID))
+	register("/.well-known/nostr.json", http.HandlerFunc(handler.NostrJSON))
+	register("/.well-known/openpgpkey/", http.HandlerFunc(handler.OpenPGPKey))
 	register("/.well-known/pin-verify", http.HandlerFunc(handler.WellKnownPinVerify))
 	register("/users/", http.HandlerFunc(handler.Actor))
+	register("/settings/security/activitypub/rotate", http.HandlerFunc(requireLogin(handler.RotateKeys)))
 	register("/p/", http.HandlerFunc(publicHandler.PrivateIdentity))
 	register("/.well-known/pinc", http.HandlerFunc(handler.PincCapability))
 	register("/.well-known/pinc/identity", http.HandlerFunc(handler.PincIdentitySchema))
@@ -0,0 +1,137 @@
+package federation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// WebFinger link relations beyond ActivityPub's self link.
+const (
+	relProfilePage = "http://webfinger.net/rel/profile-page"
+	relAvatar      = "http://webfinger.net/rel/avatar"
+)
+
+// errForeignResource marks a WebFinger resource on a host this node does not serve.
+var errForeignResource = errors.New("resource is not hosted here")
+
+// Webfinger resolves acct:, profile and actor URIs to an identity's JRD (RFC 7033).
+func (h Handler) Webfinger(w http.ResponseWriter, r *http.Request) {
+	resource := strings.TrimSpace(r.URL.Query().Get("resource"))
+	if resource == "" {
+		http.Error(w, "resource is required", http.StatusBadRequest)
+		return
+	}
+	baseURL := h.baseURL(r)
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Host == "" {
+		http.Error(w, "Failed to resolve resource", http.StatusInternalServerError)
+		return
+	}
+	handle, err := resourceHandle(resource, base)
+	if errors.Is(err, errForeignResource) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !identity.MatchesIdentity(user, handle) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/jrd+json")
+	_ = json.NewEncoder(w).Encode(webfingerDocument(baseURL, base.Host, user, r.URL.Query()["rel"]))
+}
+
+// resourceHandle extracts the handle from an acct: URI or an http(s) profile or actor URL,
+// rejecting resources on other hosts.
+func resourceHandle(resource string, base *url.URL) (string, error) {
+	if strings.HasPrefix(strings.ToLower(resource), "acct:") {
+		acct := resource[len("acct:"):]
+		at := strings.LastIndex(acct, "@")
+		if at <= 0 || at == len(acct)-1 {
+			return "", errors.New("invalid acct resource")
+		}
+		handle, _ := url.PathUnescape(acct[:at])
+		if !servesHost(acct[at+1:], base) {
+			return "", errForeignResource
+		}
+		return strings.TrimPrefix(handle, "@"), nil
+	}
+	target, err := url.Parse(resource)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return "", errors.New("resource must be an acct: or https: URI")
+	}
+	if !servesHost(target.Host, base) {
+		return "", errForeignResource
+	}
+	path := strings.Trim(target.Path, "/")
+	path = strings.TrimPrefix(path, "users/")
+	if path == "" || strings.Contains(path, "/") {
+		return "", errForeignResource
+	}
+	return path, nil
+}
+
+// servesHost reports whether host names this node, with or without the base URL's port.
+func servesHost(host string, base *url.URL) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == strings.ToLower(base.Host) || host == strings.ToLower(base.Hostname())
+}
+
+// webfingerDocument builds the JRD for user, keeping only links whose rel is in rels when any are given.
+func webfingerDocument(baseURL, host string, user domain.Identity, rels []string) map[string]interface{} {
+	actorURL := actorURLFor(baseURL, user.Handle)
+	profileURL := baseURL + "/" + url.PathEscape(user.Handle)
+	links := []map[string]string{
+		{
+			"rel":  "self",
+			"type": "application/activity+json",
+			"href": actorURL,
+		},
+		{
+			"rel":  relProfilePage,
+			"type": "text/html",
+			"href": profileURL,
+		},
+		{
+			"rel":  "alternate",
+			"type": "application/json",
+			"href": profileURL + ".json",
+		},
+		{
+			"rel":  relAvatar,
+			"type": "image/png",
+			"href": profileURL + "/profile-picture",
+		},
+	}
+	if len(rels) > 0 {
+		filtered := links[:0]
+		for _, link := range links {
+			if containsString(rels, link["rel"]) {
+				filtered = append(filtered, link)
+			}
+		}
+		links = filtered
+	}
+	return map[string]interface{}{
+		"subject": "acct:" + user.Handle + "@" + host,
+		"aliases": []string{profileURL, actorURL},
+		"links":   links,
+	}
+}
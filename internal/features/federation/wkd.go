@@ -0,0 +1,98 @@
+package federation
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// wkdPrefix is the Web Key Directory root; see draft-koch-openpgp-webkey-service.
+const wkdPrefix = "/.well-known/openpgpkey/"
+
+// OpenPGPKey serves the Web Key Directory: the policy file and each identity's OpenPGP key by
+// hashed local part. Both the direct (/hu/{hash} on the mail domain) and the advanced
+// (/{domain}/hu/{hash} on openpgpkey.{domain}) layouts are answered.
+func (h Handler) OpenPGPKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mailDomain, rest := requestHost(r), strings.Trim(strings.TrimPrefix(r.URL.Path, wkdPrefix), "/")
+	if first, after, ok := strings.Cut(rest, "/"); ok && first != "hu" {
+		mailDomain, rest = strings.ToLower(first), after
+	}
+	users, err := h.wkdIdentities(r.Context(), mailDomain)
+	if err != nil {
+		http.Error(w, "Failed to load identities", http.StatusInternalServerError)
+		return
+	}
+	if len(users) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if rest == "policy" {
+		// An empty policy announces WKD support for the domain with default settings.
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		return
+	}
+	hash, ok := strings.CutPrefix(rest, "hu/")
+	if !ok || hash == "" || strings.Contains(hash, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	for _, user := range users {
+		local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(user.Email)), "@")
+		if identity.WKDHash(local) != hash {
+			continue
+		}
+		publicUser, _ := identity.VisibleIdentity(user, false)
+		key, err := identity.ParseOpenPGPKey(identity.DecodeStringMap(publicUser.PublicKeysJSON)["pgp"])
+		if err != nil || !key.HasEmail(user.Email) {
+			continue
+		}
+		identity.WriteIdentityCacheHeaders(w)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(key.Packets)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// wkdIdentities returns the identities whose email is on mailDomain and that have verified it.
+func (h Handler) wkdIdentities(ctx context.Context, mailDomain string) ([]domain.Identity, error) {
+	listed, err := h.deps.ListIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.Identity
+	for _, entry := range listed {
+		if !strings.HasSuffix(strings.ToLower(strings.TrimSpace(entry.Email)), "@"+mailDomain) {
+			continue
+		}
+		user, err := h.deps.GetIdentityByID(ctx, entry.ID)
+		if err != nil {
+			continue
+		}
+		for _, verified := range identity.DecodeStringSlice(user.VerifiedDomainsJSON) {
+			if strings.EqualFold(strings.TrimSpace(verified), mailDomain) {
+				out = append(out, user)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// requestHost returns the request's lowercased host without its port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,301 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/features/identity/export"
+)
+
+// DefaultLimit caps how many revisions a history view loads.
+const DefaultLimit = 50
+
+const (
+	noteBaseline      = "baseline"
+	noteRestorePrefix = "restore:"
+)
+
+// ErrHandleTaken indicates a revision's handle now belongs to another identity.
+var ErrHandleTaken = errors.New("the handle in this revision is now used by another identity")
+
+// Store persists identities and their revisions.
+type Store interface {
+	GetIdentityByID(ctx context.Context, id int) (domain.Identity, error)
+	UpdateIdentity(ctx context.Context, identity domain.Identity) error
+	CheckHandleCollision(ctx context.Context, handle string, excludeID int) error
+	CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error)
+	ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error)
+	GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error)
+	GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error)
+}
+
+// Service records and restores identity revisions.
+type Service struct {
+	store Store
+}
+
+// NewService constructs a new service.
+func NewService(store Store) Service {
+	return Service{store: store}
+}
+
+// Entry is a revision decoded for display, with changes relative to the previous revision.
+type Entry struct {
+	Revision     domain.IdentityRevision
+	Identity     domain.Identity
+	Changes      []FieldChange
+	RestoredFrom int
+	Baseline     bool
+}
+
+// PublicEntry is a revision projected through the public view.
+type PublicEntry struct {
+	Rev       string      `json:"rev"`
+	CreatedAt time.Time   `json:"created_at"`
+	Identity  interface{} `json:"identity"`
+}
+
+// Save updates the identity and records the result as a new revision.
+// The pre-update state is captured first so edits made before history existed stay recoverable.
+func (s Service) Save(ctx context.Context, baseURL string, actorID int, updated domain.Identity) error {
+	return s.save(ctx, baseURL, actorID, updated, "")
+}
+
+// save updates the identity, recording the previous and new states.
+func (s Service) save(ctx context.Context, baseURL string, actorID int, updated domain.Identity, note string) error {
+	if before, err := s.store.GetIdentityByID(ctx, updated.ID); err == nil {
+		if err := s.record(ctx, baseURL, 0, before, noteBaseline); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := s.store.UpdateIdentity(ctx, updated); err != nil {
+		return err
+	}
+	after, err := s.store.GetIdentityByID(ctx, updated.ID)
+	if err != nil {
+		return err
+	}
+	return s.record(ctx, baseURL, actorID, after, note)
+}
+
+// record stores current as a revision unless it matches the latest one.
+func (s Service) record(ctx context.Context, baseURL string, actorID int, current domain.Identity, note string) error {
+	snap, err := encodeSnapshot(current)
+	if err != nil {
+		return err
+	}
+	latest, err := s.store.ListIdentityRevisions(ctx, current.ID, 1)
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 && sameSnapshot(latest[0].Snapshot, snap) {
+		return nil
+	}
+	revision := domain.IdentityRevision{
+		IdentityID: current.ID,
+		Rev:        s.rev(ctx, baseURL, current, "private"),
+		Snapshot:   snap,
+		Note:       note,
+	}
+	if actorID > 0 {
+		revision.ActorID = sql.NullInt64{Int64: int64(actorID), Valid: true}
+	}
+	_, err = s.store.CreateIdentityRevision(ctx, revision)
+	return err
+}
+
+// Rev returns the private-view PINC rev that revisions of the identity are keyed by.
+func (s Service) Rev(ctx context.Context, baseURL string, user domain.Identity) string {
+	return s.rev(ctx, baseURL, user, "private")
+}
+
+// rev returns the PINC rev of the identity as exported in the given view.
+func (s Service) rev(ctx context.Context, baseURL string, user domain.Identity, view string) string {
+	visible, customFields := identity.VisibleIdentity(user, view == "private")
+	envelope, err := export.NewHandler(revisionSource{store: s.store}).BuildPINCForBase(ctx, baseURL, visible, customFields, view, "")
+	if err != nil {
+		return ""
+	}
+	return envelope.Meta.Rev
+}
+
+// List returns the newest revisions with field-level changes against their predecessor.
+func (s Service) List(ctx context.Context, identityID, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	revisions, err := s.store.ListIdentityRevisions(ctx, identityID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	decoded := make([]domain.Identity, len(revisions))
+	for i, revision := range revisions {
+		decoded[i], err = decodeSnapshot(revision.Snapshot, domain.Identity{ID: identityID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries := make([]Entry, 0, limit)
+	for i := 0; i < len(revisions) && i < limit; i++ {
+		entry := Entry{
+			Revision: revisions[i],
+			Identity: decoded[i],
+			Baseline: revisions[i].Note == noteBaseline,
+		}
+		if strings.HasPrefix(revisions[i].Note, noteRestorePrefix) {
+			entry.RestoredFrom, _ = strconv.Atoi(strings.TrimPrefix(revisions[i].Note, noteRestorePrefix))
+		}
+		if i+1 < len(revisions) {
+			entry.Changes = Diff(decoded[i+1], decoded[i])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Restore writes a revision's fields back onto the identity as a new revision.
+func (s Service) Restore(ctx context.Context, baseURL string, actorID, identityID, revisionID int) (domain.Identity, error) {
+	revision, err := s.store.GetIdentityRevision(ctx, identityID, revisionID)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	current, err := s.store.GetIdentityByID(ctx, identityID)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	restored, err := decodeSnapshot(revision.Snapshot, current)
+	if err != nil {
+		return domain.Identity{}, err
+	}
+	restored = keepVerification(restored, current)
+	if !strings.EqualFold(restored.Handle, current.Handle) {
+		if err := s.store.CheckHandleCollision(ctx, restored.Handle, identityID); err != nil {
+			return domain.Identity{}, ErrHandleTaken
+		}
+	}
+	// Pictures deleted since the revision cannot be reselected.
+	if restored.ProfilePictureID.Valid {
+		if _, err := s.store.GetProfilePictureAlt(ctx, identityID, restored.ProfilePictureID.Int64); err != nil {
+			restored.ProfilePictureID = sql.NullInt64{}
+		}
+	}
+	if err := s.save(ctx, baseURL, actorID, restored, noteRestorePrefix+strconv.Itoa(revision.ID)); err != nil {
+		return domain.Identity{}, err
+	}
+	return s.store.GetIdentityByID(ctx, identityID)
+}
+
+// keepVerification resets verification state on a restored identity to what the current record
+// has proven: verified domains stay as they are now, and links and social profiles keep their
+// verification only when the same URL is verified today. Restoring can therefore never bring
+// back a domain or backlink that was demoted or removed since the revision.
+func keepVerification(restored, current domain.Identity) domain.Identity {
+	restored.VerifiedDomainsJSON = current.VerifiedDomainsJSON
+
+	verifiedLinks := map[string]domain.Link{}
+	for _, link := range identity.DecodeLinks(current.LinksJSON) {
+		if link.Verified {
+			verifiedLinks[link.URL] = link
+		}
+	}
+	links := identity.DecodeLinks(restored.LinksJSON)
+	for i := range links {
+		now := verifiedLinks[links[i].URL]
+		links[i].Verified, links[i].VerifiedAt = now.Verified, now.VerifiedAt
+	}
+	if len(links) > 0 {
+		restored.LinksJSON = identity.EncodeLinks(links)
+	}
+
+	verifiedProfiles := map[string]domain.SocialProfile{}
+	for _, profile := range identity.DecodeSocialProfiles(current.SocialProfilesJSON) {
+		if profile.Verified {
+			verifiedProfiles[profile.URL] = profile
+		}
+	}
+	profiles := identity.DecodeSocialProfiles(restored.SocialProfilesJSON)
+	for i := range profiles {
+		now := verifiedProfiles[profiles[i].URL]
+		profiles[i].Verified, profiles[i].VerifiedAt = now.Verified, now.VerifiedAt
+	}
+	if len(profiles) > 0 {
+		restored.SocialProfilesJSON = identity.EncodeSocialProfiles(profiles)
+	}
+	return restored
+}
+
+// PublicHistory returns revisions as seen through the public view, newest first. Fields are
+// shown only when public both in the revision and in the identity's current visibility, and
+// consecutive revisions that look the same publicly collapse into the oldest of them, so
+// private-only edits and their times stay hidden.
+func (s Service) PublicHistory(ctx context.Context, baseURL string, identityID, limit int) ([]PublicEntry, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	current, err := s.store.GetIdentityByID(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.store.ListIdentityRevisions(ctx, identityID, limit)
+	if err != nil {
+		return nil, err
+	}
+	handler := export.NewHandler(revisionSource{store: s.store})
+	var entries []PublicEntry
+	lastKey := ""
+	for i := len(revisions) - 1; i >= 0; i-- {
+		decoded, err := decodeSnapshot(revisions[i].Snapshot, domain.Identity{ID: identityID})
+		if err != nil {
+			return nil, err
+		}
+		visible, _ := identity.VisibleIdentity(decoded, false)
+		visible.VisibilityJSON = current.VisibilityJSON
+		visible, customFields := identity.VisibleIdentity(visible, false)
+		envelope, err := handler.BuildPINCForBase(ctx, baseURL, visible, customFields, "public", "")
+		if err != nil {
+			return nil, err
+		}
+		key, err := publicKey(envelope.Identity)
+		if err != nil {
+			return nil, err
+		}
+		if key == lastKey {
+			continue
+		}
+		lastKey = key
+		entries = append(entries, PublicEntry{
+			Rev:       envelope.Meta.Rev,
+			CreatedAt: revisions[i].CreatedAt,
+			Identity:  envelope.Identity,
+		})
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// publicKey serializes a public identity payload without its update time, which changes on
+// every save including private-only ones.
+func publicKey(payload interface{}) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "updated_at")
+	raw, err = json.Marshal(fields)
+	return string(raw), err
+}
@@ -0,0 +1,259 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// fakeStore keeps identities and revisions in memory.
+type fakeStore struct {
+	identities map[int]domain.Identity
+	revisions  []domain.IdentityRevision
+	taken      map[string]bool
+}
+
+// newFakeStore returns a store holding identity with a fixed update time.
+func newFakeStore(identity domain.Identity) *fakeStore {
+	identity.UpdatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &fakeStore{
+		identities: map[int]domain.Identity{identity.ID: identity},
+		taken:      map[string]bool{},
+	}
+}
+
+// GetIdentityByID returns the stored identity.
+func (s *fakeStore) GetIdentityByID(ctx context.Context, id int) (domain.Identity, error) {
+	identity, ok := s.identities[id]
+	if !ok {
+		return domain.Identity{}, sql.ErrNoRows
+	}
+	return identity, nil
+}
+
+// UpdateIdentity stores the identity, keeping its update time.
+func (s *fakeStore) UpdateIdentity(ctx context.Context, identity domain.Identity) error {
+	identity.UpdatedAt = s.identities[identity.ID].UpdatedAt
+	s.identities[identity.ID] = identity
+	return nil
+}
+
+// CheckHandleCollision reports handles marked as taken.
+func (s *fakeStore) CheckHandleCollision(ctx context.Context, handle string, excludeID int) error {
+	if s.taken[handle] {
+		return errors.New("taken")
+	}
+	return nil
+}
+
+// CreateIdentityRevision appends the revision with the next ID and a fixed time.
+func (s *fakeStore) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	revision.ID = len(s.revisions) + 1
+	revision.CreatedAt = time.Date(2024, 1, 1, 0, revision.ID, 0, 0, time.UTC)
+	s.revisions = append(s.revisions, revision)
+	return int64(revision.ID), nil
+}
+
+// ListIdentityRevisions returns the identity's revisions, newest first.
+func (s *fakeStore) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	var out []domain.IdentityRevision
+	for i := len(s.revisions) - 1; i >= 0 && len(out) < limit; i-- {
+		if s.revisions[i].IdentityID == identityID {
+			out = append(out, s.revisions[i])
+		}
+	}
+	return out, nil
+}
+
+// GetIdentityRevision returns one revision of the identity.
+func (s *fakeStore) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	for _, revision := range s.revisions {
+		if revision.ID == revisionID && revision.IdentityID == identityID {
+			return revision, nil
+		}
+	}
+	return domain.IdentityRevision{}, sql.ErrNoRows
+}
+
+// GetProfilePictureAlt reports every picture as missing.
+func (s *fakeStore) GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error) {
+	return "", sql.ErrNoRows
+}
+
+// TestSaveRecordsBaselineAndChanges verifies the first save records a baseline, later saves
+// record changes, and unchanged saves add nothing.
+func TestSaveRecordsBaselineAndChanges(t *testing.T) {
+	store := newFakeStore(domain.Identity{ID: 1, Handle: "alice", DisplayName: "Alice", PrivateToken: "secret"})
+	svc := NewService(store)
+	ctx := context.Background()
+
+	updated := store.identities[1]
+	updated.Bio = "Hello"
+	if err := svc.Save(ctx, "https://pin.test", 7, updated); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if len(store.revisions) != 2 {
+		t.Fatalf("expected baseline + revision, got %d", len(store.revisions))
+	}
+	if store.revisions[0].Note != noteBaseline || store.revisions[0].ActorID.Valid {
+		t.Fatalf("unexpected baseline revision: %+v", store.revisions[0])
+	}
+	if store.revisions[1].ActorID.Int64 != 7 || !strings.HasPrefix(store.revisions[1].Rev, "sha256:") {
+		t.Fatalf("unexpected revision: %+v", store.revisions[1])
+	}
+	for _, revision := range store.revisions {
+		if strings.Contains(revision.Snapshot, "secret") {
+			t.Fatalf("snapshot leaked private token: %s", revision.Snapshot)
+		}
+	}
+
+	// Saving again without changes must not add revisions.
+	if err := svc.Save(ctx, "https://pin.test", 7, store.identities[1]); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if len(store.revisions) != 2 {
+		t.Fatalf("expected no new revisions, got %d", len(store.revisions))
+	}
+
+	entries, err := svc.List(ctx, 1, DefaultLimit)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 2 || !entries[1].Baseline {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if len(entries[0].Changes) != 1 || entries[0].Changes[0].Field != "bio" || entries[0].Changes[0].After != "Hello" {
+		t.Fatalf("unexpected changes: %+v", entries[0].Changes)
+	}
+}
+
+// TestRestoreAddsRevision verifies a restore writes the old fields back as a new revision and
+// refuses a handle taken since.
+func TestRestoreAddsRevision(t *testing.T) {
+	store := newFakeStore(domain.Identity{ID: 1, Handle: "alice", Bio: "First"})
+	svc := NewService(store)
+	ctx := context.Background()
+
+	updated := store.identities[1]
+	updated.Handle = "alicia"
+	updated.Bio = "Second"
+	if err := svc.Save(ctx, "https://pin.test", 1, updated); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	store.taken["alice"] = true
+	if _, err := svc.Restore(ctx, "https://pin.test", 1, 1, 1); !errors.Is(err, ErrHandleTaken) {
+		t.Fatalf("expected ErrHandleTaken, got %v", err)
+	}
+	delete(store.taken, "alice")
+
+	restored, err := svc.Restore(ctx, "https://pin.test", 1, 1, 1)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.Handle != "alice" || restored.Bio != "First" {
+		t.Fatalf("unexpected restored identity: %+v", restored)
+	}
+	if len(store.revisions) != 3 {
+		t.Fatalf("expected restore revision, got %d", len(store.revisions))
+	}
+	entries, err := svc.List(ctx, 1, DefaultLimit)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if entries[0].RestoredFrom != 1 {
+		t.Fatalf("expected restored-from marker, got %+v", entries[0])
+	}
+}
+
+// TestRestoreKeepsCurrentVerification verifies restoring a revision from before a demotion does
+// not bring back verified domains, links or social profiles.
+func TestRestoreKeepsCurrentVerification(t *testing.T) {
+	store := newFakeStore(domain.Identity{
+		ID:                  1,
+		Handle:              "alice",
+		Bio:                 "First",
+		VerifiedDomainsJSON: `[{"domain":"alice.example","verified_at":"2024-01-01T00:00:00Z"}]`,
+		LinksJSON:           `[{"label":"Blog","url":"https://blog.example","verified":true,"verified_at":"2024-01-01T00:00:00Z"},{"label":"Site","url":"https://site.example","verified":true,"verified_at":"2024-01-01T00:00:00Z"}]`,
+		SocialProfilesJSON:  `[{"label":"Mastodon","url":"https://social.example/@alice","verified":true,"verified_at":"2024-01-01T00:00:00Z"}]`,
+	})
+	svc := NewService(store)
+	ctx := context.Background()
+
+	demoted := store.identities[1]
+	demoted.Bio = "Second"
+	demoted.VerifiedDomainsJSON = ""
+	demoted.LinksJSON = `[{"label":"Site","url":"https://site.example","verified":true,"verified_at":"2024-02-01T00:00:00Z"}]`
+	demoted.SocialProfilesJSON = `[{"label":"Mastodon","url":"https://social.example/@alice","verified":false}]`
+	if err := svc.Save(ctx, "https://pin.test", 1, demoted); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	restored, err := svc.Restore(ctx, "https://pin.test", 1, 1, 1)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.Bio != "First" || restored.VerifiedDomainsJSON != "" {
+		t.Fatalf("expected the bio back without the demoted domain, got %+v", restored)
+	}
+	links := identity.DecodeLinks(restored.LinksJSON)
+	if len(links) != 2 || links[0].Verified || links[0].VerifiedAt != "" || !links[1].Verified || links[1].VerifiedAt != "2024-02-01T00:00:00Z" {
+		t.Fatalf("expected only the still-verified link to keep its verification, got %+v", links)
+	}
+	profiles := identity.DecodeSocialProfiles(restored.SocialProfilesJSON)
+	if len(profiles) != 1 || profiles[0].Verified || profiles[0].VerifiedAt != "" {
+		t.Fatalf("expected the demoted social profile to stay unverified, got %+v", profiles)
+	}
+}
+
+// TestPublicHistoryHidesPrivateChanges verifies private-only edits collapse and private values
+// and revs never reach the public history.
+func TestPublicHistoryHidesPrivateChanges(t *testing.T) {
+	store := newFakeStore(domain.Identity{
+		ID:             1,
+		Handle:         "alice",
+		Bio:            "Hi",
+		VisibilityJSON: `[{"key":"phone","visibility":"private"}]`,
+	})
+	svc := NewService(store)
+	ctx := context.Background()
+
+	updated := store.identities[1]
+	updated.Phone = "+1 555 0100"
+	if err := svc.Save(ctx, "https://pin.test", 1, updated); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	updated.Bio = "Hello"
+	if err := svc.Save(ctx, "https://pin.test", 1, updated); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	entries, err := svc.PublicHistory(ctx, "https://pin.test", 1, DefaultLimit)
+	if err != nil {
+		t.Fatalf("public history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected private-only change to collapse, got %d entries", len(entries))
+	}
+	raw, _ := json.Marshal(entries)
+	if strings.Contains(string(raw), "555") {
+		t.Fatalf("public history leaked a private field: %s", raw)
+	}
+	for _, revision := range store.revisions {
+		if !strings.Contains(revision.Snapshot, "555") {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Rev == revision.Rev {
+				t.Fatalf("public history exposed a private rev")
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// snapshot is the stored form of an identity revision. The private token is never stored.
+type snapshot struct {
+	Handle              string `json:"handle"`
+	Email               string `json:"email,omitempty"`
+	DisplayName         string `json:"display_name,omitempty"`
+	Bio                 string `json:"bio,omitempty"`
+	Organization        string `json:"organization,omitempty"`
+	JobTitle            string `json:"job_title,omitempty"`
+	Birthdate           string `json:"birthdate,omitempty"`
+	Languages           string `json:"languages,omitempty"`
+	Phone               string `json:"phone,omitempty"`
+	Address             string `json:"address,omitempty"`
+	CustomFieldsJSON    string `json:"custom_fields,omitempty"`
+	VisibilityJSON      string `json:"visibility,omitempty"`
+	LinksJSON           string `json:"links,omitempty"`
+	SocialProfilesJSON  string `json:"social_profiles,omitempty"`
+	WalletsJSON         string `json:"wallets,omitempty"`
+	PublicKeysJSON      string `json:"public_keys,omitempty"`
+	Location            string `json:"location,omitempty"`
+	Website             string `json:"website,omitempty"`
+	Pronouns            string `json:"pronouns,omitempty"`
+	VerifiedDomainsJSON string `json:"verified_domains,omitempty"`
+	ATProtoHandle       string `json:"atproto_handle,omitempty"`
+	ATProtoDID          string `json:"atproto_did,omitempty"`
+	Timezone            string `json:"timezone,omitempty"`
+	NostrRelaysJSON     string `json:"nostr_relays,omitempty"`
+	ProfilePictureID    int64  `json:"profile_picture_id,omitempty"`
+	UpdatedAt           string `json:"updated_at,omitempty"`
+}
+
+// encodeSnapshot serializes the restorable identity fields.
+func encodeSnapshot(identity domain.Identity) (string, error) {
+	snap := snapshot{
+		Handle:              identity.Handle,
+		Email:               identity.Email,
+		DisplayName:         identity.DisplayName,
+		Bio:                 identity.Bio,
+		Organization:        identity.Organization,
+		JobTitle:            identity.JobTitle,
+		Birthdate:           identity.Birthdate,
+		Languages:           identity.Languages,
+		Phone:               identity.Phone,
+		Address:             identity.Address,
+		CustomFieldsJSON:    identity.CustomFieldsJSON,
+		VisibilityJSON:      identity.VisibilityJSON,
+		LinksJSON:           identity.LinksJSON,
+		SocialProfilesJSON:  identity.SocialProfilesJSON,
+		WalletsJSON:         identity.WalletsJSON,
+		PublicKeysJSON:      identity.PublicKeysJSON,
+		Location:            identity.Location,
+		Website:             identity.Website,
+		Pronouns:            identity.Pronouns,
+		VerifiedDomainsJSON: identity.VerifiedDomainsJSON,
+		ATProtoHandle:       identity.ATProtoHandle,
+		ATProtoDID:          identity.ATProtoDID,
+		Timezone:            identity.Timezone,
+		NostrRelaysJSON:     identity.NostrRelaysJSON,
+	}
+	if identity.ProfilePictureID.Valid {
+		snap.ProfilePictureID = identity.ProfilePictureID.Int64
+	}
+	if !identity.UpdatedAt.IsZero() {
+		snap.UpdatedAt = identity.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// sameSnapshot reports whether two stored snapshots hold the same fields, ignoring the update
+// time the store stamps on every save.
+func sameSnapshot(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var left, right snapshot
+	if json.Unmarshal([]byte(a), &left) != nil || json.Unmarshal([]byte(b), &right) != nil {
+		return false
+	}
+	left.UpdatedAt, right.UpdatedAt = "", ""
+	return left == right
+}
+
+// decodeSnapshot overlays a stored snapshot onto base, keeping base's IDs and private token.
+func decodeSnapshot(raw string, base domain.Identity) (domain.Identity, error) {
+	var snap snapshot
+	if err := json.Unmarshal([]byte(raw), &snap); err != nil {
+		return domain.Identity{}, err
+	}
+	out := base
+	out.Handle = snap.Handle
+	out.Email = snap.Email
+	out.DisplayName = snap.DisplayName
+	out.Bio = snap.Bio
+	out.Organization = snap.Organization
+	out.JobTitle = snap.JobTitle
+	out.Birthdate = snap.Birthdate
+	out.Languages = snap.Languages
+	out.Phone = snap.Phone
+	out.Address = snap.Address
+	out.CustomFieldsJSON = snap.CustomFieldsJSON
+	out.VisibilityJSON = snap.VisibilityJSON
+	out.LinksJSON = snap.LinksJSON
+	out.SocialProfilesJSON = snap.SocialProfilesJSON
+	out.WalletsJSON = snap.WalletsJSON
+	out.PublicKeysJSON = snap.PublicKeysJSON
+	out.Location = snap.Location
+	out.Website = snap.Website
+	out.Pronouns = snap.Pronouns
+	out.VerifiedDomainsJSON = snap.VerifiedDomainsJSON
+	out.ATProtoHandle = snap.ATProtoHandle
+	out.ATProtoDID = snap.ATProtoDID
+	out.Timezone = snap.Timezone
+	out.NostrRelaysJSON = snap.NostrRelaysJSON
+	out.ProfilePictureID = sql.NullInt64{}
+	if snap.ProfilePictureID > 0 {
+		out.ProfilePictureID = sql.NullInt64{Int64: snap.ProfilePictureID, Valid: true}
+	}
+	out.UpdatedAt = time.Time{}
+	if parsed, err := time.Parse(time.RFC3339, snap.UpdatedAt); err == nil {
+		out.UpdatedAt = parsed
+	}
+	return out, nil
+}
+
+// FieldChange describes one field that differs between two revisions.
+type FieldChange struct {
+	Field  string
+	Label  string
+	Before string
+	After  string
+}
+
+type diffField struct {
+	key   string
+	label string
+	value func(domain.Identity) string
+}
+
+var diffFields = []diffField{
+	{"handle", "Handle", func(i domain.Identity) string { return i.Handle }},
+	{"display_name", "Display name", func(i domain.Identity) string { return i.DisplayName }},
+	{"email", "Email", func(i domain.Identity) string { return i.Email }},
+	{"bio", "Bio", func(i domain.Identity) string { return i.Bio }},
+	{"organization", "Organization", func(i domain.Identity) string { return i.Organization }},
+	{"job_title", "Job title", func(i domain.Identity) string { return i.JobTitle }},
+	{"birthdate", "Birthdate", func(i domain.Identity) string { return i.Birthdate }},
+	{"languages", "Languages", func(i domain.Identity) string { return i.Languages }},
+	{"phone", "Phone", func(i domain.Identity) string { return i.Phone }},
+	{"address", "Address", func(i domain.Identity) string { return i.Address }},
+	{"location", "Location", func(i domain.Identity) string { return i.Location }},
+	{"website", "Website", func(i domain.Identity) string { return i.Website }},
+	{"pronouns", "Pronouns", func(i domain.Identity) string { return i.Pronouns }},
+	{"timezone", "Timezone", func(i domain.Identity) string { return i.Timezone }},
+	{"custom_fields", "Custom fields", func(i domain.Identity) string { return i.CustomFieldsJSON }},
+	{"links", "Links", func(i domain.Identity) string { return i.LinksJSON }},
+	{"social_profiles", "Social profiles", func(i domain.Identity) string { return i.SocialProfilesJSON }},
+	{"wallets", "Wallets", func(i domain.Identity) string { return i.WalletsJSON }},
+	{"public_keys", "Public keys", func(i domain.Identity) string { return i.PublicKeysJSON }},
+	{"nostr_relays", "Nostr relays", func(i domain.Identity) string { return i.NostrRelaysJSON }},
+	{"verified_domains", "Verified domains", func(i domain.Identity) string { return i.VerifiedDomainsJSON }},
+	{"atproto_handle", "ATProto handle", func(i domain.Identity) string { return i.ATProtoHandle }},
+	{"atproto_did", "ATProto DID", func(i domain.Identity) string { return i.ATProtoDID }},
+	{"visibility", "Visibility", func(i domain.Identity) string { return i.VisibilityJSON }},
+	{"profile_picture_id", "Profile picture", func(i domain.Identity) string {
+		if !i.ProfilePictureID.Valid {
+			return ""
+		}
+		return strconv.FormatInt(i.ProfilePictureID.Int64, 10)
+	}},
+}
+
+// Diff returns the fields that changed from before to after.
+func Diff(before, after domain.Identity) []FieldChange {
+	var changes []FieldChange
+	for _, field := range diffFields {
+		oldValue := normalizeEmptyJSON(field.value(before))
+		newValue := normalizeEmptyJSON(field.value(after))
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field.key, Label: field.label, Before: oldValue, After: newValue})
+	}
+	return changes
+}
+
+// normalizeEmptyJSON treats empty JSON containers as empty values for diffing.
+func normalizeEmptyJSON(value string) string {
+	switch value {
+	case "{}", "[]", "null":
+		return ""
+	default:
+		return value
+	}
+}
@@ -0,0 +1,54 @@
+package history
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// revisionSource adapts the history store to export.Source for rev computation.
+type revisionSource struct {
+	store Store
+}
+
+// GetOwnerIdentity is unused when building revisions.
+func (s revisionSource) GetOwnerIdentity(ctx context.Context) (domain.Identity, error) {
+	return domain.Identity{}, errors.New("not supported")
+}
+
+// VisibleIdentity returns the visible identity fields for the requested view.
+func (s revisionSource) VisibleIdentity(user domain.Identity, isPrivate bool) (domain.Identity, map[string]string) {
+	return identity.VisibleIdentity(user, isPrivate)
+}
+
+// ActiveProfilePictureAlt returns the alt text of the revision's selected picture.
+func (s revisionSource) ActiveProfilePictureAlt(ctx context.Context, user domain.Identity) string {
+	if !user.ProfilePictureID.Valid {
+		return ""
+	}
+	alt, err := s.store.GetProfilePictureAlt(ctx, user.ID, user.ProfilePictureID.Int64)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(alt)
+}
+
+// DomainVerifications returns none; re-check times are server state, not part of a revision.
+func (s revisionSource) DomainVerifications(ctx context.Context, user domain.Identity) []domain.DomainVerification {
+	return nil
+}
+
+// BaseURL is unused; revisions are built for an explicit base URL.
+func (s revisionSource) BaseURL(r *http.Request) string {
+	return ""
+}
+
+// PINCSigningKey returns no key; revision envelopes are only hashed.
+func (s revisionSource) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	return nil, nil
+}
@@ -0,0 +1,141 @@
+package history_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"pin/internal/contracts/identities"
+	"pin/internal/contracts/profilepictures"
+	"pin/internal/domain"
+	"pin/internal/features/history"
+	sqlitestore "pin/internal/platform/storage/sqlite"
+)
+
+// sqliteStore is the history store backed by the real SQLite repositories.
+type sqliteStore struct {
+	identities.Repository
+	pictures profilepictures.Repository
+}
+
+// GetProfilePictureAlt returns the stored alt text.
+func (s sqliteStore) GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error) {
+	return s.pictures.GetProfilePictureAlt(ctx, identityID, pictureID)
+}
+
+// newSQLiteStore opens an in-memory database holding one identity and returns its ID.
+func newSQLiteStore(t *testing.T, record domain.Identity) (*sql.DB, sqliteStore, int) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	if err := sqlitestore.InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	repos := sqlitestore.NewRepos(db)
+	ctx := context.Background()
+	userID, err := repos.Users.CreateUser(ctx, "owner", "hash", "secret", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	record.UserID = int(userID)
+	id, err := repos.Identities.CreateIdentity(ctx, record)
+	if err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	return db, sqliteStore{Repository: repos.Identities, pictures: repos.ProfilePictures}, int(id)
+}
+
+// backdate moves the stored update time into the past so the next save stamps a new one.
+func backdate(t *testing.T, db *sql.DB, id int) {
+	t.Helper()
+	if _, err := db.Exec("UPDATE identity SET updated_at = '2024-01-01T00:00:00Z' WHERE id = ?", id); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+}
+
+// TestSQLiteSaveSkipsNoOpRevisions verifies saves that only change the stored update time add
+// no revisions.
+func TestSQLiteSaveSkipsNoOpRevisions(t *testing.T) {
+	db, store, id := newSQLiteStore(t, domain.Identity{Handle: "alice", Bio: "Hi"})
+	svc := history.NewService(store)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		record, err := store.GetIdentityByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get identity: %v", err)
+		}
+		if err := svc.Save(ctx, "https://pin.test", 1, record); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		backdate(t, db, id)
+	}
+	revisions, err := store.ListIdentityRevisions(ctx, id, history.DefaultLimit)
+	if err != nil {
+		t.Fatalf("list revisions: %v", err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("expected only the baseline revision, got %d", len(revisions))
+	}
+}
+
+// TestSQLitePublicHistoryHidesPrivateChanges verifies private-only edits do not show up as
+// public entries, and fields made private later are hidden from older entries.
+func TestSQLitePublicHistoryHidesPrivateChanges(t *testing.T) {
+	db, store, id := newSQLiteStore(t, domain.Identity{
+		Handle:         "alice",
+		Bio:            "Hi",
+		VisibilityJSON: `[{"key":"phone","visibility":"private"}]`,
+	})
+	svc := history.NewService(store)
+	ctx := context.Background()
+
+	save := func(edit func(*domain.Identity)) {
+		t.Helper()
+		record, err := store.GetIdentityByID(ctx, id)
+		if err != nil {
+			t.Fatalf("get identity: %v", err)
+		}
+		edit(&record)
+		if err := svc.Save(ctx, "https://pin.test", 1, record); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		backdate(t, db, id)
+	}
+	save(func(record *domain.Identity) { record.Phone = "+1 555 0100" })
+	save(func(record *domain.Identity) { record.Phone = "+1 555 0199" })
+	save(func(record *domain.Identity) { record.Bio = "Hello" })
+
+	entries, err := svc.PublicHistory(ctx, "https://pin.test", id, history.DefaultLimit)
+	if err != nil {
+		t.Fatalf("public history: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected private-only changes to collapse, got %d entries", len(entries))
+	}
+	raw, _ := json.Marshal(entries)
+	if strings.Contains(string(raw), "555") {
+		t.Fatalf("public history leaked a private field: %s", raw)
+	}
+
+	save(func(record *domain.Identity) {
+		record.VisibilityJSON = `[{"key":"phone","visibility":"private"},{"key":"bio","visibility":"private"}]`
+	})
+	entries, err = svc.PublicHistory(ctx, "https://pin.test", id, history.DefaultLimit)
+	if err != nil {
+		t.Fatalf("public history: %v", err)
+	}
+	raw, _ = json.Marshal(entries)
+	if len(entries) != 1 || strings.Contains(string(raw), "Hello") || strings.Contains(string(raw), `"Hi"`) {
+		t.Fatalf("expected the now-private bio to be hidden from every entry, got %d entries: %s", len(entries), raw)
+	}
+}
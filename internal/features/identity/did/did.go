@@ -0,0 +1,174 @@
+// Package did builds did:web documents for identities.
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// Contexts of a did:web document; Multikey and JsonWebKey methods each need their own.
+var contexts = []string{
+	"https://www.w3.org/ns/did/v1",
+	"https://w3id.org/security/multikey/v1",
+	"https://w3id.org/security/suites/jws-2020/v1",
+}
+
+// WebDID returns the did:web for an identity under baseURL. The owner is the bare host;
+// other identities get a path segment resolving to /{handle}/did.json.
+func WebDID(baseURL, handle string, owner bool) string {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	// did:web percent-encodes the port separator.
+	id := "did:web:" + strings.ReplaceAll(strings.ToLower(base.Host), ":", "%3A")
+	for _, segment := range strings.Split(strings.Trim(base.Path, "/"), "/") {
+		if segment != "" {
+			id += ":" + url.PathEscape(segment)
+		}
+	}
+	if !owner {
+		id += ":" + url.PathEscape(handle)
+	}
+	return id
+}
+
+// Document builds the DID document for the public view of user.
+func Document(baseURL string, user domain.Identity, owner bool) map[string]interface{} {
+	publicUser, _ := identity.VisibleIdentity(user, false)
+	id := WebDID(baseURL, publicUser.Handle, owner)
+	profileURL := baseURL + "/" + url.PathEscape(publicUser.Handle)
+
+	var methods []map[string]interface{}
+	var signing, agreement []string
+	counts := map[string]int{}
+	for _, key := range identity.ParsePublicKeys(identity.DecodeStringMap(publicUser.PublicKeysJSON)) {
+		counts[key.Label]++
+		methodID := id + "#" + key.Label
+		if counts[key.Label] > 1 {
+			methodID += "-" + strconv.Itoa(counts[key.Label])
+		}
+		method := verificationMethod(methodID, id, key)
+		if method == nil {
+			continue
+		}
+		methods = append(methods, method)
+		if key.Kind == identity.KeyX25519 {
+			agreement = append(agreement, methodID)
+		} else {
+			signing = append(signing, methodID)
+		}
+	}
+
+	document := map[string]interface{}{
+		"@context": contexts,
+		"id":       id,
+		"service": []map[string]string{
+			{
+				"id":              id + "#pinc",
+				"type":            "PincIdentity",
+				"serviceEndpoint": profileURL + ".json",
+			},
+			{
+				"id":              id + "#activitypub",
+				"type":            "ActivityPubActor",
+				"serviceEndpoint": baseURL + "/users/" + url.PathEscape(publicUser.Handle),
+			},
+		},
+	}
+	if aka := alsoKnownAs(publicUser, profileURL); len(aka) > 0 {
+		document["alsoKnownAs"] = aka
+	}
+	if len(methods) > 0 {
+		document["verificationMethod"] = methods
+	}
+	if len(signing) > 0 {
+		document["authentication"] = signing
+		document["assertionMethod"] = signing
+	}
+	if len(agreement) > 0 {
+		document["keyAgreement"] = agreement
+	}
+	return document
+}
+
+// Write sends a DID document; resolvers fetch it cross-origin.
+func Write(w http.ResponseWriter, document map[string]interface{}) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/did+json")
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+// alsoKnownAs lists the profile page, verified social profiles and verified domains.
+func alsoKnownAs(user domain.Identity, profileURL string) []string {
+	out := []string{profileURL}
+	for _, profile := range identity.DecodeSocialProfiles(user.SocialProfilesJSON) {
+		if profile.Verified && strings.TrimSpace(profile.URL) != "" {
+			out = append(out, strings.TrimSpace(profile.URL))
+		}
+	}
+	for _, verified := range identity.DecodeStringSlice(user.VerifiedDomainsJSON) {
+		if verified = strings.TrimSpace(verified); verified != "" {
+			out = append(out, "https://"+verified)
+		}
+	}
+	return out
+}
+
+// verificationMethod expresses key as a Multikey, or a JsonWebKey for RSA and ECDSA.
+func verificationMethod(methodID, controller string, key identity.ParsedKey) map[string]interface{} {
+	method := map[string]interface{}{
+		"id":         methodID,
+		"controller": controller,
+	}
+	if multibase, ok := identity.Multikey(key.Public); ok {
+		method["type"] = "Multikey"
+		method["publicKeyMultibase"] = multibase
+		return method
+	}
+	jwk := publicJWK(key)
+	if jwk == nil {
+		return nil
+	}
+	method["type"] = "JsonWebKey2020"
+	method["publicKeyJwk"] = jwk
+	return method
+}
+
+// publicJWK encodes an RSA or ECDSA public key as a JWK.
+func publicJWK(key identity.ParsedKey) map[string]string {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   encode(pub.N.Bytes()),
+			"e":   encode(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return nil
+		}
+		// Uncompressed point: 0x04 || X || Y.
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		return map[string]string{
+			"kty": "EC",
+			"crv": pub.Curve.Params().Name,
+			"x":   encode(point[:size]),
+			"y":   encode(point[size:]),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package did
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+)
+
+// TestWebDID verifies host, port and path encoding.
+func TestWebDID(t *testing.T) {
+	cases := []struct {
+		base   string
+		owner  bool
+		expect string
+	}{
+		{"https://pin.example", true, "did:web:pin.example"},
+		{"https://pin.example", false, "did:web:pin.example:alice"},
+		{"http://localhost:8080", false, "did:web:localhost%3A8080:alice"},
+		{"https://example.com/pin", true, "did:web:example.com:pin"},
+	}
+	for _, tc := range cases {
+		if got := WebDID(tc.base, "alice", tc.owner); got != tc.expect {
+			t.Fatalf("WebDID(%q, %v) = %q, want %q", tc.base, tc.owner, got, tc.expect)
+		}
+	}
+}
+
+// TestDocumentKeysAndAliases verifies verification methods, services and alsoKnownAs.
+func TestDocumentKeysAndAliases(t *testing.T) {
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	edSSH, _ := ssh.NewPublicKey(edPub)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa: %v", err)
+	}
+	rsaSSH, _ := ssh.NewPublicKey(&rsaKey.PublicKey)
+	user := domain.Identity{
+		Handle: "alice",
+		PublicKeysJSON: identity.EncodeStringMap(map[string]string{
+			"ssh": string(ssh.MarshalAuthorizedKey(edSSH)) + string(ssh.MarshalAuthorizedKey(rsaSSH)),
+			"age": "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+		}),
+		SocialProfilesJSON: identity.EncodeSocialProfiles([]domain.SocialProfile{
+			{Label: "GitHub", URL: "https://github.com/alice", Verified: true},
+			{Label: "Blog", URL: "https://blog.example"},
+		}),
+		VerifiedDomainsJSON: `["alice.example"]`,
+	}
+	doc := Document("https://pin.example", user, false)
+	if doc["id"] != "did:web:pin.example:alice" {
+		t.Fatalf("unexpected id %v", doc["id"])
+	}
+	methods := doc["verificationMethod"].([]map[string]interface{})
+	if len(methods) != 3 {
+		t.Fatalf("expected three verification methods, got %+v", methods)
+	}
+	if methods[0]["id"] != "did:web:pin.example:alice#age" || methods[1]["type"] != "Multikey" || methods[2]["id"] != "did:web:pin.example:alice#ssh-2" || methods[2]["type"] != "JsonWebKey2020" {
+		t.Fatalf("unexpected methods %+v", methods)
+	}
+	if agreement := doc["keyAgreement"].([]string); len(agreement) != 1 || agreement[0] != "did:web:pin.example:alice#age" {
+		t.Fatalf("unexpected keyAgreement %v", agreement)
+	}
+	if signing := doc["assertionMethod"].([]string); len(signing) != 2 {
+		t.Fatalf("unexpected assertionMethod %v", signing)
+	}
+	aka := doc["alsoKnownAs"].([]string)
+	if len(aka) != 3 || aka[0] != "https://pin.example/alice" || aka[1] != "https://github.com/alice" || aka[2] != "https://alice.example" {
+		t.Fatalf("unexpected alsoKnownAs %v", aka)
+	}
+	services := doc["service"].([]map[string]string)
+	if services[0]["serviceEndpoint"] != "https://pin.example/alice.json" || services[1]["serviceEndpoint"] != "https://pin.example/users/alice" {
+		t.Fatalf("unexpected services %v", services)
+	}
+}
@@ -2,6 +2,7 @@ package export
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/xml"
 	"net/http"
 	"net/url"
@@ -53,7 +54,9 @@ type Source interface {
 	GetOwnerIdentity(ctx context.Context) (domain.Identity, error)
 	VisibleIdentity(user domain.Identity, isPrivate bool) (domain.Identity, map[string]string)
 	ActiveProfilePictureAlt(ctx context.Context, user domain.Identity) string
+	DomainVerifications(ctx context.Context, user domain.Identity) []domain.DomainVerification
 	BaseURL(r *http.Request) string
+	PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error)
 }
 
 type Handler struct {
@@ -139,210 +142,61 @@ func (h Handler) IdentityJSON(w http.ResponseWriter, r *http.Request) {
 
 // IdentityXML handles HTTP requests for XML.
 func (h Handler) IdentityXML(w http.ResponseWriter, r *http.Request) {
-	user, err := h.source.GetOwnerIdentity(r.Context())
-	if err != nil {
-		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
-		return
-	}
-	publicUser, customFields := h.source.VisibleIdentity(user, false)
-	identityExport, err := h.Build(r.Context(), r, publicUser, customFields, h.source.BaseURL(r)+"/"+url.PathEscape(user.Handle))
-	if err != nil {
-		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
-		return
-	}
-	identity.WriteIdentityCacheHeaders(w)
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-	enc := xml.NewEncoder(w)
-	enc.Indent("", "  ")
-	if _, err := w.Write([]byte(xml.Header)); err != nil {
-		return
-	}
-	_ = enc.Encode(identityExport)
+	h.serveOwnerIdentity(w, r, "xml")
 }
 
 // IdentityTXT handles HTTP requests for txt.
 func (h Handler) IdentityTXT(w http.ResponseWriter, r *http.Request) {
-	user, err := h.source.GetOwnerIdentity(r.Context())
-	if err != nil {
-		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
-		return
-	}
-	publicUser, customFields := h.source.VisibleIdentity(user, false)
-	identityExport, err := h.Build(r.Context(), r, publicUser, customFields, h.source.BaseURL(r)+"/"+url.PathEscape(user.Handle))
-	if err != nil {
-		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
-		return
-	}
-	identity.WriteIdentityCacheHeaders(w)
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	lines := []string{
-		"handle: " + identityExport.Handle,
-		"display_name: " + identityExport.DisplayName,
-	}
-	if identityExport.Email != "" {
-		lines = append(lines, "email: "+identityExport.Email)
-	}
-	if identityExport.Bio != "" {
-		lines = append(lines, "bio: "+identityExport.Bio)
-	}
-	if identityExport.Organization != "" {
-		lines = append(lines, "organization: "+identityExport.Organization)
-	}
-	if identityExport.JobTitle != "" {
-		lines = append(lines, "job_title: "+identityExport.JobTitle)
-	}
-	if identityExport.Birthdate != "" {
-		lines = append(lines, "birthdate: "+identityExport.Birthdate)
-	}
-	if identityExport.Languages != "" {
-		lines = append(lines, "languages: "+identityExport.Languages)
-	}
-	if identityExport.Phone != "" {
-		lines = append(lines, "phone: "+identityExport.Phone)
-	}
-	if len(identityExport.CustomFields) > 0 {
-		for key, value := range identityExport.CustomFields {
-			if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
-				continue
-			}
-			lines = append(lines, "custom."+strings.ToLower(key)+": "+value)
-		}
-	}
-	if identityExport.Location != "" {
-		lines = append(lines, "location: "+identityExport.Location)
-	}
-	if identityExport.Website != "" {
-		lines = append(lines, "website: "+identityExport.Website)
-	}
-	if identityExport.Pronouns != "" {
-		lines = append(lines, "pronouns: "+identityExport.Pronouns)
-	}
-	if identityExport.Timezone != "" {
-		lines = append(lines, "timezone: "+identityExport.Timezone)
-	}
-	if len(identityExport.Wallets) > 0 {
-		for key, value := range identityExport.Wallets {
-			if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
-				continue
-			}
-			lines = append(lines, "wallet."+strings.ToLower(key)+": "+value)
-		}
-	}
-	if len(identityExport.PublicKeys) > 0 {
-		for key, value := range identityExport.PublicKeys {
-			if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
-				continue
-			}
-			lines = append(lines, "key."+strings.ToLower(key)+": "+value)
-		}
-	}
-	if len(identityExport.Domains) > 0 {
-		for _, domain := range identityExport.Domains {
-			if strings.TrimSpace(domain) == "" {
-				continue
-			}
-			lines = append(lines, "verified_domain: "+domain)
-		}
-	}
-	if identityExport.ATProtoHandle != "" {
-		lines = append(lines, "atproto_handle: "+identityExport.ATProtoHandle)
-	}
-	if identityExport.ATProtoDID != "" {
-		lines = append(lines, "atproto_did: "+identityExport.ATProtoDID)
-	}
-	if identityExport.ProfileURL != "" {
-		lines = append(lines, "profile_url: "+identityExport.ProfileURL)
-	}
-	if identityExport.ProfileImage != "" {
-		lines = append(lines, "profile_image: "+identityExport.ProfileImage)
-	}
-	if identityExport.ImageAltText != "" {
-		lines = append(lines, "profile_image_alt: "+identityExport.ImageAltText)
-	}
-	if identityExport.UpdatedAt != "" {
-		lines = append(lines, "updated_at: "+identityExport.UpdatedAt)
-	}
-	_, _ = w.Write([]byte(strings.Join(lines, "\n")))
+	h.serveOwnerIdentity(w, r, "txt")
 }
 
 // IdentityVCF handles HTTP requests for VCF.
 func (h Handler) IdentityVCF(w http.ResponseWriter, r *http.Request) {
+	h.serveOwnerIdentity(w, r, "vcf")
+}
+
+// serveOwnerIdentity renders the owner's public identity in an alternate export format.
+func (h Handler) serveOwnerIdentity(w http.ResponseWriter, r *http.Request, ext string) {
 	user, err := h.source.GetOwnerIdentity(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
 		return
 	}
 	publicUser, customFields := h.source.VisibleIdentity(user, false)
-	identityExport, err := h.Build(r.Context(), r, publicUser, customFields, h.source.BaseURL(r)+"/"+url.PathEscape(user.Handle))
-	if err != nil {
+	profileURL := h.source.BaseURL(r) + "/" + url.PathEscape(user.Handle)
+	if err := h.ServeIdentity(w, r, publicUser, customFields, profileURL, ext, false); err != nil {
 		http.Error(w, "Failed to load identity", http.StatusInternalServerError)
 		return
 	}
-	identity.WriteIdentityCacheHeaders(w)
-	w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
-	lines := []string{
-		"BEGIN:VCARD",
-		"VERSION:4.0",
-		"N:;" + identity.EscapeVCard(identityExport.DisplayName) + ";;;",
-		"FN:" + identity.EscapeVCard(identityExport.DisplayName),
-	}
-	if identityExport.Email != "" {
-		lines = append(lines, "EMAIL;TYPE=work:"+identity.EscapeVCard(identityExport.Email))
-	}
-	if identityExport.Phone != "" {
-		lines = append(lines, "TEL;TYPE=cell:"+identity.EscapeVCard(identityExport.Phone))
-	}
-	if identityExport.Organization != "" {
-		lines = append(lines, "ORG:"+identity.EscapeVCard(identityExport.Organization))
-	}
-	if identityExport.JobTitle != "" {
-		lines = append(lines, "TITLE:"+identity.EscapeVCard(identityExport.JobTitle))
-	}
-	if identityExport.Website != "" {
-		lines = append(lines, "URL:"+identity.EscapeVCard(identityExport.Website))
-	}
-	if identityExport.ProfileURL != "" && identityExport.Website == "" {
-		lines = append(lines, "URL:"+identity.EscapeVCard(identityExport.ProfileURL))
-	}
-	if identityExport.Location != "" {
-		lines = append(lines, "ADR;TYPE=home:;;"+identity.EscapeVCard(identityExport.Location)+";;;;")
-	}
-	if identityExport.Address != "" && identityExport.Location == "" {
-		lines = append(lines, "ADR;TYPE=home:;;"+identity.EscapeVCard(identityExport.Address)+";;;;")
-	}
-	if identityExport.ProfileImage != "" {
-		lines = append(lines, "PHOTO;MEDIATYPE=image/webp:"+identity.EscapeVCard(identityExport.ProfileImage))
-	}
-	if identityExport.Bio != "" {
-		lines = append(lines, "NOTE:"+identity.EscapeVCard(identityExport.Bio))
-	}
-	if identityExport.Languages != "" {
-		lines = append(lines, "LANG:"+identity.EscapeVCard(identityExport.Languages))
-	}
-	if len(identityExport.CustomFields) > 0 {
-		for key, value := range identityExport.CustomFields {
-			if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
-				continue
-			}
-			lines = append(lines, "X-"+identity.SanitizeVCardKey(key)+":"+identity.EscapeVCard(value))
-		}
-	}
-	lines = append(lines, "END:VCARD")
-	_, _ = w.Write([]byte(strings.Join(lines, "\r\n")))
 }
 
 // ServeIdentity renders an identity export for a given user and extension.
 func (h Handler) ServeIdentity(w http.ResponseWriter, r *http.Request, user domain.Identity, customFields map[string]string, profileURL string, ext string, isPrivate bool) error {
+	ext = strings.ToLower(ext)
+	switch ext {
+	case "xml", "txt", "vcf":
+	default:
+		http.NotFound(w, r)
+		return nil
+	}
 	identityExport, err := h.Build(r.Context(), r, user, customFields, profileURL)
 	if err != nil {
 		return err
 	}
+	view := "public"
 	if isPrivate {
+		view = "private"
 		identity.WritePrivateIdentityCacheHeaders(w)
 	} else {
 		identity.WriteIdentityCacheHeaders(w)
 	}
-	switch strings.ToLower(ext) {
+	// Validators share the PINC rev so every format changes in lockstep with the JSON view;
+	// the rev does not cover the signature, so skip signing.
+	rev := h.buildUnsignedPINC(r.Context(), h.source.BaseURL(r), user, customFields, view, profileURL).Meta.Rev
+	if h.notModified(w, r, rev, user.UpdatedAt, ext) {
+		return nil
+	}
+	switch ext {
 	case "xml":
 		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 		enc := xml.NewEncoder(w)
@@ -499,3 +353,14 @@ func (h Handler) ServeIdentity(w http.ResponseWriter, r *http.Request, user doma
 		return nil
 	}
 }
+
+// notModified writes export validators and answers 304 when the client copy is current.
+func (h Handler) notModified(w http.ResponseWriter, r *http.Request, rev string, updatedAt time.Time, ext string) bool {
+	etag := identity.IdentityETag(rev, ext)
+	identity.WriteIdentityValidators(w, etag, updatedAt)
+	if identity.IdentityNotModified(r, etag, updatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
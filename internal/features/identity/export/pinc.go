@@ -16,12 +16,13 @@ import (
 )
 
 type pincMeta struct {
-	Version string `json:"version"`
-	BaseURL string `json:"base_url"`
-	View    string `json:"view"`
-	Subject string `json:"subject"`
-	Rev     string `json:"rev"`
-	Self    string `json:"self,omitempty"`
+	Version   string `json:"version"`
+	BaseURL   string `json:"base_url"`
+	View      string `json:"view"`
+	Subject   string `json:"subject"`
+	Rev       string `json:"rev"`
+	Self      string `json:"self,omitempty"`
+	Signature string `json:"signature,omitempty"`
 }
 
 type pincIdentity struct {
@@ -48,7 +49,9 @@ type pincIdentity struct {
 	Social          []domain.SocialProfile `json:"social,omitempty"`
 	Wallets         map[string]string      `json:"wallets,omitempty"`
 	PublicKeys      map[string]string      `json:"public_keys,omitempty"`
+	KeyFingerprints []pincKeyFingerprint   `json:"key_fingerprints,omitempty"`
 	VerifiedDomains []string               `json:"verified_domains,omitempty"`
+	DomainChecks    []pincDomainCheck      `json:"domain_checks,omitempty"`
 	ATProtoHandle   string                 `json:"atproto_handle,omitempty"`
 	ATProtoDID      string                 `json:"atproto_did,omitempty"`
 }
@@ -58,6 +61,16 @@ type pincEnvelope struct {
 	Identity pincIdentity `json:"identity"`
 }
 
+// pincKeyFingerprint lists a public key's fingerprint next to the raw key map.
+type pincKeyFingerprint = identity.KeyFingerprint
+
+// pincDomainCheck reports when a verified domain was verified and last re-checked.
+type pincDomainCheck struct {
+	Domain      string `json:"domain"`
+	VerifiedAt  string `json:"verified_at,omitempty"`
+	LastChecked string `json:"last_checked,omitempty"`
+}
+
 type pincPair struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -87,14 +100,37 @@ type pincIdentityRev struct {
 	Social          []domain.SocialProfile `json:"social,omitempty"`
 	Wallets         []pincPair             `json:"wallets,omitempty"`
 	PublicKeys      []pincPair             `json:"public_keys,omitempty"`
+	KeyFingerprints []pincKeyFingerprint   `json:"key_fingerprints,omitempty"`
 	VerifiedDomains []string               `json:"verified_domains,omitempty"`
+	DomainChecks    []pincDomainCheck      `json:"domain_checks,omitempty"`
 	ATProtoHandle   string                 `json:"atproto_handle,omitempty"`
 	ATProtoDID      string                 `json:"atproto_did,omitempty"`
 }
 
 // BuildPINC builds a PINC envelope with identity fields and metadata.
 func (h Handler) BuildPINC(ctx context.Context, r *http.Request, user domain.Identity, customFields map[string]string, view string, selfURL string) (pincEnvelope, error) {
-	baseURL := h.source.BaseURL(r)
+	return h.BuildPINCForBase(ctx, h.source.BaseURL(r), user, customFields, view, selfURL)
+}
+
+// BuildPINCForBase builds a PINC envelope as served from an explicit base URL.
+func (h Handler) BuildPINCForBase(ctx context.Context, baseURL string, user domain.Identity, customFields map[string]string, view string, selfURL string) (pincEnvelope, error) {
+	envelope := h.buildUnsignedPINC(ctx, baseURL, user, customFields, view, selfURL)
+	key, err := h.source.PINCSigningKey(ctx)
+	if err != nil {
+		return pincEnvelope{}, err
+	}
+	if key != nil {
+		signature, err := SignPINC(envelope, key)
+		if err != nil {
+			return pincEnvelope{}, err
+		}
+		envelope.Meta.Signature = signature
+	}
+	return envelope, nil
+}
+
+// buildUnsignedPINC builds a PINC envelope without its signature, which is all the rev needs.
+func (h Handler) buildUnsignedPINC(ctx context.Context, baseURL string, user domain.Identity, customFields map[string]string, view string, selfURL string) pincEnvelope {
 	handle := strings.TrimSpace(user.Handle)
 	profileURL := baseURL + "/" + url.PathEscape(handle)
 	profileImageURL := baseURL + "/" + url.PathEscape(handle) + "/profile-picture"
@@ -160,7 +196,9 @@ func (h Handler) BuildPINC(ctx context.Context, r *http.Request, user domain.Ide
 		Social:          socialProfiles,
 		Wallets:         wallets,
 		PublicKeys:      publicKeys,
+		KeyFingerprints: identity.PublicKeyFingerprints(publicKeys),
 		VerifiedDomains: verifiedDomains,
+		DomainChecks:    domainChecks(verifiedDomains, h.source.DomainVerifications(ctx, user)),
 		ATProtoHandle:   strings.TrimSpace(user.ATProtoHandle),
 		ATProtoDID:      strings.TrimSpace(user.ATProtoDID),
 	}
@@ -179,7 +217,30 @@ func (h Handler) BuildPINC(ctx context.Context, r *http.Request, user domain.Ide
 	return pincEnvelope{
 		Meta:     meta,
 		Identity: identityPayload,
-	}, nil
+	}
+}
+
+// domainChecks lists verification times for the exported verified domains, in their order.
+func domainChecks(verifiedDomains []string, rows []domain.DomainVerification) []pincDomainCheck {
+	byDomain := map[string]domain.DomainVerification{}
+	for _, row := range rows {
+		if row.VerifiedAt.Valid {
+			byDomain[strings.ToLower(row.Domain)] = row
+		}
+	}
+	var out []pincDomainCheck
+	for _, verified := range verifiedDomains {
+		row, ok := byDomain[strings.ToLower(strings.TrimSpace(verified))]
+		if !ok {
+			continue
+		}
+		check := pincDomainCheck{Domain: row.Domain, VerifiedAt: row.VerifiedAt.Time.UTC().Format(time.RFC3339)}
+		if row.LastCheckedAt.Valid {
+			check.LastChecked = row.LastCheckedAt.Time.UTC().Format(time.RFC3339)
+		}
+		out = append(out, check)
+	}
+	return out
 }
 
 // profileImageFromSelf converts a self URL to its profile-picture URL.
@@ -209,6 +270,9 @@ func (h Handler) ServePINCJSON(w http.ResponseWriter, r *http.Request, user doma
 	} else {
 		identity.WriteIdentityCacheHeaders(w)
 	}
+	if h.notModified(w, r, payload.Meta.Rev, user.UpdatedAt, "json") {
+		return nil
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(payload)
 }
@@ -240,7 +304,9 @@ func computePINCRev(identityPayload pincIdentity) string {
 		Social:          identityPayload.Social,
 		Wallets:         sortedPairs(identityPayload.Wallets),
 		PublicKeys:      sortedPairs(identityPayload.PublicKeys),
+		KeyFingerprints: identityPayload.KeyFingerprints,
 		VerifiedDomains: identityPayload.VerifiedDomains,
+		DomainChecks:    identityPayload.DomainChecks,
 		ATProtoHandle:   identityPayload.ATProtoHandle,
 		ATProtoDID:      identityPayload.ATProtoDID,
 	}
@@ -2,6 +2,9 @@ package export
 
 import (
 	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,6 +17,9 @@ import (
 type pincSource struct {
 	baseURL string
 	alt     string
+	key     ed25519.PrivateKey
+	keyErr  error
+	domains []domain.DomainVerification
 }
 
 // GetOwnerIdentity returns the owner identity.
@@ -31,11 +37,21 @@ func (p pincSource) ActiveProfilePictureAlt(ctx context.Context, user domain.Ide
 	return p.alt
 }
 
+// DomainVerifications returns the configured test rows.
+func (p pincSource) DomainVerifications(ctx context.Context, user domain.Identity) []domain.DomainVerification {
+	return p.domains
+}
+
 // BaseURL returns the test base URL.
 func (p pincSource) BaseURL(r *http.Request) string {
 	return p.baseURL
 }
 
+// PINCSigningKey returns the configured test key, if any, or the configured error.
+func (p pincSource) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	return p.key, p.keyErr
+}
+
 // TestBuildPINCUsesSelfProfileImageAndMeta verifies build PINC uses self profile image and meta behavior.
 func TestBuildPINCUsesSelfProfileImageAndMeta(t *testing.T) {
 	source := pincSource{baseURL: "https://pin.example", alt: "Portrait"}
@@ -95,6 +111,30 @@ func TestBuildPINCUsesSelfProfileImageAndMeta(t *testing.T) {
 	}
 }
 
+// TestBuildPINCDomainChecks verifies verified domains carry their verification and re-check times.
+func TestBuildPINCDomainChecks(t *testing.T) {
+	verifiedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	checkedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	handler := NewHandler(pincSource{baseURL: "https://pin.example", domains: []domain.DomainVerification{
+		{Domain: "alice.example", VerifiedAt: sql.NullTime{Time: verifiedAt, Valid: true}, LastCheckedAt: sql.NullTime{Time: checkedAt, Valid: true}},
+		{Domain: "hidden.example", VerifiedAt: sql.NullTime{Time: verifiedAt, Valid: true}},
+		{Domain: "pending.example"},
+	}})
+	user := domain.Identity{ID: 1, Handle: "alice", VerifiedDomainsJSON: `["alice.example","pending.example"]`}
+
+	env, err := handler.BuildPINCForBase(context.Background(), "https://pin.example", user, nil, "public", "")
+	if err != nil {
+		t.Fatalf("build pinc: %v", err)
+	}
+	checks := env.Identity.DomainChecks
+	if len(checks) != 1 || checks[0].Domain != "alice.example" {
+		t.Fatalf("expected only the exported verified domain, got %+v", checks)
+	}
+	if checks[0].VerifiedAt != "2025-01-01T00:00:00Z" || checks[0].LastChecked != "2025-02-01T00:00:00Z" {
+		t.Fatalf("unexpected domain check times: %+v", checks[0])
+	}
+}
+
 // TestComputePINCRevStableForMaps verifies compute PINC rev stable for maps behavior.
 func TestComputePINCRevStableForMaps(t *testing.T) {
 	payload := pincIdentity{
@@ -126,3 +166,103 @@ func TestComputePINCRevStableForMaps(t *testing.T) {
 		t.Fatalf("expected stable rev, got %q and %q", rev1, rev2)
 	}
 }
+
+// TestComputePINCRevCoversDomainRechecks verifies a re-check changes the rev, since the served
+// body carries last_checked and a strong ETag must change with the bytes.
+func TestComputePINCRevCoversDomainRechecks(t *testing.T) {
+	payload := pincIdentity{
+		Handle:       "alice",
+		UpdatedAt:    "2025-01-01T00:00:00Z",
+		DomainChecks: []pincDomainCheck{{Domain: "alice.example", VerifiedAt: "2025-01-01T00:00:00Z", LastChecked: "2025-02-01T00:00:00Z"}},
+	}
+	rev := computePINCRev(payload)
+
+	payload.DomainChecks = []pincDomainCheck{{Domain: "alice.example", VerifiedAt: "2025-01-01T00:00:00Z", LastChecked: "2025-03-01T00:00:00Z"}}
+	if got := computePINCRev(payload); got == rev {
+		t.Fatalf("expected a re-check to change the rev")
+	}
+}
+
+// TestServePINCJSONHonorsIfNoneMatch verifies serve PINC JSON honors if none match behavior.
+func TestServePINCJSONHonorsIfNoneMatch(t *testing.T) {
+	handler := NewHandler(pincSource{baseURL: "https://pin.example"})
+	user := domain.Identity{ID: 1, Handle: "alice", UpdatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	req := httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	rec := httptest.NewRecorder()
+	if err := handler.ServePINCJSON(rec, req, user, nil, "public", ""); err != nil {
+		t.Fatalf("serve pinc: %v", err)
+	}
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with ETag, got %d %q", rec.Code, etag)
+	}
+	if rec.Header().Get("Last-Modified") != "Wed, 01 Jan 2025 00:00:00 GMT" {
+		t.Fatalf("unexpected Last-Modified: %q", rec.Header().Get("Last-Modified"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	if err := handler.ServePINCJSON(rec, req, user, nil, "public", ""); err != nil {
+		t.Fatalf("serve pinc: %v", err)
+	}
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("expected empty 304, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	user.JobTitle = "Engineer"
+	rec = httptest.NewRecorder()
+	if err := handler.ServePINCJSON(rec, req, user, nil, "public", ""); err != nil {
+		t.Fatalf("serve pinc: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 after change, got %d", rec.Code)
+	}
+}
+
+// TestServeIdentityFormatsUseDistinctETags verifies serve identity formats use distinct e tags behavior.
+func TestServeIdentityFormatsUseDistinctETags(t *testing.T) {
+	handler := NewHandler(pincSource{baseURL: "https://pin.example"})
+	user := domain.Identity{ID: 1, Handle: "alice", UpdatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	seen := map[string]string{}
+	for _, ext := range []string{"xml", "txt", "vcf"} {
+		req := httptest.NewRequest(http.MethodGet, "/alice."+ext, nil)
+		rec := httptest.NewRecorder()
+		if err := handler.ServeIdentity(rec, req, user, nil, "https://pin.example/alice", ext, false); err != nil {
+			t.Fatalf("serve %s: %v", ext, err)
+		}
+		etag := rec.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("expected ETag for %s", ext)
+		}
+		if prev, ok := seen[etag]; ok {
+			t.Fatalf("expected distinct ETags, %s and %s share %q", prev, ext, etag)
+		}
+		seen[etag] = ext
+
+		req.Header.Set("If-Modified-Since", rec.Header().Get("Last-Modified"))
+		rec = httptest.NewRecorder()
+		if err := handler.ServeIdentity(rec, req, user, nil, "https://pin.example/alice", ext, false); err != nil {
+			t.Fatalf("serve %s: %v", ext, err)
+		}
+		if rec.Code != http.StatusNotModified {
+			t.Fatalf("expected 304 for %s, got %d", ext, rec.Code)
+		}
+	}
+}
+
+// TestServeIdentitySkipsSigning verifies alternate formats compute their validators without
+// loading the signing key.
+func TestServeIdentitySkipsSigning(t *testing.T) {
+	handler := NewHandler(pincSource{baseURL: "https://pin.example", keyErr: errors.New("no key")})
+	user := domain.Identity{ID: 1, Handle: "alice", UpdatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	req := httptest.NewRequest(http.MethodGet, "/alice.xml", nil)
+	rec := httptest.NewRecorder()
+	if err := handler.ServeIdentity(rec, req, user, nil, "https://pin.example/alice", "xml", false); err != nil {
+		t.Fatalf("serve xml: %v", err)
+	}
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == "" {
+		t.Fatalf("expected 200 with ETag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// SignatureAlgorithm is the JWS algorithm used for PINC signatures.
+const SignatureAlgorithm = "EdDSA"
+
+var (
+	// ErrSignatureMissing indicates the payload carries no meta.signature.
+	ErrSignatureMissing = errors.New("pinc signature missing")
+	// ErrSignatureInvalid indicates the signature does not match the payload or key.
+	ErrSignatureInvalid = errors.New("pinc signature invalid")
+)
+
+type jwsHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// KeyID returns the RFC 7638 JWK thumbprint of an Ed25519 public key.
+func KeyID(pub ed25519.PublicKey) string {
+	// Thumbprint members are the required OKP members in lexicographic order.
+	thumb := `{"crv":"Ed25519","kty":"OKP","x":"` + base64.RawURLEncoding.EncodeToString(pub) + `"}`
+	sum := sha256.Sum256([]byte(thumb))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicJWK returns the public key as a JWK suitable for publishing.
+func PublicJWK(pub ed25519.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(pub),
+		"kid": KeyID(pub),
+		"alg": SignatureAlgorithm,
+		"use": "sig",
+	}
+}
+
+// SignPINC returns a detached compact JWS over the canonical JSON of payload.
+// Any existing meta.signature is excluded from the signed bytes.
+func SignPINC(payload interface{}, key ed25519.PrivateKey) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	canonical, _, err := canonicalPINC(raw)
+	if err != nil {
+		return "", err
+	}
+	pub, _ := key.Public().(ed25519.PublicKey)
+	header, err := json.Marshal(jwsHeader{Alg: SignatureAlgorithm, Kid: KeyID(pub)})
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	signature := ed25519.Sign(key, signingInput(encodedHeader, canonical))
+	return encodedHeader + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyPINC checks the detached JWS in meta.signature of a PINC JSON document.
+func VerifyPINC(payload []byte, pub ed25519.PublicKey) error {
+	canonical, signature, err := canonicalPINC(payload)
+	if err != nil {
+		return err
+	}
+	if signature == "" {
+		return ErrSignatureMissing
+	}
+	return VerifyDetached(canonical, signature, pub)
+}
+
+// VerifyDetached checks a detached compact JWS against canonical payload bytes.
+func VerifyDetached(canonical []byte, jws string, pub ed25519.PublicKey) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return ErrSignatureInvalid
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return ErrSignatureInvalid
+	}
+	if header.Alg != SignatureAlgorithm {
+		return ErrSignatureInvalid
+	}
+	if header.Kid != "" && header.Kid != KeyID(pub) {
+		return ErrSignatureInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return ErrSignatureInvalid
+	}
+	if !ed25519.Verify(pub, signingInput(parts[0], canonical), signature) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// signingInput builds the JWS signing input for a header and payload.
+func signingInput(encodedHeader string, payload []byte) []byte {
+	return []byte(encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// canonicalPINC strips meta.signature and returns canonical JSON plus the removed signature.
+func canonicalPINC(raw []byte) ([]byte, string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, "", err
+	}
+	signature := ""
+	if meta, ok := doc["meta"].(map[string]interface{}); ok {
+		signature, _ = meta["signature"].(string)
+		delete(meta, "signature")
+	}
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, doc); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), signature, nil
+}
+
+// writeCanonical writes JSON with sorted object keys, no whitespace, and no HTML escaping.
+func writeCanonical(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalString(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case string:
+		return writeCanonicalString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case nil:
+		buf.WriteString("null")
+	default:
+		return errors.New("unsupported JSON value")
+	}
+	return nil
+}
+
+// writeCanonicalString writes a JSON string without HTML escaping.
+func writeCanonicalString(buf *bytes.Buffer, value string) error {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(out.Bytes(), []byte("\n")))
+	return nil
+}
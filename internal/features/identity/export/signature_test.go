@@ -0,0 +1,87 @@
+package export
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pin/internal/domain"
+)
+
+// TestServePINCJSONSignatureVerifies verifies signed PINC output can be checked offline.
+func TestServePINCJSONSignatureVerifies(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	handler := NewHandler(pincSource{baseURL: "https://pin.example", key: key})
+	user := domain.Identity{
+		ID:          1,
+		Handle:      "alice",
+		DisplayName: "Alice <Admin> & Co",
+		LinksJSON:   `[{"label":"Site","url":"https://alice.example/?a=1&b=2"}]`,
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	if err := handler.ServePINCJSON(rec, req, user, map[string]string{"note": "hi"}, "public", ""); err != nil {
+		t.Fatalf("serve pinc: %v", err)
+	}
+	body := rec.Body.Bytes()
+	if !strings.Contains(string(body), `"signature":"`) {
+		t.Fatalf("expected meta.signature in payload: %s", body)
+	}
+	if err := VerifyPINC(body, pub); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	tampered := []byte(strings.Replace(string(body), `"handle":"alice"`, `"handle":"mallory"`, 1))
+	if err := VerifyPINC(tampered, pub); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected invalid signature for tampered payload, got %v", err)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	if err := VerifyPINC(body, otherPub); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected invalid signature for wrong key, got %v", err)
+	}
+}
+
+// TestVerifyPINCIgnoresFormatting verifies verification is independent of key order and whitespace.
+func TestVerifyPINCIgnoresFormatting(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	doc := map[string]interface{}{
+		"meta":     map[string]interface{}{"version": "pinc-1", "rev": "sha256:abc"},
+		"identity": map[string]interface{}{"handle": "alice", "display_name": "A & B"},
+	}
+	signature, err := SignPINC(doc, key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	reformatted := `{
+  "identity": {"display_name": "A & B", "handle": "alice"},
+  "meta": {"signature": "` + signature + `", "rev": "sha256:abc", "version": "pinc-1"}
+}`
+	if err := VerifyPINC([]byte(reformatted), pub); err != nil {
+		t.Fatalf("verify reformatted: %v", err)
+	}
+	if err := VerifyPINC([]byte(`{"meta":{"rev":"x"},"identity":{}}`), pub); !errors.Is(err, ErrSignatureMissing) {
+		t.Fatalf("expected missing signature, got %v", err)
+	}
+}
+
+// TestBuildPINCUnsignedWithoutKey verifies exports stay unsigned when no key is configured.
+func TestBuildPINCUnsignedWithoutKey(t *testing.T) {
+	handler := NewHandler(pincSource{baseURL: "https://pin.example"})
+	env, err := handler.BuildPINC(context.Background(), httptest.NewRequest(http.MethodGet, "/alice.json", nil), domain.Identity{Handle: "alice"}, nil, "public", "")
+	if err != nil {
+		t.Fatalf("build pinc: %v", err)
+	}
+	if env.Meta.Signature != "" {
+		t.Fatalf("expected no signature, got %q", env.Meta.Signature)
+	}
+}
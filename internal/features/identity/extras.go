@@ -69,6 +69,7 @@ func MergeSocialProfiles(updated []domain.SocialProfile, existing []domain.Socia
 		}
 		if prev, ok := existingByURL[key]; ok {
 			profile.Verified = prev.Verified
+			profile.VerifiedAt = prev.VerifiedAt
 			if profile.Provider == "" {
 				profile.Provider = prev.Provider
 			}
@@ -77,3 +78,21 @@ func MergeSocialProfiles(updated []domain.SocialProfile, existing []domain.Socia
 	}
 	return out
 }
+
+// MergeLinks preserves existing rel="me" verification when links are rewritten from a form.
+func MergeLinks(updated []domain.Link, existing []domain.Link) []domain.Link {
+	existingByURL := map[string]domain.Link{}
+	for _, link := range existing {
+		key := strings.ToLower(strings.TrimSpace(link.URL))
+		if key != "" {
+			existingByURL[key] = link
+		}
+	}
+	for i, link := range updated {
+		if prev, ok := existingByURL[strings.ToLower(strings.TrimSpace(link.URL))]; ok {
+			updated[i].Verified = prev.Verified
+			updated[i].VerifiedAt = prev.VerifiedAt
+		}
+	}
+	return updated
+}
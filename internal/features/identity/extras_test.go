@@ -50,3 +50,21 @@ func TestMergeSocialProfiles(t *testing.T) {
 		t.Fatalf("expected provider to be preserved")
 	}
 }
+
+// TestMergeLinks verifies rel="me" verification survives a form rewrite of the same URL only.
+func TestMergeLinks(t *testing.T) {
+	existing := []domain.Link{
+		{Label: "Blog", URL: "https://blog.example/", Verified: true, VerifiedAt: "2025-01-01T00:00:00Z"},
+	}
+	updated := []domain.Link{
+		{Label: "My blog", URL: "https://Blog.example/"},
+		{Label: "Shop", URL: "https://shop.example/"},
+	}
+	out := MergeLinks(updated, existing)
+	if !out[0].Verified || out[0].VerifiedAt != "2025-01-01T00:00:00Z" || out[0].Label != "My blog" {
+		t.Fatalf("expected verification to be preserved, got %+v", out[0])
+	}
+	if out[1].Verified {
+		t.Fatalf("expected a new link to be unverified")
+	}
+}
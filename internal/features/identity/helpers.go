@@ -42,6 +42,7 @@ func VisibleIdentity(user domain.Identity, isPrivate bool) (domain.Identity, map
 		"timezone":       &user.Timezone,
 		"atproto_handle": &user.ATProtoHandle,
 		"atproto_did":    &user.ATProtoDID,
+		"nostr_relays":   &user.NostrRelaysJSON,
 	}, fieldVisibility, defaultPrivate)
 
 	if user.LinksJSON != "" {
@@ -85,7 +86,9 @@ func VisibleIdentity(user domain.Identity, isPrivate bool) (domain.Identity, map
 	if user.PublicKeysJSON != "" {
 		if m := DecodeStringMap(user.PublicKeysJSON); len(m) > 0 {
 			for k := range m {
-				if isVisibilityPrivate(customVisibility, "key."+strings.ToLower(k), "") {
+				// The profile form stores key visibility as key_<label>; older rows used key.<label>.
+				label := strings.ToLower(k)
+				if isVisibilityPrivate(customVisibility, "key_"+label, "") || isVisibilityPrivate(customVisibility, "key."+label, "") {
 					delete(m, k)
 				}
 			}
@@ -391,7 +394,7 @@ func PublicKeysMapToStructs(keys map[string]string) []domain.PublicKey {
 		return nil
 	}
 	var out []domain.PublicKey
-	algorithms := []string{"pgp", "ssh", "age", "activitypub"}
+	algorithms := []string{"pgp", "ssh", "age", "activitypub", "nostr"}
 	for _, algo := range algorithms {
 		if key := strings.TrimSpace(keys[algo]); key != "" {
 			out = append(out, domain.PublicKey{
@@ -459,6 +462,7 @@ func BuildAttachments(user domain.Identity, wallets, keys map[string]string, dom
 	add("PGP", keys["pgp"])
 	add("SSH", keys["ssh"])
 	add("age", keys["age"])
+	add("Nostr", keys["nostr"])
 	for _, profile := range social {
 		if strings.TrimSpace(profile.URL) == "" {
 			continue
@@ -27,7 +27,8 @@ func TestVisibleIdentityFiltersPrivateFields(t *testing.T) {
 		LinksJSON:          EncodeLinks(links),
 		SocialProfilesJSON: EncodeSocialProfiles(social),
 		WalletsJSON:        EncodeStringMap(map[string]string{"btc": "1", "eth": "2"}),
-		PublicKeysJSON:     EncodeStringMap(map[string]string{"pgp": "key"}),
+		PublicKeysJSON:     EncodeStringMap(map[string]string{"pgp": "key", "nostr": "npub"}),
+		NostrRelaysJSON:    `["wss://relay.example"]`,
 		CustomFieldsJSON:   EncodeStringMap(map[string]string{"foo": "bar"}),
 		VisibilityJSON: EncodeVisibilityMap(map[string]string{
 			"display_name": "private",
@@ -38,6 +39,8 @@ func TestVisibleIdentityFiltersPrivateFields(t *testing.T) {
 			"social:1":     "private",
 			"wallet.btc":   "private",
 			"key.pgp":      "private",
+			"key_nostr":    "private",
+			"nostr_relays": "private",
 			"custom.foo":   "private",
 		}),
 	}
@@ -71,6 +74,12 @@ func TestVisibleIdentityFiltersPrivateFields(t *testing.T) {
 	if _, ok := keys["pgp"]; ok {
 		t.Fatalf("expected private key to be removed")
 	}
+	if _, ok := keys["nostr"]; ok {
+		t.Fatalf("expected key hidden through its form visibility key to be removed")
+	}
+	if publicUser.NostrRelaysJSON != "" {
+		t.Fatalf("expected nostr relays to be filtered")
+	}
 	if publicUser.CustomFieldsJSON != "" {
 		t.Fatalf("expected custom fields to be removed from user")
 	}
@@ -0,0 +1,333 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Key kinds reported by ParsePublicKeys.
+const (
+	KeyEd25519 = "ed25519"
+	KeyRSA     = "rsa"
+	KeyECDSA   = "ecdsa"
+	KeyX25519  = "x25519"
+)
+
+// ParsedKey is a public key decoded from an identity's key map.
+type ParsedKey struct {
+	// Label is the key map entry the key came from, e.g. "ssh".
+	Label string
+	Kind  string
+	// Public is an ed25519.PublicKey, *rsa.PublicKey, *ecdsa.PublicKey or *ecdh.PublicKey (X25519).
+	Public crypto.PublicKey
+}
+
+// ParsePublicKeys decodes the keys it understands from an identity's key map: SSH authorized_keys
+// lines, age recipients and PEM keys. Entries that do not parse are skipped.
+func ParsePublicKeys(keys map[string]string) []ParsedKey {
+	labels := make([]string, 0, len(keys))
+	for label := range keys {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	var out []ParsedKey
+	for _, label := range labels {
+		value := strings.TrimSpace(keys[label])
+		switch {
+		case value == "":
+		case label == "ssh":
+			out = append(out, parseSSHKeys(label, value)...)
+		case label == "age":
+			if pub, err := ParseAgeRecipient(value); err == nil {
+				out = append(out, ParsedKey{Label: label, Kind: KeyX25519, Public: pub})
+			}
+		case strings.HasPrefix(value, "-----BEGIN PUBLIC KEY-----"):
+			if key, ok := parsePEMKey(label, value); ok {
+				out = append(out, key)
+			}
+		}
+	}
+	return out
+}
+
+// KeyFingerprint identifies one public key from an identity's key map.
+type KeyFingerprint struct {
+	// Key is the key map label the key came from, e.g. "pgp".
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// PublicKeyFingerprints fingerprints the OpenPGP, SSH and PEM keys in an identity's key map:
+// OpenPGP keys by their primary key fingerprint, SSH keys as OpenSSH's SHA256 form, and PEM
+// keys as the SHA-256 of their DER encoding. Entries that do not parse are skipped.
+func PublicKeyFingerprints(keys map[string]string) []KeyFingerprint {
+	labels := make([]string, 0, len(keys))
+	for label := range keys {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	var out []KeyFingerprint
+	for _, label := range labels {
+		value := strings.TrimSpace(keys[label])
+		switch {
+		case value == "":
+		case label == "pgp":
+			if key, err := ParseOpenPGPKey(value); err == nil {
+				out = append(out, KeyFingerprint{Key: label, Type: "openpgp", Fingerprint: key.Fingerprint})
+			}
+		case label == "ssh":
+			for _, line := range SSHAuthorizedKeys(value) {
+				key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+				if err != nil {
+					continue
+				}
+				out = append(out, KeyFingerprint{Key: label, Type: key.Type(), Fingerprint: ssh.FingerprintSHA256(key)})
+			}
+		case strings.HasPrefix(value, "-----BEGIN PUBLIC KEY-----"):
+			if key, ok := parsePEMKey(label, value); ok {
+				if der, err := x509.MarshalPKIXPublicKey(key.Public); err == nil {
+					sum := sha256.Sum256(der)
+					out = append(out, KeyFingerprint{Key: label, Type: key.Kind, Fingerprint: "SHA256:" + hex.EncodeToString(sum[:])})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SSHAuthorizedKeys returns the valid keys of an authorized_keys value, one normalized
+// "type base64" line each; comments, options and malformed lines are dropped.
+func SSHAuthorizedKeys(value string) []string {
+	var out []string
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			continue
+		}
+		out = append(out, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))))
+	}
+	return out
+}
+
+// parseSSHKeys decodes each authorized_keys line; security-key types are skipped because they
+// do not sign plain messages.
+func parseSSHKeys(label, value string) []ParsedKey {
+	var out []ParsedKey
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil || strings.HasPrefix(key.Type(), "sk-") {
+			continue
+		}
+		cryptoKey, ok := key.(ssh.CryptoPublicKey)
+		if !ok {
+			continue
+		}
+		if parsed, ok := typedKey(label, cryptoKey.CryptoPublicKey()); ok {
+			out = append(out, parsed)
+		}
+	}
+	return out
+}
+
+// parsePEMKey decodes a PKIX public key.
+func parsePEMKey(label, value string) (ParsedKey, bool) {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return ParsedKey{}, false
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return ParsedKey{}, false
+	}
+	return typedKey(label, pub)
+}
+
+// typedKey classifies a standard library public key.
+func typedKey(label string, pub crypto.PublicKey) (ParsedKey, bool) {
+	switch pub.(type) {
+	case ed25519.PublicKey:
+		return ParsedKey{Label: label, Kind: KeyEd25519, Public: pub}, true
+	case *rsa.PublicKey:
+		return ParsedKey{Label: label, Kind: KeyRSA, Public: pub}, true
+	case *ecdsa.PublicKey:
+		return ParsedKey{Label: label, Kind: KeyECDSA, Public: pub}, true
+	case *ecdh.PublicKey:
+		return ParsedKey{Label: label, Kind: KeyX25519, Public: pub}, true
+	}
+	return ParsedKey{}, false
+}
+
+// ParseAgeRecipient decodes an "age1..." X25519 recipient.
+func ParseAgeRecipient(value string) (*ecdh.PublicKey, error) {
+	hrp, data, err := DecodeBech32(strings.ToLower(strings.TrimSpace(value)))
+	if err != nil {
+		return nil, err
+	}
+	if hrp != "age" {
+		return nil, errors.New("not an age recipient")
+	}
+	return ecdh.X25519().NewPublicKey(data)
+}
+
+// ParseNostrKey normalizes a Nostr public key given as an npub or 64 hex characters to lowercase hex.
+func ParseNostrKey(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if strings.HasPrefix(value, "npub1") {
+		hrp, data, err := DecodeBech32(value)
+		if err != nil {
+			return "", err
+		}
+		if hrp != "npub" || len(data) != 32 {
+			return "", errors.New("not an npub key")
+		}
+		return hex.EncodeToString(data), nil
+	}
+	if len(value) != 64 {
+		return "", errors.New("nostr key must be an npub or 64 hex characters")
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return "", errors.New("nostr key must be an npub or 64 hex characters")
+	}
+	return value, nil
+}
+
+// Multikey encodes an Ed25519 or X25519 public key as a base58btc Multikey value; other kinds
+// have no Multikey form here and report false.
+func Multikey(pub crypto.PublicKey) (string, bool) {
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		// 0xed 0x01 is the multicodec prefix for ed25519-pub.
+		return "z" + Base58Encode(append([]byte{0xed, 0x01}, key...)), true
+	case *ecdh.PublicKey:
+		if key.Curve() != ecdh.X25519() {
+			return "", false
+		}
+		// 0xec 0x01 is the multicodec prefix for x25519-pub.
+		return "z" + Base58Encode(append([]byte{0xec, 0x01}, key.Bytes()...)), true
+	}
+	return "", false
+}
+
+// base58Alphabet is the Bitcoin base58 alphabet used by multibase "z".
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Base58Encode encodes data in base58btc, keeping leading zero bytes as '1'.
+func Base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// bech32Charset maps 5-bit groups to bech32 characters.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// DecodeBech32 decodes a lowercase bech32 string (BIP-173), as used by age recipients and
+// Nostr npub keys, and returns its human-readable part and 8-bit payload.
+func DecodeBech32(value string) (string, []byte, error) {
+	sep := strings.LastIndexByte(value, '1')
+	if sep < 1 || sep+7 > len(value) {
+		return "", nil, errors.New("invalid bech32 string")
+	}
+	hrp := value[:sep]
+	values := make([]byte, 0, len(value)-sep-1)
+	for _, c := range value[sep+1:] {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx < 0 {
+			return "", nil, errors.New("invalid bech32 character")
+		}
+		values = append(values, byte(idx))
+	}
+	if bech32Polymod(append(bech32ExpandHRP(hrp), values...)) != 1 {
+		return "", nil, errors.New("invalid bech32 checksum")
+	}
+	data, err := convertBits(values[:len(values)-6], 5, 8)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+// bech32Polymod computes the bech32 checksum over values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32ExpandHRP expands the human-readable part for checksumming.
+func bech32ExpandHRP(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups 5-bit values into bytes, rejecting non-zero padding.
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+	var out []byte
+	for _, value := range data {
+		acc = acc<<from | uint(value)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxv != 0 {
+		return nil, errors.New("invalid bech32 padding")
+	}
+	return out, nil
+}
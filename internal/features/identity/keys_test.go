@@ -0,0 +1,94 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestDecodeBech32 verifies decoding against the NIP-19 npub example and checksum validation.
+func TestDecodeBech32(t *testing.T) {
+	hrp, data, err := DecodeBech32("npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if hrp != "npub" || hex.EncodeToString(data) != "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e" {
+		t.Fatalf("unexpected decode %s %x", hrp, data)
+	}
+	if _, _, err := DecodeBech32("npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjpth"); err == nil {
+		t.Fatalf("expected a checksum error")
+	}
+}
+
+// TestParsePublicKeys verifies SSH, age and unparseable entries.
+func TestParsePublicKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	sshKey, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("ssh key: %v", err)
+	}
+	keys := ParsePublicKeys(map[string]string{
+		"ssh": "# laptop\n" + string(ssh.MarshalAuthorizedKey(sshKey)) + "not a key\n",
+		"age": "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+		"pgp": "-----BEGIN PGP PUBLIC KEY BLOCK-----",
+	})
+	if len(keys) != 2 {
+		t.Fatalf("expected two parsed keys, got %+v", keys)
+	}
+	if keys[0].Label != "age" || keys[0].Kind != KeyX25519 {
+		t.Fatalf("unexpected age key %+v", keys[0])
+	}
+	if keys[1].Label != "ssh" || keys[1].Kind != KeyEd25519 || !pub.Equal(keys[1].Public) {
+		t.Fatalf("unexpected ssh key %+v", keys[1])
+	}
+	if multikey, ok := Multikey(keys[1].Public); !ok || multikey[:4] != "z6Mk" {
+		t.Fatalf("expected an ed25519 Multikey, got %q", multikey)
+	}
+}
+
+// TestParseNostrKey verifies npub and hex keys normalize to the same hex form.
+func TestParseNostrKey(t *testing.T) {
+	const want = "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e"
+	for _, value := range []string{
+		"npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+		" 7E7E9C42A91BFEF19FA929E5FDA1B72E0EBC1A4C1141673E2794234D86ADDF4E ",
+	} {
+		got, err := ParseNostrKey(value)
+		if err != nil || got != want {
+			t.Fatalf("ParseNostrKey(%q) = %q, %v", value, got, err)
+		}
+	}
+	for _, value := range []string{"", "npub1invalid", want[:62], "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"} {
+		if _, err := ParseNostrKey(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+// TestPublicKeyFingerprints verifies SSH fingerprints and authorized_keys normalization.
+func TestPublicKeyFingerprints(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	sshKey, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("ssh key: %v", err)
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshKey)))
+	value := "# laptop\n" + line + " alice@laptop\nnot a key\n"
+	if lines := SSHAuthorizedKeys(value); len(lines) != 1 || lines[0] != line {
+		t.Fatalf("unexpected authorized keys %q", lines)
+	}
+	prints := PublicKeyFingerprints(map[string]string{"ssh": value, "pgp": "garbage"})
+	if len(prints) != 1 || prints[0].Key != "ssh" || prints[0].Type != "ssh-ed25519" || prints[0].Fingerprint != ssh.FingerprintSHA256(sshKey) {
+		t.Fatalf("unexpected fingerprints %+v", prints)
+	}
+}
@@ -0,0 +1,177 @@
+package identity
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// OpenPGP packet tags read from transferable public keys.
+const (
+	pgpTagPublicKey = 6
+	pgpTagUserID    = 13
+)
+
+// OpenPGPKey is an armored OpenPGP public key decoded just far enough to publish it.
+type OpenPGPKey struct {
+	// Fingerprint is the primary key's uppercase hex fingerprint.
+	Fingerprint string
+	// Emails are the addresses of the key's User ID packets, lowercased.
+	Emails []string
+	// Packets is the binary transferable public key, as served by WKD.
+	Packets []byte
+}
+
+// ParseOpenPGPKey dearmors a "PGP PUBLIC KEY BLOCK" and reads its primary key fingerprint and
+// User IDs. Packets are walked rather than fully parsed, so any key algorithm is accepted.
+func ParseOpenPGPKey(value string) (OpenPGPKey, error) {
+	block, err := armor.Decode(strings.NewReader(strings.TrimSpace(value)))
+	if err != nil {
+		return OpenPGPKey{}, err
+	}
+	if block.Type != "PGP PUBLIC KEY BLOCK" {
+		return OpenPGPKey{}, errors.New("not an OpenPGP public key block")
+	}
+	packets, err := io.ReadAll(block.Body)
+	if err != nil {
+		return OpenPGPKey{}, err
+	}
+	key := OpenPGPKey{Packets: packets}
+	rest := packets
+	for len(rest) > 0 {
+		tag, body, next, err := readPGPPacket(rest)
+		if err != nil {
+			return OpenPGPKey{}, err
+		}
+		rest = next
+		switch {
+		case tag == pgpTagPublicKey && key.Fingerprint == "":
+			if key.Fingerprint, err = pgpFingerprint(body); err != nil {
+				return OpenPGPKey{}, err
+			}
+		case tag == pgpTagUserID:
+			if addr, err := mail.ParseAddress(string(body)); err == nil {
+				key.Emails = append(key.Emails, strings.ToLower(addr.Address))
+			}
+		}
+	}
+	if key.Fingerprint == "" {
+		return OpenPGPKey{}, errors.New("no OpenPGP public key packet")
+	}
+	return key, nil
+}
+
+// HasEmail reports whether one of the key's User IDs carries email.
+func (k OpenPGPKey) HasEmail(email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, candidate := range k.Emails {
+		if candidate == email {
+			return true
+		}
+	}
+	return false
+}
+
+// readPGPPacket splits the first packet off data (RFC 9580 section 4.2). Partial body lengths
+// never occur in key material and are rejected.
+func readPGPPacket(data []byte) (tag byte, body, rest []byte, err error) {
+	header := data[0]
+	if header&0x80 == 0 {
+		return 0, nil, nil, errors.New("invalid OpenPGP packet header")
+	}
+	var length, offset int
+	if header&0x40 != 0 {
+		tag = header & 0x3f
+		if len(data) < 2 {
+			return 0, nil, nil, errors.New("truncated OpenPGP packet")
+		}
+		switch first := int(data[1]); {
+		case first < 192:
+			length, offset = first, 2
+		case first < 224:
+			if len(data) < 3 {
+				return 0, nil, nil, errors.New("truncated OpenPGP packet")
+			}
+			length, offset = (first-192)<<8+int(data[2])+192, 3
+		case first == 255:
+			if len(data) < 6 {
+				return 0, nil, nil, errors.New("truncated OpenPGP packet")
+			}
+			length, offset = int(binary.BigEndian.Uint32(data[2:6])), 6
+		default:
+			return 0, nil, nil, errors.New("partial OpenPGP packet lengths are not supported")
+		}
+	} else {
+		tag = (header >> 2) & 0x0f
+		size := []int{1, 2, 4}
+		lengthType := int(header & 0x03)
+		if lengthType == 3 {
+			return 0, nil, nil, errors.New("indeterminate OpenPGP packet lengths are not supported")
+		}
+		offset = 1 + size[lengthType]
+		if len(data) < offset {
+			return 0, nil, nil, errors.New("truncated OpenPGP packet")
+		}
+		for _, b := range data[1:offset] {
+			length = length<<8 | int(b)
+		}
+	}
+	if length < 0 || len(data)-offset < length {
+		return 0, nil, nil, errors.New("truncated OpenPGP packet")
+	}
+	return tag, data[offset : offset+length], data[offset+length:], nil
+}
+
+// pgpFingerprint computes a v4 (SHA-1) or v6 (SHA-256) fingerprint of a public key packet body.
+func pgpFingerprint(body []byte) (string, error) {
+	if len(body) == 0 {
+		return "", errors.New("empty OpenPGP public key packet")
+	}
+	var buf bytes.Buffer
+	switch body[0] {
+	case 4:
+		buf.WriteByte(0x99)
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(body)))
+		buf.Write(body)
+		sum := sha1.Sum(buf.Bytes())
+		return strings.ToUpper(hex.EncodeToString(sum[:])), nil
+	case 6:
+		buf.WriteByte(0x9b)
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(body)))
+		buf.Write(body)
+		sum := sha256.Sum256(buf.Bytes())
+		return strings.ToUpper(hex.EncodeToString(sum[:])), nil
+	}
+	return "", errors.New("unsupported OpenPGP key version")
+}
+
+// zbase32Alphabet is the z-base-32 alphabet WKD uses for hashed local parts.
+const zbase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// WKDHash returns the Web Key Directory hash of an address's local part: the z-base-32
+// encoded SHA-1 of the lowercased local part.
+func WKDHash(localPart string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(localPart)))
+	var out strings.Builder
+	var acc, bits uint
+	for _, b := range sum {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out.WriteByte(zbase32Alphabet[acc>>bits&0x1f])
+		}
+	}
+	if bits > 0 {
+		out.WriteByte(zbase32Alphabet[acc<<(5-bits)&0x1f])
+	}
+	return out.String()
+}
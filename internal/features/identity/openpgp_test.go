@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// TestWKDHash verifies the example from the Web Key Directory draft.
+func TestWKDHash(t *testing.T) {
+	if got := WKDHash("Joe.Doe"); got != "iy9q119eutrkn8s1mk4r39qejnbu3n5q" {
+		t.Fatalf("unexpected WKD hash %q", got)
+	}
+}
+
+// TestParseOpenPGPKey verifies fingerprints and User IDs against a generated key.
+func TestParseOpenPGPKey(t *testing.T) {
+	entity, err := openpgp.NewEntity("Alice", "", "Alice@Example.org", nil)
+	if err != nil {
+		t.Fatalf("new entity: %v", err)
+	}
+	var buf bytes.Buffer
+	writer, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor: %v", err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	_ = writer.Close()
+
+	key, err := ParseOpenPGPKey(buf.String())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := strings.ToUpper(fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint)); key.Fingerprint != want {
+		t.Fatalf("expected fingerprint %s, got %s", want, key.Fingerprint)
+	}
+	if !key.HasEmail("alice@example.org") || key.HasEmail("bob@example.org") {
+		t.Fatalf("unexpected emails %v", key.Emails)
+	}
+	if len(key.Packets) == 0 {
+		t.Fatalf("expected binary key packets")
+	}
+
+	if _, err := ParseOpenPGPKey(strings.Replace(buf.String(), "PUBLIC KEY", "PRIVATE KEY", 2)); err == nil {
+		t.Fatalf("expected a private key block to be rejected")
+	}
+	if _, err := ParseOpenPGPKey("not a key"); err == nil {
+		t.Fatalf("expected garbage to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ package identity
 import (
 	"net/http"
 	"strings"
+	"time"
 )
 
 // FromIdent splits an identifier into name and extension.
@@ -52,6 +53,61 @@ func WritePrivateIdentityCacheHeaders(w http.ResponseWriter) {
 	w.Header().Set("Cache-Control", "private, no-store")
 }
 
+// IdentityETag returns a strong ETag for an export format derived from a PINC rev.
+func IdentityETag(rev, ext string) string {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return ""
+	}
+	rev = strings.TrimPrefix(rev, "sha256:")
+	return `"` + rev + "." + strings.ToLower(ext) + `"`
+}
+
+// WriteIdentityValidators writes ETag and Last-Modified headers to the response/output.
+func WriteIdentityValidators(w http.ResponseWriter, etag string, lastModified time.Time) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// IdentityNotModified reports whether the request preconditions match the current representation.
+// If-None-Match takes precedence over If-Modified-Since as required by RFC 9110.
+func IdentityNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagListMatches(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.UTC().Truncate(time.Second).After(since)
+}
+
+// etagListMatches reports whether an If-None-Match list contains the ETag using weak comparison.
+func etagListMatches(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // FirstNonEmpty returns the first non empty.
 func FirstNonEmpty(values ...string) string {
 	for _, value := range values {
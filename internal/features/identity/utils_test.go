@@ -1,7 +1,10 @@
 package identity
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"pin/internal/domain"
 )
@@ -76,3 +79,41 @@ func TestSubjectForIdentityNormalizesHandle(t *testing.T) {
 		t.Fatalf("expected different subject for different ID")
 	}
 }
+
+// TestIdentityNotModified verifies identity not modified behavior.
+func TestIdentityNotModified(t *testing.T) {
+	etag := IdentityETag("sha256:abc", "json")
+	if etag != `"abc.json"` {
+		t.Fatalf("unexpected etag: %q", etag)
+	}
+	updated := time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	req := httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	req.Header.Set("If-None-Match", `"other", W/"abc.json"`)
+	if !IdentityNotModified(req, etag, updated) {
+		t.Fatalf("expected If-None-Match to match")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	req.Header.Set("If-Modified-Since", updated.Add(time.Hour).Format(http.TimeFormat))
+	if IdentityNotModified(req, etag, updated) {
+		t.Fatalf("expected If-None-Match to take precedence over If-Modified-Since")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/alice.json", nil)
+	req.Header.Set("If-Modified-Since", updated.Format(http.TimeFormat))
+	if !IdentityNotModified(req, etag, updated) {
+		t.Fatalf("expected If-Modified-Since at updated_at to match")
+	}
+	req.Header.Set("If-Modified-Since", updated.Add(-time.Second).Format(http.TimeFormat))
+	if IdentityNotModified(req, etag, updated) {
+		t.Fatalf("expected older If-Modified-Since to miss")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/alice.json", nil)
+	req.Header.Set("If-None-Match", "*")
+	if IdentityNotModified(req, etag, updated) {
+		t.Fatalf("expected non-GET requests to ignore preconditions")
+	}
+}
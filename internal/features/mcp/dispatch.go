@@ -0,0 +1,440 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/features/identity/export"
+	"pin/internal/features/profilepicture"
+	"pin/internal/platform/core"
+)
+
+// supportedVersions lists the MCP protocol revisions this server speaks, newest first.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type readParams struct {
+	URI string `json:"uri"`
+}
+
+// exchange is the transport state messages are handled with; initialize attaches a session to it.
+type exchange struct {
+	baseURL   string
+	session   *session
+	sessions  *sessionStore
+	principal principal
+}
+
+// isNotification reports whether the message expects no response.
+func (req request) isNotification() bool {
+	return len(req.ID) == 0
+}
+
+// handleMessage decodes and handles one JSON-RPC message, returning nil when no response is due.
+func (h Handler) handleMessage(ctx context.Context, ex *exchange, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &response{JSONRPC: "2.0", Error: &rpcError{Code: -32600, Message: "Invalid request"}}
+	}
+	// Responses to server-initiated requests carry no method; the server sends none, so drop them.
+	if req.Method == "" && !req.isNotification() {
+		return nil
+	}
+	result, rpcErr := h.handle(ctx, ex, req)
+	if req.isNotification() {
+		return nil
+	}
+	if rpcErr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// handle runs one JSON-RPC method.
+func (h Handler) handle(ctx context.Context, ex *exchange, req request) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: -32600, Message: "Invalid JSON-RPC version"}
+	}
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	if h.cfg.ReadOnly && !isReadMethod(req.Method) {
+		return nil, &rpcError{Code: -32601, Message: "Method not allowed"}
+	}
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if len(req.Params) > 0 {
+			_ = json.Unmarshal(req.Params, &params)
+		}
+		version := negotiateVersion(params.ProtocolVersion)
+		if ex.session == nil && ex.sessions != nil {
+			sess, err := ex.sessions.create(ex.principal.key())
+			if err != nil {
+				return nil, &rpcError{Code: -32000, Message: "Too many open sessions"}
+			}
+			ex.session = sess
+		}
+		resources := map[string]interface{}{"subscribe": true, "listChanged": false}
+		capabilities := map[string]interface{}{"resources": resources}
+		if !h.cfg.ReadOnly {
+			capabilities["tools"] = map[string]interface{}{"listChanged": false}
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"serverInfo": map[string]string{
+				"name":    "pin",
+				"version": "1.0",
+			},
+			"capabilities": capabilities,
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "resources/list":
+		resources, err := h.listIdentityResources(ctx, ex.principal)
+		h.auditRead(ctx, ex.principal, "mcp.resources.list", "", err, nil)
+		if err != nil {
+			return nil, &rpcError{Code: -32000, Message: "Failed to list resources"}
+		}
+		return map[string]interface{}{"resources": resources}, nil
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": resourceTemplates()}, nil
+	case "resources/read":
+		var params readParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.URI) == "" {
+			return nil, &rpcError{Code: -32602, Message: "Invalid params"}
+		}
+		contents, err := h.readIdentityResource(ctx, ex.baseURL, ex.principal, params.URI)
+		h.auditRead(ctx, ex.principal, "mcp.resources.read", params.URI, err, nil)
+		if err != nil {
+			return nil, &rpcError{Code: -32004, Message: err.Error()}
+		}
+		return map[string]interface{}{"contents": contents}, nil
+	case "resources/subscribe", "resources/unsubscribe":
+		var params readParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.URI) == "" {
+			return nil, &rpcError{Code: -32602, Message: "Invalid params"}
+		}
+		if ex.session == nil {
+			return nil, &rpcError{Code: -32600, Message: "Subscriptions require an initialized session"}
+		}
+		if req.Method == "resources/unsubscribe" {
+			ex.session.unsubscribe(params.URI)
+			return map[string]interface{}{}, nil
+		}
+		rev, err := h.resourceRev(ctx, ex.baseURL, ex.principal, params.URI)
+		h.auditRead(ctx, ex.principal, "mcp.resources.subscribe", params.URI, err, nil)
+		if err != nil {
+			return nil, &rpcError{Code: -32004, Message: err.Error()}
+		}
+		if !ex.session.subscribe(params.URI, rev) {
+			return nil, &rpcError{Code: -32000, Message: "Too many subscriptions"}
+		}
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		// Read-only servers expose no tools, since every tool edits an identity.
+		list := []tool{}
+		if !h.cfg.ReadOnly {
+			list = tools()
+		}
+		return map[string]interface{}{"tools": list}, nil
+	case "tools/call":
+		// Edits are never exposed without a credential, even when reads are open.
+		if ex.principal.kind == credentialAnonymous {
+			return nil, &rpcError{Code: -32001, Message: "Write tools require a credential"}
+		}
+		var params callParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.Name) == "" {
+			return nil, &rpcError{Code: -32602, Message: "Invalid params"}
+		}
+		return h.callTool(ctx, ex.baseURL, ex.principal, params)
+	default:
+		return nil, &rpcError{Code: -32601, Message: "Method not found"}
+	}
+}
+
+// negotiateVersion echoes a supported requested version and otherwise offers the newest one.
+func negotiateVersion(requested string) string {
+	if isSupportedVersion(requested) {
+		return requested
+	}
+	return supportedVersions[0]
+}
+
+// isSupportedVersion reports whether the protocol revision is one this server speaks.
+func isSupportedVersion(version string) bool {
+	for _, supported := range supportedVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadMethod reports whether read method is true.
+func isReadMethod(method string) bool {
+	switch method {
+	case "initialize", "ping", "resources/list", "resources/read", "resources/templates/list",
+		"resources/subscribe", "resources/unsubscribe", "tools/list":
+		return true
+	default:
+		return false
+	}
+}
+
+// resourceTemplates describes the identity URIs clients can construct.
+func resourceTemplates() []resourceTemplate {
+	return []resourceTemplate{
+		{
+			URITemplate: "identity://{handle}{?view}",
+			Name:        "identity",
+			Description: "PINC export of an identity; view=private needs a credential that can read it",
+			MimeType:    "application/json",
+		},
+		{
+			URITemplate: "identity://{handle}/profile-picture{?view}",
+			Name:        "profile-picture",
+			Description: "Active profile picture URL and alt text of an identity",
+			MimeType:    "application/json",
+		},
+	}
+}
+
+// listIdentityResources lists the identities the principal may address, with private views where it may read them.
+func (h Handler) listIdentityResources(ctx context.Context, p principal) ([]resource, error) {
+	users, err := h.deps.ListIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resources []resource
+	for _, user := range users {
+		if user.Handle == "" || !p.canAccess(user) {
+			continue
+		}
+		base := "identity://" + user.Handle
+		resources = append(resources, resource{
+			URI:         base,
+			Name:        user.Handle,
+			Description: "Identity export for " + user.Handle,
+			MimeType:    "application/json",
+		})
+		if p.canReadPrivate(user) {
+			resources = append(resources, resource{
+				URI:         base + "?view=private",
+				Name:        user.Handle + " (private)",
+				Description: "Private identity export for " + user.Handle,
+				MimeType:    "application/json",
+			})
+		}
+		resources = append(resources, resource{
+			URI:         base + "/profile-picture",
+			Name:        user.Handle + " profile picture",
+			Description: "Active profile picture for " + user.Handle,
+			MimeType:    "application/json",
+		})
+	}
+	return resources, nil
+}
+
+// resolveIdentity looks up the identity a resource URI points at and checks the principal may read it in that view.
+// Identities outside the credential's reach are reported as missing rather than forbidden.
+func (h Handler) resolveIdentity(ctx context.Context, p principal, uri string) (identityResourceTarget, domain.Identity, error) {
+	target, err := parseIdentityURI(uri)
+	if err != nil {
+		return target, domain.Identity{}, err
+	}
+	user, err := h.deps.GetIdentityByHandle(ctx, target.Ident)
+	if err != nil || !identity.MatchesIdentity(user, target.Ident) || !p.canAccess(user) {
+		return target, domain.Identity{}, errors.New("Identity not found")
+	}
+	if target.Private && !p.canReadPrivate(user) {
+		return target, domain.Identity{}, errors.New("Private view requires the identity:read-private scope")
+	}
+	return target, user, nil
+}
+
+// renderIdentity builds the PINC export of an identity in the target's view and returns it with its rev.
+func (h Handler) renderIdentity(ctx context.Context, baseURL string, target identityResourceTarget, user domain.Identity) (interface{}, string, error) {
+	view := "public"
+	selfURL := ""
+	if target.Private {
+		view = "private"
+		selfURL = privateSelfURL(baseURL, user)
+	}
+	visible, customFields := identity.VisibleIdentity(user, target.Private)
+	envelope, err := export.NewHandler(source{deps: h.deps}).BuildPINCForBase(ctx, baseURL, visible, customFields, view, selfURL)
+	if err != nil {
+		return nil, "", errors.New("Failed to load identity")
+	}
+	return envelope, envelope.Meta.Rev, nil
+}
+
+// readIdentityResource resolves an identity URI into JSON-RPC resource contents.
+func (h Handler) readIdentityResource(ctx context.Context, baseURL string, p principal, uri string) ([]map[string]interface{}, error) {
+	target, user, err := h.resolveIdentity(ctx, p, uri)
+	if err != nil {
+		return nil, err
+	}
+	var payload interface{}
+	if target.ProfilePicture {
+		pictureURL := profilePictureURL(baseURL, user)
+		if selfURL := privateSelfURL(baseURL, user); target.Private && selfURL != "" {
+			pictureURL = strings.TrimSuffix(selfURL, ".json") + "/profile-picture"
+		}
+		payload = map[string]string{
+			"url": pictureURL,
+			"alt": profilepicture.NewService(h.deps).ActiveAlt(ctx, user),
+		}
+	} else if payload, _, err = h.renderIdentity(ctx, baseURL, target, user); err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(payload)
+	return []map[string]interface{}{
+		{
+			"uri":      uri,
+			"mimeType": "application/json",
+			"text":     string(raw),
+		},
+	}, nil
+}
+
+// resourceRev returns the PINC rev behind a resource in its view; picture resources also track the selected picture.
+func (h Handler) resourceRev(ctx context.Context, baseURL string, p principal, uri string) (string, error) {
+	target, user, err := h.resolveIdentity(ctx, p, uri)
+	if err != nil {
+		return "", err
+	}
+	_, rev, err := h.renderIdentity(ctx, baseURL, target, user)
+	if err != nil {
+		return "", err
+	}
+	if target.ProfilePicture {
+		return rev + ":" + strconv.FormatInt(user.ProfilePictureID.Int64, 10), nil
+	}
+	return rev, nil
+}
+
+// pollSubscriptions returns an update notification for every subscribed resource whose rev changed.
+func (h Handler) pollSubscriptions(ctx context.Context, baseURL string, p principal, sess *session) []notification {
+	var notes []notification
+	for uri, last := range sess.subscriptions() {
+		// A resource that disappeared reports an empty rev, which also counts as a change.
+		rev, _ := h.resourceRev(ctx, baseURL, p, uri)
+		if rev == last || !sess.advance(uri, last, rev) {
+			continue
+		}
+		notes = append(notes, notification{
+			JSONRPC: "2.0",
+			Method:  "notifications/resources/updated",
+			Params:  map[string]string{"uri": uri},
+		})
+	}
+	return notes
+}
+
+// auditRead records an MCP read under the principal's credential.
+func (h Handler) auditRead(ctx context.Context, p principal, action, target string, err error, extra map[string]string) {
+	meta := p.auditMeta()
+	for key, value := range extra {
+		meta[key] = value
+	}
+	h.deps.AuditOutcome(ctx, p.userID, action, target, err, meta)
+}
+
+type identityResourceTarget struct {
+	Ident          string
+	ProfilePicture bool
+	Private        bool
+}
+
+// parseIdentityURI validates and parses an identity:// URI, including its optional view query.
+func parseIdentityURI(uri string) (identityResourceTarget, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return identityResourceTarget{}, errors.New("Invalid resource URI")
+	}
+	if parsed.Scheme != "identity" {
+		return identityResourceTarget{}, errors.New("Unsupported resource URI")
+	}
+	ident := strings.Trim(parsed.Host+parsed.Path, "/")
+	if ident == "" {
+		return identityResourceTarget{}, errors.New("Invalid resource URI")
+	}
+	var target identityResourceTarget
+	switch parsed.Query().Get("view") {
+	case "", "public":
+	case "private":
+		target.Private = true
+	default:
+		return identityResourceTarget{}, errors.New("Unsupported view")
+	}
+	if strings.HasSuffix(strings.ToLower(ident), "/profile-picture") {
+		target.Ident = strings.TrimSuffix(ident, "/profile-picture")
+		target.ProfilePicture = true
+		return target, nil
+	}
+	target.Ident = ident
+	return target, nil
+}
+
+// profilePictureURL returns the public profile picture URL for a user.
+func profilePictureURL(baseURL string, user domain.Identity) string {
+	return baseURL + "/" + url.PathEscape(user.Handle) + "/profile-picture"
+}
+
+// privateSelfURL returns the private JSON export URL of a user, or "" before a private link exists.
+func privateSelfURL(baseURL string, user domain.Identity) string {
+	if user.PrivateToken == "" {
+		return ""
+	}
+	hash := core.ShortHash(strings.ToLower(strings.TrimSpace(user.Handle)), 7)
+	return baseURL + "/p/" + url.PathEscape(hash) + "/" + url.PathEscape(user.PrivateToken) + ".json"
+}
@@ -1,79 +1,82 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/domains"
+	"pin/internal/features/history"
 	"pin/internal/features/identity"
-	"pin/internal/features/identity/export"
 	"pin/internal/features/profilepicture"
 )
 
-const protocolVersion = "2024-11-05"
+// maxBodyBytes caps a POSTed message or batch.
+const maxBodyBytes = 1 << 20
+
+const (
+	defaultPollInterval = 5 * time.Second
+	keepAliveInterval   = 30 * time.Second
+)
+
+const (
+	sessionHeader   = "Mcp-Session-Id"
+	versionHeader   = "MCP-Protocol-Version"
+	eventStreamType = "text/event-stream"
+)
 
 type Config struct {
 	Enabled  bool
 	Token    string
 	ReadOnly bool
+	// PollInterval is how often open streams check subscribed identities for a new rev.
+	PollInterval time.Duration
 }
 
 type Dependencies interface {
 	profilepicture.Store
+	history.Store
+	apitokens.Store
+	domains.Store
 	ListIdentities(ctx context.Context) ([]domain.Identity, error)
 	GetIdentityByHandle(ctx context.Context, handle string) (domain.Identity, error)
 	BaseURL(r *http.Request) string
 	GetOwnerIdentity(ctx context.Context) (domain.Identity, error)
+	PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error)
+	HTTPClient() *http.Client
+	GetUserByID(ctx context.Context, id int) (domain.User, error)
+	Reserved() map[string]struct{}
+	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
+	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
 }
 
 type Handler struct {
-	cfg  Config
-	deps Dependencies
+	cfg      Config
+	deps     Dependencies
+	sessions *sessionStore
 }
 
 // NewHandler constructs a new handler.
 func NewHandler(cfg Config, deps Dependencies) Handler {
-	return Handler{cfg: cfg, deps: deps}
+	return Handler{cfg: cfg, deps: deps, sessions: newSessionStore()}
 }
 
-type request struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id"`
-	Method  string          `json:"method"`
-	Params  json.RawMessage `json:"params"`
-}
-
-type response struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      interface{} `json:"id"`
-	Result  interface{} `json:"result,omitempty"`
-	Error   *rpcError   `json:"error,omitempty"`
-}
-
-type rpcError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-}
-
-type resource struct {
-	URI         string `json:"uri"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
-	MimeType    string `json:"mimeType,omitempty"`
-}
-
-type readParams struct {
-	URI string `json:"uri"`
-}
-
-// ServeHTTP handles HTTP requests for HTTP.
+// ServeHTTP implements the Streamable HTTP transport: POST carries messages, GET opens an SSE stream and DELETE ends a session.
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodPost, http.MethodGet, http.MethodDelete:
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -81,189 +84,183 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Not found", http.StatusNotFound)
 		return
 	}
-	if !h.authorize(r) {
-		h.writeError(w, nil, -32001, "Unauthorized")
-		return
-	}
-	var req request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, nil, -32700, "Invalid JSON")
+	if !allowedOrigin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
-	if req.JSONRPC != "2.0" {
-		h.writeError(w, req.ID, -32600, "Invalid JSON-RPC version")
+	caller, ok := h.authenticate(r)
+	if !ok {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.writeError(w, nil, -32001, "Unauthorized")
 		return
 	}
-	if h.cfg.ReadOnly && !isReadMethod(req.Method) {
-		h.writeError(w, req.ID, -32601, "Method not allowed")
+	if version := strings.TrimSpace(r.Header.Get(versionHeader)); version != "" && !isSupportedVersion(version) {
+		http.Error(w, "Unsupported MCP protocol version", http.StatusBadRequest)
 		return
 	}
-	switch req.Method {
-	case "initialize":
-		h.writeResult(w, req.ID, map[string]interface{}{
-			"protocolVersion": protocolVersion,
-			"serverInfo": map[string]string{
-				"name":    "pin",
-				"version": "1.0",
-			},
-			"capabilities": map[string]interface{}{
-				"resources": map[string]interface{}{},
-			},
-		})
-	case "resources/list":
-		resources, err := h.listIdentityResources(r)
-		if err != nil {
-			h.writeError(w, req.ID, -32000, "Failed to list resources")
-			return
-		}
-		h.writeResult(w, req.ID, map[string]interface{}{
-			"resources": resources,
-		})
-	case "resources/read":
-		var params readParams
-		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.URI) == "" {
-			h.writeError(w, req.ID, -32602, "Invalid params")
+	var sess *session
+	if id := strings.TrimSpace(r.Header.Get(sessionHeader)); id != "" {
+		found, ok := h.sessions.get(id, time.Now())
+		// Sessions belong to the credential that initialized them.
+		if !ok || found.owner != caller.key() {
+			http.Error(w, "Session not found", http.StatusNotFound)
 			return
 		}
-		contents, err := h.readIdentityResource(r, params.URI)
-		if err != nil {
-			h.writeError(w, req.ID, -32004, err.Error())
+		sess = found
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.serveStream(w, r, sess, caller)
+	case http.MethodDelete:
+		if sess == nil {
+			http.Error(w, "Missing session", http.StatusBadRequest)
 			return
 		}
-		h.writeResult(w, req.ID, map[string]interface{}{
-			"contents": contents,
-		})
+		h.sessions.remove(sess.id)
+		w.WriteHeader(http.StatusNoContent)
 	default:
-		h.writeError(w, req.ID, -32601, "Method not found")
+		h.servePost(w, r, sess, caller)
 	}
 }
 
-// listIdentityResources returns the identity resources list.
-func (h Handler) listIdentityResources(r *http.Request) ([]resource, error) {
-	users, err := h.deps.ListIdentities(r.Context())
+// servePost handles a single message or a batch and replies with JSON, or 202 when nothing needs an answer.
+func (h Handler) servePost(w http.ResponseWriter, r *http.Request, sess *session, caller principal) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
 	if err != nil {
-		return nil, err
-	}
-	var resources []resource
-	for _, user := range users {
-		if user.Handle == "" {
-			continue
-		}
-		base := "identity://" + user.Handle
-		resources = append(resources, resource{
-			URI:         base,
-			Name:        user.Handle,
-			Description: "Identity export for " + user.Handle,
-			MimeType:    "application/json",
-		})
-		resources = append(resources, resource{
-			URI:         base + "/profile-picture",
-			Name:        user.Handle + " profile picture",
-			Description: "Active profile picture for " + user.Handle,
-			MimeType:    "application/json",
-		})
+		h.writeError(w, nil, -32700, "Invalid JSON")
+		return
 	}
-	return resources, nil
-}
-
-// readIdentityResource resolves an identity URI into JSON-RPC resource contents.
-func (h Handler) readIdentityResource(r *http.Request, uri string) ([]map[string]interface{}, error) {
-	target, err := parseIdentityURI(uri)
+	messages, batch, err := splitBatch(body)
 	if err != nil {
-		return nil, err
+		h.writeError(w, nil, -32700, "Invalid JSON")
+		return
 	}
-	user, err := h.deps.GetIdentityByHandle(r.Context(), target.Ident)
-	if err != nil || !identity.MatchesIdentity(user, target.Ident) {
-		return nil, errors.New("Identity not found")
+	if batch && len(messages) == 0 {
+		h.writeError(w, nil, -32600, "Empty batch")
+		return
 	}
-	if target.ProfilePicture {
-		payload := map[string]string{
-			"url": h.profilePictureURL(r, user),
-			"alt": profilepicture.NewService(h.deps).ActiveAlt(r.Context(), user),
+	ex := &exchange{baseURL: h.deps.BaseURL(r), session: sess, sessions: h.sessions, principal: caller}
+	var responses []*response
+	for _, raw := range messages {
+		if resp := h.handleMessage(r.Context(), ex, raw); resp != nil {
+			responses = append(responses, resp)
 		}
-		raw, _ := json.Marshal(payload)
-		return []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     string(raw),
-			},
-		}, nil
 	}
-	publicUser, customFields := identity.VisibleIdentity(user, false)
-	handler := export.NewHandler(source{deps: h.deps})
-	payload, err := handler.BuildPINC(r.Context(), r, publicUser, customFields, "public", "")
-	if err != nil {
-		return nil, errors.New("Failed to load identity")
+	if ex.session != nil && sess == nil {
+		w.Header().Set(sessionHeader, ex.session.id)
 	}
-	raw, _ := json.Marshal(payload)
-	return []map[string]interface{}{
-		{
-			"uri":      uri,
-			"mimeType": "application/json",
-			"text":     string(raw),
-		},
-	}, nil
-}
-
-type identityResourceTarget struct {
-	Ident          string
-	ProfilePicture bool
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if batch {
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(responses[0])
 }
 
-// parseIdentityURI validates and parses an identity:// URI.
-func parseIdentityURI(uri string) (identityResourceTarget, error) {
-	parsed, err := url.Parse(uri)
-	if err != nil {
-		return identityResourceTarget{}, errors.New("Invalid resource URI")
+// serveStream opens the session's SSE stream and pushes resource update notifications until the client leaves.
+func (h Handler) serveStream(w http.ResponseWriter, r *http.Request, sess *session, caller principal) {
+	if !strings.Contains(r.Header.Get("Accept"), eventStreamType) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-	if parsed.Scheme != "identity" {
-		return identityResourceTarget{}, errors.New("Unsupported resource URI")
+	if sess == nil {
+		http.Error(w, "Missing session", http.StatusBadRequest)
+		return
 	}
-	ident := strings.Trim(parsed.Host+parsed.Path, "/")
-	if ident == "" {
-		return identityResourceTarget{}, errors.New("Invalid resource URI")
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
 	}
-	if strings.HasSuffix(strings.ToLower(ident), "/profile-picture") {
-		name := strings.TrimSuffix(ident, "/profile-picture")
-		return identityResourceTarget{Ident: name, ProfilePicture: true}, nil
+	if !sess.openStream() {
+		http.Error(w, "Stream already open", http.StatusConflict)
+		return
+	}
+	defer sess.closeStream()
+
+	w.Header().Set("Content-Type", eventStreamType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	baseURL := h.deps.BaseURL(r)
+	poll := time.NewTicker(h.pollInterval())
+	defer poll.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-poll.C:
+			for _, note := range h.pollSubscriptions(r.Context(), baseURL, caller, sess) {
+				raw, _ := json.Marshal(note)
+				if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
 	}
-	return identityResourceTarget{Ident: ident}, nil
 }
 
-// profilePictureURL returns the public profile picture URL for a user.
-func (h Handler) profilePictureURL(r *http.Request, user domain.Identity) string {
-	return h.deps.BaseURL(r) + "/" + url.PathEscape(user.Handle) + "/profile-picture"
+// pollInterval returns the configured subscription poll interval.
+func (h Handler) pollInterval() time.Duration {
+	if h.cfg.PollInterval > 0 {
+		return h.cfg.PollInterval
+	}
+	return defaultPollInterval
 }
 
-// writeResult writes result to the response/output.
-func (h Handler) writeResult(w http.ResponseWriter, id interface{}, result interface{}) {
-	resp := response{JSONRPC: "2.0", ID: id, Result: result}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_ = json.NewEncoder(w).Encode(resp)
+// splitBatch returns the messages in a POST body and whether it was a batch.
+func splitBatch(body []byte) ([]json.RawMessage, bool, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, false, errors.New("empty body")
+	}
+	if trimmed[0] != '[' {
+		if !json.Valid(trimmed) {
+			return nil, false, errors.New("invalid json")
+		}
+		return []json.RawMessage{trimmed}, false, nil
+	}
+	var messages []json.RawMessage
+	if err := json.Unmarshal(trimmed, &messages); err != nil {
+		return nil, true, err
+	}
+	return messages, true, nil
 }
 
 // writeError writes error to the response/output.
-func (h Handler) writeError(w http.ResponseWriter, id interface{}, code int, message string) {
+func (h Handler) writeError(w http.ResponseWriter, id json.RawMessage, code int, message string) {
 	resp := response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// authorize validates the request token against configured credentials.
-func (h Handler) authorize(r *http.Request) bool {
-	if strings.TrimSpace(h.cfg.Token) == "" {
+// allowedOrigin rejects browser requests from other origins, guarding against DNS rebinding.
+func allowedOrigin(r *http.Request) bool {
+	origin := strings.TrimSpace(r.Header.Get("Origin"))
+	if origin == "" {
 		return true
 	}
-	auth := strings.TrimSpace(r.Header.Get("Authorization"))
-	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
-		token := strings.TrimSpace(auth[7:])
-		return subtleCompare(token, h.cfg.Token)
-	}
-	if token := strings.TrimSpace(r.Header.Get("X-MCP-Token")); token != "" {
-		return subtleCompare(token, h.cfg.Token)
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
 	}
-	return false
+	return strings.EqualFold(parsed.Host, r.Host)
 }
 
 // subtleCompare performs a constant-time string comparison.
@@ -271,16 +268,6 @@ func subtleCompare(a, b string) bool {
 	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
-// isReadMethod reports whether read method is true.
-func isReadMethod(method string) bool {
-	switch method {
-	case "initialize", "resources/list", "resources/read":
-		return true
-	default:
-		return false
-	}
-}
-
 type source struct {
 	deps Dependencies
 }
@@ -300,7 +287,21 @@ func (s source) ActiveProfilePictureAlt(ctx context.Context, user domain.Identit
 	return profilepicture.NewService(s.deps).ActiveAlt(ctx, user)
 }
 
+// DomainVerifications returns the identity's domain verification rows.
+func (s source) DomainVerifications(ctx context.Context, user domain.Identity) []domain.DomainVerification {
+	rows, err := s.deps.ListDomainVerifications(ctx, user.ID)
+	if err != nil {
+		return nil
+	}
+	return rows
+}
+
 // BaseURL returns the base URL.
 func (s source) BaseURL(r *http.Request) string {
 	return s.deps.BaseURL(r)
 }
+
+// PINCSigningKey returns the node key used to sign PINC exports.
+func (s source) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	return s.deps.PINCSigningKey(ctx)
+}
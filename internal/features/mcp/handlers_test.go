@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -47,3 +48,129 @@ func TestServeHTTPUnauthorized(t *testing.T) {
 		t.Fatalf("expected unauthorized error code, got %+v", resp.Error)
 	}
 }
+
+// post sends a JSON-RPC body to a handler without dependencies.
+func post(t *testing.T, handler Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestInitializeNegotiatesVersionAndSession verifies version negotiation and session issuance.
+func TestInitializeNegotiatesVersionAndSession(t *testing.T) {
+	handler := NewHandler(Config{Enabled: true}, baseURLDeps{})
+	cases := map[string]string{"2024-11-05": "2024-11-05", "2025-03-26": "2025-03-26", "1999-01-01": supportedVersions[0]}
+	for requested, expected := range cases {
+		rec := post(t, handler, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+requested+`"}}`, nil)
+		var resp struct {
+			Result struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid json response: %v", err)
+		}
+		if resp.Result.ProtocolVersion != expected {
+			t.Fatalf("requested %s: expected %s, got %s", requested, expected, resp.Result.ProtocolVersion)
+		}
+		if rec.Header().Get(sessionHeader) == "" {
+			t.Fatalf("expected a session id header")
+		}
+	}
+	if rec := post(t, handler, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, map[string]string{versionHeader: "1999-01-01"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported protocol header, got %d", rec.Code)
+	}
+	if rec := post(t, handler, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, map[string]string{sessionHeader: "missing"}); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", rec.Code)
+	}
+}
+
+// TestBatchesAndNotifications verifies batch responses skip notifications and notification-only posts get 202.
+func TestBatchesAndNotifications(t *testing.T) {
+	handler := NewHandler(Config{Enabled: true, ReadOnly: true}, baseURLDeps{})
+	if rec := post(t, handler, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, nil); rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Fatalf("expected empty 202 for a notification, got %d %q", rec.Code, rec.Body.String())
+	}
+	rec := post(t, handler, `[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":"two","method":"resources/templates/list"},
+		{"jsonrpc":"2.0","id":3,"method":"tools/call"}
+	]`, nil)
+	var resps []response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil {
+		t.Fatalf("invalid batch response: %v (%s)", err, rec.Body.String())
+	}
+	if len(resps) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(resps))
+	}
+	if string(resps[1].ID) != `"two"` || resps[1].Error != nil {
+		t.Fatalf("unexpected templates response: %+v", resps[1])
+	}
+	if !strings.Contains(rec.Body.String(), "identity://{handle}/profile-picture") {
+		t.Fatalf("expected profile picture template, got %s", rec.Body.String())
+	}
+	if resps[2].Error == nil || resps[2].Error.Code != -32601 {
+		t.Fatalf("expected tools/call to be rejected in read-only mode, got %+v", resps[2])
+	}
+	if rec := post(t, handler, `[]`, nil); !strings.Contains(rec.Body.String(), "-32600") {
+		t.Fatalf("expected invalid request for an empty batch, got %s", rec.Body.String())
+	}
+}
+
+// TestSubscribeRequiresSession verifies subscriptions are refused outside an initialized session.
+func TestSubscribeRequiresSession(t *testing.T) {
+	handler := NewHandler(Config{Enabled: true}, baseURLDeps{})
+	rec := post(t, handler, `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"identity://alice"}}`, nil)
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid json response: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != -32600 {
+		t.Fatalf("expected invalid request without a session, got %+v", resp)
+	}
+}
+
+// baseURLDeps satisfies the methods the transport touches before any store access.
+type baseURLDeps struct {
+	Dependencies
+}
+
+// BaseURL returns a fixed base URL.
+func (baseURLDeps) BaseURL(r *http.Request) string {
+	return "https://pin.test"
+}
+
+// TestServeStdio verifies stdio shares dispatch with HTTP: one reply per line, none for notifications.
+func TestServeStdio(t *testing.T) {
+	handler := NewHandler(Config{Enabled: true, ReadOnly: true}, baseURLDeps{})
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`[{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","id":3,"method":"tools/list"}]`,
+		`not json`,
+	}, "\n"))
+	var out strings.Builder
+	if err := handler.ServeStdio(context.Background(), in, &out, "https://pin.test"); err != nil {
+		t.Fatalf("serve stdio: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 output lines, got %d: %s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"protocolVersion":"2024-11-05"`) {
+		t.Fatalf("unexpected initialize reply: %s", lines[0])
+	}
+	var batch []response
+	if err := json.Unmarshal([]byte(lines[1]), &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("expected a 2-element batch reply, got %s", lines[1])
+	}
+	if !strings.Contains(lines[2], "-32700") {
+		t.Fatalf("expected parse error, got %s", lines[2])
+	}
+}
@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+)
+
+// Credential kinds an MCP exchange can act for.
+const (
+	credentialAnonymous = "anonymous"
+	credentialGlobal    = "global"
+	credentialToken     = "token"
+	credentialLocal     = "local"
+)
+
+// principal is the credential an MCP exchange acts for.
+type principal struct {
+	kind      string
+	userID    int
+	tokenID   int
+	tokenName string
+	scopes    []string
+	// all lets the credential list and address every identity rather than only its owner's.
+	all bool
+}
+
+// localPrincipal is the operator running the stdio transport, who already holds the database.
+func localPrincipal() principal {
+	return principal{kind: credentialLocal, all: true}
+}
+
+// key identifies the credential so a session cannot be picked up by another one.
+func (p principal) key() string {
+	if p.kind == credentialToken {
+		return credentialToken + ":" + strconv.Itoa(p.tokenID)
+	}
+	return p.kind
+}
+
+// has reports whether the credential grants scope. The global token keeps its
+// write access but never sees private views.
+func (p principal) has(scope string) bool {
+	switch p.kind {
+	case credentialLocal:
+		return true
+	case credentialGlobal:
+		return scope != apitokens.ScopeIdentityReadPrivate
+	case credentialToken:
+		return apitokens.HasScope(p.scopes, scope)
+	default:
+		return false
+	}
+}
+
+// canAccess reports whether the credential may address the identity at all.
+func (p principal) canAccess(record domain.Identity) bool {
+	return p.all || (p.userID > 0 && record.UserID == p.userID)
+}
+
+// canReadPrivate reports whether the credential may read the identity's private view.
+func (p principal) canReadPrivate(record domain.Identity) bool {
+	return p.canAccess(record) && p.has(apitokens.ScopeIdentityReadPrivate)
+}
+
+// auditMeta describes the credential for audit log entries.
+func (p principal) auditMeta() map[string]string {
+	meta := map[string]string{"via": "mcp", "credential": p.kind}
+	if p.kind == credentialToken {
+		meta["token"] = p.tokenName
+		meta["token_id"] = strconv.Itoa(p.tokenID)
+	}
+	return meta
+}
+
+// authenticate resolves the request's credential. Personal API tokens need the mcp
+// scope; anything else must match PIN_MCP_TOKEN when one is configured.
+func (h Handler) authenticate(r *http.Request) (principal, bool) {
+	token := presentedToken(r)
+	if strings.HasPrefix(token, apitokens.TokenPrefix) {
+		return h.authenticateAPIToken(r.Context(), token)
+	}
+	if strings.TrimSpace(h.cfg.Token) == "" {
+		if token != "" {
+			return principal{}, false
+		}
+		return principal{kind: credentialAnonymous, all: true}, true
+	}
+	if token == "" || !subtleCompare(token, h.cfg.Token) {
+		return principal{}, false
+	}
+	return principal{kind: credentialGlobal, all: true}, true
+}
+
+// authenticateAPIToken binds a personal API token to its owner, widening it to every identity for admins.
+func (h Handler) authenticateAPIToken(ctx context.Context, raw string) (principal, bool) {
+	token, err := apitokens.NewService(h.deps).Authenticate(ctx, raw, apitokens.ScopeMCP, "mcp.connect", "/mcp")
+	if err != nil {
+		return principal{}, false
+	}
+	p := principal{
+		kind:      credentialToken,
+		userID:    token.UserID,
+		tokenID:   token.ID,
+		tokenName: token.Name,
+		scopes:    token.Scopes,
+	}
+	if user, err := h.deps.GetUserByID(ctx, token.UserID); err == nil {
+		p.all = strings.EqualFold(user.Role, "admin") || strings.EqualFold(user.Role, "owner")
+	}
+	return p, true
+}
+
+// presentedToken returns the bearer or X-MCP-Token credential, if any.
+func presentedToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-MCP-Token"))
+}
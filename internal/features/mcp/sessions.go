@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"pin/internal/platform/core"
+)
+
+// sessionIdleTimeout is how long an unused session is kept before it is dropped.
+const sessionIdleTimeout = time.Hour
+
+// maxSessionsPerOwner caps the open sessions of one credential; all anonymous callers share a cap.
+const maxSessionsPerOwner = 16
+
+// maxSubscriptions caps the resources one session may subscribe to, since each is re-rendered
+// on every poll.
+const maxSubscriptions = 32
+
+// errTooManySessions is returned when a credential already has maxSessionsPerOwner streaming sessions.
+var errTooManySessions = errors.New("too many open MCP sessions")
+
+// session is one initialized MCP client and the resources it subscribed to.
+type session struct {
+	id        string
+	owner     string
+	mu        sync.Mutex
+	subs      map[string]string
+	streaming bool
+	lastSeen  time.Time
+}
+
+// subscribe starts tracking uri from its current rev, reporting false when the session already
+// has maxSubscriptions other subscriptions.
+func (s *session) subscribe(uri, rev string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[uri]; !ok && len(s.subs) >= maxSubscriptions {
+		return false
+	}
+	s.subs[uri] = rev
+	return true
+}
+
+// unsubscribe stops tracking uri.
+func (s *session) unsubscribe(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subs, uri)
+}
+
+// subscriptions returns a snapshot of subscribed URIs and their last seen revs.
+func (s *session) subscriptions() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]string, len(s.subs))
+	for uri, rev := range s.subs {
+		out[uri] = rev
+	}
+	return out
+}
+
+// advance records rev for uri if it is still subscribed at last, reporting whether it did.
+func (s *session) advance(uri, last, rev string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.subs[uri]
+	if !ok || current != last {
+		return false
+	}
+	s.subs[uri] = rev
+	return true
+}
+
+// openStream claims the session's single SSE stream.
+func (s *session) openStream() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streaming {
+		return false
+	}
+	s.streaming = true
+	return true
+}
+
+// closeStream releases the session's SSE stream.
+func (s *session) closeStream() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streaming = false
+	s.lastSeen = time.Now()
+}
+
+// sessionStore keeps the sessions created by initialize in memory.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// newSessionStore constructs an empty session store.
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: map[string]*session{}}
+}
+
+// create starts a session for the owning credential and prunes idle ones. When the owner is at
+// maxSessionsPerOwner, its least recently used session without an open stream is dropped; if
+// every one is streaming, create fails with errTooManySessions.
+func (s *sessionStore) create(owner string) (*session, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owned := 0
+	var oldest *session
+	var oldestSeen time.Time
+	for id, sess := range s.sessions {
+		sess.mu.Lock()
+		streaming, lastSeen := sess.streaming, sess.lastSeen
+		sess.mu.Unlock()
+		if !streaming && now.Sub(lastSeen) > sessionIdleTimeout {
+			delete(s.sessions, id)
+			continue
+		}
+		if sess.owner != owner {
+			continue
+		}
+		owned++
+		if !streaming && (oldest == nil || lastSeen.Before(oldestSeen)) {
+			oldest, oldestSeen = sess, lastSeen
+		}
+	}
+	if owned >= maxSessionsPerOwner {
+		if oldest == nil {
+			return nil, errTooManySessions
+		}
+		delete(s.sessions, oldest.id)
+	}
+	sess := &session{
+		id:       core.RandomTokenURL(24),
+		owner:    owner,
+		subs:     map[string]string{},
+		lastSeen: now,
+	}
+	s.sessions[sess.id] = sess
+	return sess, nil
+}
+
+// get returns the session with id and marks it as used.
+func (s *sessionStore) get(id string, now time.Time) (*session, bool) {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	sess.mu.Lock()
+	sess.lastSeen = now
+	sess.mu.Unlock()
+	return sess, true
+}
+
+// remove ends the session with id.
+func (s *sessionStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
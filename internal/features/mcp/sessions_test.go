@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestSessionStoreCapsPerOwner verifies an owner at the cap loses its least recently used idle
+// session, and cannot open more once every session is streaming, without affecting other owners.
+func TestSessionStoreCapsPerOwner(t *testing.T) {
+	store := newSessionStore()
+	var first *session
+	for i := 0; i < maxSessionsPerOwner; i++ {
+		sess, err := store.create(credentialAnonymous)
+		if err != nil {
+			t.Fatalf("create session %d: %v", i, err)
+		}
+		if first == nil {
+			first = sess
+		}
+	}
+	first.lastSeen = first.lastSeen.Add(-time.Minute)
+	if _, err := store.create(credentialAnonymous); err != nil {
+		t.Fatalf("expected the oldest session to make room, got %v", err)
+	}
+	if _, ok := store.get(first.id, time.Now()); ok {
+		t.Fatalf("expected the oldest session to be dropped")
+	}
+	if len(store.sessions) != maxSessionsPerOwner {
+		t.Fatalf("expected %d sessions, got %d", maxSessionsPerOwner, len(store.sessions))
+	}
+
+	for _, sess := range store.sessions {
+		sess.openStream()
+	}
+	if _, err := store.create(credentialAnonymous); !errors.Is(err, errTooManySessions) {
+		t.Fatalf("expected errTooManySessions, got %v", err)
+	}
+	if _, err := store.create(credentialLocal); err != nil {
+		t.Fatalf("expected another owner to be unaffected, got %v", err)
+	}
+}
+
+// TestSessionSubscriptionCap verifies a session refuses new subscriptions past the cap but still
+// accepts re-subscribing to a resource it tracks.
+func TestSessionSubscriptionCap(t *testing.T) {
+	sess, err := newSessionStore().create(credentialAnonymous)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for i := 0; i < maxSubscriptions; i++ {
+		if !sess.subscribe("identity://user"+strconv.Itoa(i), "rev") {
+			t.Fatalf("expected subscription %d to be accepted", i)
+		}
+	}
+	if sess.subscribe("identity://one-too-many", "rev") {
+		t.Fatalf("expected a subscription past the cap to be refused")
+	}
+	if !sess.subscribe("identity://user0", "rev2") {
+		t.Fatalf("expected re-subscribing to be accepted")
+	}
+}
@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ServeStdio serves newline-delimited JSON-RPC messages from in, writing replies and
+// resource update notifications to out until in is exhausted or ctx ends.
+// The caller owns the process, so it acts as the local operator; the read-only setting still applies.
+func (h Handler) ServeStdio(ctx context.Context, in io.Reader, out io.Writer, baseURL string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// A stdio connection is one client, so its session exists from the start.
+	caller := localPrincipal()
+	sess, err := h.sessions.create(caller.key())
+	if err != nil {
+		return err
+	}
+	ex := &exchange{baseURL: baseURL, session: sess, principal: caller}
+	defer h.sessions.remove(ex.session.id)
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(out)
+	write := func(v interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return encoder.Encode(v)
+	}
+
+	go func() {
+		poll := time.NewTicker(h.pollInterval())
+		defer poll.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-poll.C:
+				for _, note := range h.pollSubscriptions(ctx, baseURL, caller, ex.session) {
+					if write(note) != nil {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBodyBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		messages, batch, err := splitBatch(line)
+		if err != nil || (batch && len(messages) == 0) {
+			rpcErr := &rpcError{Code: -32600, Message: "Empty batch"}
+			if err != nil {
+				rpcErr = &rpcError{Code: -32700, Message: "Invalid JSON"}
+			}
+			if err := write(response{JSONRPC: "2.0", Error: rpcErr}); err != nil {
+				return err
+			}
+			continue
+		}
+		var responses []*response
+		for _, raw := range messages {
+			if resp := h.handleMessage(ctx, ex, raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		switch {
+		case len(responses) == 0:
+			continue
+		case batch:
+			err = write(responses)
+		default:
+			err = write(responses[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,425 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/domains"
+	"pin/internal/features/history"
+	"pin/internal/features/identity"
+	"pin/internal/features/profilepicture"
+	"pin/internal/features/users"
+)
+
+// errInvalidArguments marks tool input that fails validation.
+var errInvalidArguments = errors.New("invalid arguments")
+
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+type callParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type toolHandler func(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error)
+
+// identityFieldNames lists the scalar identity fields update_identity_field can set.
+var identityFieldNames = []string{
+	"handle",
+	"display_name",
+	"email",
+	"bio",
+	"organization",
+	"job_title",
+	"birthdate",
+	"languages",
+	"phone",
+	"address",
+	"location",
+	"website",
+	"pronouns",
+	"timezone",
+	"atproto_handle",
+	"atproto_did",
+}
+
+// tools returns the tool definitions in the order tools/list reports them.
+func tools() []tool {
+	handle := map[string]interface{}{"type": "string", "description": "Handle of the identity to edit."}
+	visibility := map[string]interface{}{"type": "string", "enum": []string{"public", "private"}}
+	return []tool{
+		{
+			Name:        "update_identity_field",
+			Description: "Set a single profile field. An empty value clears it.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"handle": handle,
+				"field":  map[string]interface{}{"type": "string", "enum": identityFieldNames},
+				"value":  map[string]interface{}{"type": "string"},
+			}, "handle", "field", "value"),
+		},
+		{
+			Name:        "add_link",
+			Description: "Append a link to the profile.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"handle":     handle,
+				"label":      map[string]interface{}{"type": "string"},
+				"url":        map[string]interface{}{"type": "string", "format": "uri"},
+				"visibility": visibility,
+			}, "handle", "label", "url"),
+		},
+		{
+			Name:        "set_visibility",
+			Description: "Make a profile field public or private. Fields are profile field names or existing keys such as link:0 or custom:key.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"handle":     handle,
+				"field":      map[string]interface{}{"type": "string"},
+				"visibility": visibility,
+			}, "handle", "field", "visibility"),
+		},
+		{
+			Name:        "select_profile_picture",
+			Description: "Make an uploaded profile picture the active one.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"handle":     handle,
+				"picture_id": map[string]interface{}{"type": "integer", "minimum": 1},
+			}, "handle", "picture_id"),
+		},
+		{
+			Name:        "request_domain_verification",
+			Description: "Add a domain to verify and return the token to publish at /.well-known/pin-verify or in a _pin-verify DNS TXT record.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"handle": handle,
+				"domain": map[string]interface{}{"type": "string"},
+			}, "handle", "domain"),
+		},
+	}
+}
+
+// toolScopes lists the API token scope each tool needs, matching the settings page it stands in for.
+var toolScopes = map[string]string{
+	"update_identity_field":       apitokens.ScopeIdentityWrite,
+	"add_link":                    apitokens.ScopeIdentityWrite,
+	"set_visibility":              apitokens.ScopeIdentityWrite,
+	"select_profile_picture":      apitokens.ScopePicturesWrite,
+	"request_domain_verification": apitokens.ScopeDomainsWrite,
+}
+
+// toolHandlers maps tool names to their implementations.
+var toolHandlers = map[string]toolHandler{
+	"update_identity_field":       updateIdentityField,
+	"add_link":                    addLink,
+	"set_visibility":              setVisibility,
+	"select_profile_picture":      selectProfilePicture,
+	"request_domain_verification": requestDomainVerification,
+}
+
+// objectSchema builds a closed JSON schema object.
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// callTool runs a tool against the identity named in its arguments and audits the call.
+func (h Handler) callTool(ctx context.Context, baseURL string, p principal, params callParams) (map[string]interface{}, *rpcError) {
+	run, ok := toolHandlers[params.Name]
+	if !ok {
+		return nil, &rpcError{Code: -32602, Message: "Unknown tool"}
+	}
+	var target struct {
+		Handle string `json:"handle"`
+	}
+	if len(params.Arguments) == 0 || json.Unmarshal(params.Arguments, &target) != nil || strings.TrimSpace(target.Handle) == "" {
+		return nil, &rpcError{Code: -32602, Message: "Invalid params"}
+	}
+	record, err := h.deps.GetIdentityByHandle(ctx, target.Handle)
+	if err != nil || !identity.MatchesIdentity(record, target.Handle) || !p.canAccess(record) {
+		return toolResult("Identity not found", true), nil
+	}
+	action := "mcp." + params.Name
+	meta := p.auditMeta()
+	h.deps.AuditAttempt(ctx, p.userID, action, record.Handle, meta)
+	if !p.has(toolScopes[params.Name]) {
+		err := fmt.Errorf("%w: %s", apitokens.ErrInsufficientScope, toolScopes[params.Name])
+		h.deps.AuditOutcome(ctx, p.userID, action, record.Handle, err, meta)
+		return toolResult(err.Error(), true), nil
+	}
+	text, err := run(h, ctx, baseURL, p.userID, record, params.Arguments)
+	h.deps.AuditOutcome(ctx, p.userID, action, record.Handle, err, meta)
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	return toolResult(text, false), nil
+}
+
+// toolResult wraps text in an MCP tool result.
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// decodeArguments strictly decodes tool arguments into dst.
+func decodeArguments(args json.RawMessage, dst interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(string(args)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return errInvalidArguments
+	}
+	return nil
+}
+
+// save records an edited identity through the revision history, attributed to the calling
+// principal's user; shared-token and local callers have none.
+func (h Handler) save(ctx context.Context, baseURL string, actorID int, record domain.Identity) error {
+	return history.NewService(h.deps).Save(ctx, baseURL, actorID, record)
+}
+
+// updateIdentityField sets one scalar field, validating handle changes like the profile form.
+func updateIdentityField(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error) {
+	var input struct {
+		Handle string `json:"handle"`
+		Field  string `json:"field"`
+		Value  string `json:"value"`
+	}
+	if err := decodeArguments(args, &input); err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(input.Value)
+	field := identityField(&record, input.Field)
+	if field == nil {
+		return "", errors.New("unknown field " + strconv.Quote(input.Field))
+	}
+	if input.Field == "handle" {
+		if err := identity.ValidateHandle(ctx, value, record.ID, h.deps.Reserved(), h.deps.CheckHandleCollision); err != nil {
+			return "", err
+		}
+	}
+	*field = value
+	if err := h.save(ctx, baseURL, actorID, record); err != nil {
+		return "", err
+	}
+	return "Updated " + input.Field + ".", nil
+}
+
+// identityField returns a pointer to the named scalar field, or nil when unknown.
+func identityField(record *domain.Identity, name string) *string {
+	switch name {
+	case "handle":
+		return &record.Handle
+	case "display_name":
+		return &record.DisplayName
+	case "email":
+		return &record.Email
+	case "bio":
+		return &record.Bio
+	case "organization":
+		return &record.Organization
+	case "job_title":
+		return &record.JobTitle
+	case "birthdate":
+		return &record.Birthdate
+	case "languages":
+		return &record.Languages
+	case "phone":
+		return &record.Phone
+	case "address":
+		return &record.Address
+	case "location":
+		return &record.Location
+	case "website":
+		return &record.Website
+	case "pronouns":
+		return &record.Pronouns
+	case "timezone":
+		return &record.Timezone
+	case "atproto_handle":
+		return &record.ATProtoHandle
+	case "atproto_did":
+		return &record.ATProtoDID
+	default:
+		return nil
+	}
+}
+
+// addLink appends a link, keeping the visibility of existing links.
+func addLink(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error) {
+	var input struct {
+		Handle     string `json:"handle"`
+		Label      string `json:"label"`
+		URL        string `json:"url"`
+		Visibility string `json:"visibility"`
+	}
+	if err := decodeArguments(args, &input); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(input.Label) == "" || strings.TrimSpace(input.URL) == "" {
+		return "", errors.New("label and url are required")
+	}
+	if parsed, err := url.Parse(strings.TrimSpace(input.URL)); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", errors.New("url must be absolute")
+	}
+	visibility := identity.DecodeVisibilityMap(record.VisibilityJSON)
+	existing := identity.DecodeLinks(record.LinksJSON)
+	var labels, urls, visibilities []string
+	for i, link := range existing {
+		labels = append(labels, link.Label)
+		urls = append(urls, link.URL)
+		visibilities = append(visibilities, visibility[identity.LinkVisibilityKey(i)])
+	}
+	labels = append(labels, input.Label)
+	urls = append(urls, input.URL)
+	visibilities = append(visibilities, input.Visibility)
+	links, linkVisibility := users.ParseLinksForm(labels, urls, visibilities)
+	links = identity.MergeLinks(links, existing)
+	for key := range visibility {
+		if strings.HasPrefix(key, "link:") {
+			delete(visibility, key)
+		}
+	}
+	for key, value := range linkVisibility {
+		visibility[key] = value
+	}
+	record.LinksJSON = identity.EncodeLinks(links)
+	record.VisibilityJSON = identity.EncodeVisibilityMap(visibility)
+	if err := h.save(ctx, baseURL, actorID, record); err != nil {
+		return "", err
+	}
+	return "Added link " + strconv.Quote(strings.TrimSpace(input.Label)) + ".", nil
+}
+
+// setVisibility changes the visibility of a profile field or an existing visibility key.
+func setVisibility(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error) {
+	var input struct {
+		Handle     string `json:"handle"`
+		Field      string `json:"field"`
+		Visibility string `json:"visibility"`
+	}
+	if err := decodeArguments(args, &input); err != nil {
+		return "", err
+	}
+	if input.Visibility != "public" && input.Visibility != "private" {
+		return "", errors.New("visibility must be public or private")
+	}
+	field := strings.TrimSpace(input.Field)
+	visibility := identity.DecodeVisibilityMap(record.VisibilityJSON)
+	if _, ok := visibility[field]; !ok && !isProfileVisibilityField(field) {
+		keys := append([]string{}, users.ProfileVisibilityFields...)
+		for key := range visibility {
+			if !isProfileVisibilityField(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys[len(users.ProfileVisibilityFields):])
+		return "", errors.New("unknown field " + strconv.Quote(field) + "; expected one of " + strings.Join(keys, ", "))
+	}
+	visibility[field] = input.Visibility
+	record.VisibilityJSON = identity.EncodeVisibilityMap(visibility)
+	if err := h.save(ctx, baseURL, actorID, record); err != nil {
+		return "", err
+	}
+	return "Set " + field + " to " + input.Visibility + ".", nil
+}
+
+// isProfileVisibilityField reports whether field has a fixed visibility setting on the profile form.
+func isProfileVisibilityField(field string) bool {
+	for _, name := range users.ProfileVisibilityFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// selectProfilePicture activates one of the identity's uploaded pictures.
+func selectProfilePicture(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error) {
+	var input struct {
+		Handle    string `json:"handle"`
+		PictureID int64  `json:"picture_id"`
+	}
+	if err := decodeArguments(args, &input); err != nil {
+		return "", err
+	}
+	svc := profilepicture.NewService(h.deps)
+	pictures, err := svc.List(ctx, record.ID)
+	if err != nil {
+		return "", err
+	}
+	found := false
+	for _, picture := range pictures {
+		if picture.ID == input.PictureID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", errors.New("profile picture not found")
+	}
+	if err := svc.Select(ctx, record.ID, input.PictureID); err != nil {
+		return "", err
+	}
+	return "Selected profile picture " + strconv.FormatInt(input.PictureID, 10) + ".", nil
+}
+
+// requestDomainVerification adds a domain alongside the existing ones and returns its token.
+func requestDomainVerification(h Handler, ctx context.Context, baseURL string, actorID int, record domain.Identity, args json.RawMessage) (string, error) {
+	var input struct {
+		Handle string `json:"handle"`
+		Domain string `json:"domain"`
+	}
+	if err := decodeArguments(args, &input); err != nil {
+		return "", err
+	}
+	requested := users.ParseVerifiedDomainsText(input.Domain)
+	if len(requested) != 1 {
+		return "", errors.New("exactly one domain is required")
+	}
+	existing, err := h.deps.ListDomainVerifications(ctx, record.ID)
+	if err != nil {
+		return "", err
+	}
+	// CreateDomains syncs to the full list, so keep every domain already on file.
+	list := make([]string, 0, len(existing)+1)
+	for _, row := range existing {
+		list = append(list, row.Domain)
+	}
+	list = append(list, requested[0])
+	svc := domains.NewService(h.deps, h.deps.HTTPClient())
+	rows, verified, err := svc.CreateDomains(ctx, record.ID, list, func() string {
+		return domains.RandomTokenURL(12)
+	})
+	if err != nil {
+		return "", err
+	}
+	record.VerifiedDomainsJSON = identity.EncodeStringSlice(verified)
+	if err := h.save(ctx, baseURL, actorID, record); err != nil {
+		return "", err
+	}
+	name := strings.ToLower(requested[0])
+	for _, row := range rows {
+		if row.Domain != name {
+			continue
+		}
+		if row.VerifiedAt.Valid {
+			return name + " is already verified.", nil
+		}
+		return "Publish this token at https://" + name + "/.well-known/pin-verify, or as a TXT record _pin-verify." + name + " \"pin-verify=" + row.Token + "\", then verify the domain from the profile settings: " + row.Token, nil
+	}
+	return "", errors.New("domain could not be added")
+}
@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// githubProvider verifies GitHub accounts.
+type githubProvider struct {
+	clientID     string
+	clientSecret string
+}
+
+// Name returns the provider key.
+func (githubProvider) Name() string { return "github" }
+
+// Label returns the display label.
+func (githubProvider) Label() string { return "GitHub" }
+
+// AuthorizeURL returns GitHub's consent URL requesting read access to the user profile.
+func (p githubProvider) AuthorizeURL(ctx context.Context, client *http.Client, redirectURI, state string) (string, error) {
+	params := url.Values{}
+	params.Set("client_id", p.clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", "read:user")
+	params.Set("state", state)
+	return authorizeURL("https://github.com/login/oauth/authorize", params)
+}
+
+// Exchange exchanges an OAuth code for a GitHub access token.
+func (p githubProvider) Exchange(ctx context.Context, client *http.Client, redirectURI, code string) (string, error) {
+	payload := url.Values{}
+	payload.Set("client_id", p.clientID)
+	payload.Set("client_secret", p.clientSecret)
+	payload.Set("code", code)
+	payload.Set("redirect_uri", redirectURI)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://github.com/login/oauth/access_token", strings.NewReader(payload.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return postTokenRequest(client, req)
+}
+
+// FetchProfile returns the GitHub login and profile URL for a token.
+func (githubProvider) FetchProfile(ctx context.Context, client *http.Client, token string) (Profile, error) {
+	var data struct {
+		Login string `json:"login"`
+		HTML  string `json:"html_url"`
+	}
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if err := getJSON(ctx, client, "https://api.github.com/user", token, header, &data); err != nil {
+		return Profile{}, err
+	}
+	if data.Login == "" || data.HTML == "" {
+		return Profile{}, errors.New("missing profile data")
+	}
+	return Profile{Username: data.Login, URL: data.HTML}, nil
+}
+
+// ProfileURL returns the profile URL GitHub reported.
+func (githubProvider) ProfileURL(profile Profile) string {
+	if profile.URL != "" {
+		return profile.URL
+	}
+	return "https://github.com/" + url.PathEscape(profile.Username)
+}
+
+// redditProvider verifies Reddit accounts. Reddit requires a descriptive User-Agent on every
+// API call.
+type redditProvider struct {
+	clientID     string
+	clientSecret string
+	userAgent    string
+}
+
+// Name returns the provider key.
+func (redditProvider) Name() string { return "reddit" }
+
+// Label returns the display label.
+func (redditProvider) Label() string { return "Reddit" }
+
+// AuthorizeURL returns Reddit's consent URL requesting the identity scope.
+func (p redditProvider) AuthorizeURL(ctx context.Context, client *http.Client, redirectURI, state string) (string, error) {
+	params := url.Values{}
+	params.Set("client_id", p.clientID)
+	params.Set("response_type", "code")
+	params.Set("state", state)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("duration", "permanent")
+	params.Set("scope", "identity")
+	return authorizeURL("https://www.reddit.com/api/v1/authorize", params)
+}
+
+// Exchange exchanges an OAuth code for a Reddit access token.
+func (p redditProvider) Exchange(ctx context.Context, client *http.Client, redirectURI, code string) (string, error) {
+	payload := url.Values{}
+	payload.Set("grant_type", "authorization_code")
+	payload.Set("code", code)
+	payload.Set("redirect_uri", redirectURI)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://www.reddit.com/api/v1/access_token", strings.NewReader(payload.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.clientID, p.clientSecret)
+	req.Header.Set("User-Agent", p.userAgent)
+	return postTokenRequest(client, req)
+}
+
+// FetchProfile returns the Reddit username for a token.
+func (p redditProvider) FetchProfile(ctx context.Context, client *http.Client, token string) (Profile, error) {
+	var data struct {
+		Name string `json:"name"`
+	}
+	header := http.Header{"User-Agent": {p.userAgent}}
+	if err := getJSON(ctx, client, "https://oauth.reddit.com/api/v1/me", token, header, &data); err != nil {
+		return Profile{}, err
+	}
+	if data.Name == "" {
+		return Profile{}, errors.New("missing profile data")
+	}
+	return Profile{Username: data.Name}, nil
+}
+
+// ProfileURL returns the Reddit user page.
+func (redditProvider) ProfileURL(profile Profile) string {
+	return "https://www.reddit.com/user/" + profile.Username
+}
@@ -0,0 +1,164 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"pin/internal/config"
+)
+
+// GenericProvider verifies accounts on any OAuth2 server with a userinfo endpoint, such as
+// GitLab, Gitea, Forgejo or Codeberg, or any OpenID Connect issuer.
+type GenericProvider struct {
+	cfg config.OAuthProvider
+
+	mu         sync.Mutex
+	discovered bool
+}
+
+// NewGenericProvider constructs a provider from its configuration.
+func NewGenericProvider(cfg config.OAuthProvider) *GenericProvider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.Label == "" {
+		cfg.Label = cfg.Name
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	return &GenericProvider{cfg: cfg}
+}
+
+// Name returns the provider key.
+func (p *GenericProvider) Name() string { return p.cfg.Name }
+
+// Label returns the display label.
+func (p *GenericProvider) Label() string { return p.cfg.Label }
+
+// AuthorizeURL returns the authorization endpoint URL for a code flow with the configured scopes.
+func (p *GenericProvider) AuthorizeURL(ctx context.Context, client *http.Client, redirectURI, state string) (string, error) {
+	endpoints, err := p.endpoints(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("state", state)
+	if len(p.cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	}
+	return authorizeURL(endpoints.AuthURL, params)
+}
+
+// Exchange exchanges an authorization code at the token endpoint, authenticating with the
+// client secret in the form body.
+func (p *GenericProvider) Exchange(ctx context.Context, client *http.Client, redirectURI, code string) (string, error) {
+	endpoints, err := p.endpoints(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	payload := url.Values{}
+	payload.Set("grant_type", "authorization_code")
+	payload.Set("code", code)
+	payload.Set("redirect_uri", redirectURI)
+	payload.Set("client_id", p.cfg.ClientID)
+	if p.cfg.ClientSecret != "" {
+		payload.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoints.TokenURL, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return postTokenRequest(client, req)
+}
+
+// FetchProfile reads the username claim and the "profile" claim from the userinfo endpoint.
+func (p *GenericProvider) FetchProfile(ctx context.Context, client *http.Client, token string) (Profile, error) {
+	endpoints, err := p.endpoints(ctx, client)
+	if err != nil {
+		return Profile{}, err
+	}
+	claims := map[string]interface{}{}
+	header := http.Header{"Accept": {"application/json"}}
+	if err := getJSON(ctx, client, endpoints.UserinfoURL, token, header, &claims); err != nil {
+		return Profile{}, err
+	}
+	username, _ := claims[p.cfg.UsernameClaim].(string)
+	profileURL, _ := claims["profile"].(string)
+	if strings.TrimSpace(username) == "" {
+		return Profile{}, fmt.Errorf("userinfo has no %q claim", p.cfg.UsernameClaim)
+	}
+	profileURL = strings.TrimSpace(profileURL)
+	// Many servers let users edit the claim, so only a URL on the provider's own host counts.
+	if !sameHost(profileURL, endpoints.Issuer, endpoints.AuthURL) {
+		profileURL = ""
+	}
+	return Profile{Username: strings.TrimSpace(username), URL: profileURL}, nil
+}
+
+// ProfileURL fills the configured template with the username, or falls back to the "profile"
+// claim when no template is set. The claim is only kept when it is on the issuer's or the
+// authorization endpoint's host.
+func (p *GenericProvider) ProfileURL(profile Profile) string {
+	if p.cfg.ProfileURL != "" {
+		return strings.ReplaceAll(p.cfg.ProfileURL, "{username}", url.PathEscape(profile.Username))
+	}
+	return profile.URL
+}
+
+// sameHost reports whether rawURL is an http(s) URL on the host of one of candidates.
+func sameHost(rawURL string, candidates ...string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return false
+	}
+	for _, candidate := range candidates {
+		other, err := url.Parse(candidate)
+		if err == nil && other.Host != "" && strings.EqualFold(other.Host, parsed.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// endpoints returns the configured endpoints, filling gaps from the issuer's discovery document
+// on first use. A failed discovery is retried on the next call.
+func (p *GenericProvider) endpoints(ctx context.Context, client *http.Client) (config.OAuthProvider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered || p.cfg.Issuer == "" || (p.cfg.AuthURL != "" && p.cfg.TokenURL != "" && p.cfg.UserinfoURL != "") {
+		return p.cfg, nil
+	}
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := getJSON(ctx, client, p.cfg.Issuer+"/.well-known/openid-configuration", "", nil, &doc); err != nil {
+		return config.OAuthProvider{}, fmt.Errorf("openid discovery: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != p.cfg.Issuer {
+		return config.OAuthProvider{}, errors.New("openid discovery: issuer mismatch")
+	}
+	if p.cfg.AuthURL == "" {
+		p.cfg.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.cfg.TokenURL == "" {
+		p.cfg.TokenURL = doc.TokenEndpoint
+	}
+	if p.cfg.UserinfoURL == "" {
+		p.cfg.UserinfoURL = doc.UserinfoEndpoint
+	}
+	if p.cfg.AuthURL == "" || p.cfg.TokenURL == "" || p.cfg.UserinfoURL == "" {
+		return config.OAuthProvider{}, errors.New("openid discovery: missing endpoints")
+	}
+	p.discovered = true
+	return p.cfg, nil
+}
@@ -10,7 +10,9 @@ import (
 	"strings"
 
 	"github.com/gorilla/sessions"
+	"pin/internal/config"
 	"pin/internal/domain"
+	"pin/internal/features/history"
 	"pin/internal/features/identity"
 	"pin/internal/platform/core"
 )
@@ -23,82 +25,114 @@ type Config struct {
 	RedditClientSecret string
 	RedditUserAgent    string
 	BlueskyPDS         string
+	Providers          []config.OAuthProvider
+}
+
+// NewConfig selects the OAuth settings from the server configuration.
+func NewConfig(cfg config.Config) Config {
+	return Config{
+		BaseURL:            cfg.BaseURL,
+		GitHubClientID:     cfg.GitHubClientID,
+		GitHubClientSecret: cfg.GitHubClientSecret,
+		RedditClientID:     cfg.RedditClientID,
+		RedditClientSecret: cfg.RedditClientSecret,
+		RedditUserAgent:    cfg.RedditUserAgent,
+		BlueskyPDS:         cfg.BlueskyPDS,
+		Providers:          cfg.OAuthProviders,
+	}
 }
 
 type Dependencies interface {
+	history.Store
+	BaseURL(r *http.Request) string
 	GetSession(r *http.Request, name string) (*sessions.Session, error)
 	ValidateCSRF(session *sessions.Session, token string) bool
 	CurrentUser(r *http.Request) (domain.User, error)
 	CurrentIdentity(r *http.Request) (domain.Identity, error)
 	UpdateIdentity(ctx context.Context, identity domain.Identity) error
+	HTTPClient() *http.Client
 	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
 	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
 }
 
 type Handler struct {
-	cfg  Config
-	deps Dependencies
+	cfg       Config
+	deps      Dependencies
+	providers *Registry
 }
 
-// NewHandler constructs a new handler.
+// NewHandler constructs a new handler serving the providers configured in cfg.
 func NewHandler(cfg Config, deps Dependencies) Handler {
-	return Handler{cfg: cfg, deps: deps}
+	return Handler{cfg: cfg, deps: deps, providers: RegistryFromConfig(cfg)}
 }
 
-// GitHubStart redirects to GitHub OAuth with a stored state token.
-func (h Handler) GitHubStart(w http.ResponseWriter, r *http.Request) {
-	if h.cfg.GitHubClientID == "" || h.cfg.GitHubClientSecret == "" || h.cfg.BaseURL == "" {
-		http.Error(w, "GitHub OAuth not configured", http.StatusBadRequest)
+// Start redirects to the provider named in the /oauth/{provider}/start path with a stored
+// state token.
+func (h Handler) Start(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.provider(r, "/start")
+	if !ok {
+		http.NotFound(w, r)
 		return
 	}
 	session, _ := h.deps.GetSession(r, "pin_session")
 	// Bind the authorization request to a session-scoped state token.
 	state := core.RandomToken(16)
-	session.Values["oauth_github_state"] = state
+	session.Values[stateKey(provider)] = state
 	if err := session.Save(r, w); err != nil {
 		http.Error(w, "Session error", http.StatusInternalServerError)
 		return
 	}
 
-	authURL := "https://github.com/login/oauth/authorize"
-	params := url.Values{}
-	params.Set("client_id", h.cfg.GitHubClientID)
-	params.Set("redirect_uri", h.cfg.BaseURL+"/oauth/github/callback")
-	params.Set("scope", "read:user")
-	params.Set("state", state)
-	http.Redirect(w, r, authURL+"?"+params.Encode(), http.StatusFound)
+	authURL, err := provider.AuthorizeURL(r.Context(), h.deps.HTTPClient(), h.redirectURI(provider), state)
+	if err != nil {
+		http.Error(w, provider.Label()+" OAuth unavailable", http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
 }
 
-// GitHubCallback exchanges the code for a token and persists the verified profile.
-func (h Handler) GitHubCallback(w http.ResponseWriter, r *http.Request) {
+// Callback exchanges the code for a token and persists the verified profile.
+func (h Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.provider(r, "/callback")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
 	session, _ := h.deps.GetSession(r, "pin_session")
 	// Validate state to protect against CSRF in the OAuth callback.
-	state, _ := session.Values["oauth_github_state"].(string)
+	state, _ := session.Values[stateKey(provider)].(string)
 	if state == "" || r.URL.Query().Get("state") != state {
 		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
 		return
 	}
+	delete(session.Values, stateKey(provider))
+	_ = session.Save(r, w)
 	code := r.URL.Query().Get("code")
 	if code == "" {
 		http.Error(w, "Missing code", http.StatusBadRequest)
 		return
 	}
 
-	token, err := exchangeGitHubToken(h.cfg, code)
+	token, err := provider.Exchange(r.Context(), h.deps.HTTPClient(), h.redirectURI(provider), code)
 	if err != nil {
-		http.Error(w, "GitHub auth failed", http.StatusBadRequest)
+		http.Error(w, provider.Label()+" auth failed", http.StatusBadRequest)
 		return
 	}
-	_, profileURL, err := fetchGitHubProfile(token)
+	profile, err := provider.FetchProfile(r.Context(), h.deps.HTTPClient(), token)
 	if err != nil {
-		http.Error(w, "GitHub profile fetch failed", http.StatusBadRequest)
+		http.Error(w, provider.Label()+" profile fetch failed", http.StatusBadRequest)
+		return
+	}
+	profileURL := provider.ProfileURL(profile)
+	if parsed, err := url.Parse(profileURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		http.Error(w, provider.Label()+" profile fetch failed", http.StatusBadRequest)
 		return
 	}
 
 	if err := h.addOrUpdateSocialProfile(r, domain.SocialProfile{
-		Label:    "GitHub",
+		Label:    provider.Label(),
 		URL:      profileURL,
-		Provider: "github",
+		Provider: provider.Name(),
 		Verified: true,
 	}); err != nil {
 		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
@@ -108,69 +142,24 @@ func (h Handler) GitHubCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/settings/profile", http.StatusFound)
 }
 
-// RedditStart redirects to Reddit OAuth with a stored state token.
-func (h Handler) RedditStart(w http.ResponseWriter, r *http.Request) {
-	if h.cfg.RedditClientID == "" || h.cfg.RedditClientSecret == "" || h.cfg.BaseURL == "" {
-		http.Error(w, "Reddit OAuth not configured", http.StatusBadRequest)
-		return
-	}
-	session, _ := h.deps.GetSession(r, "pin_session")
-	// Bind the authorization request to a session-scoped state token.
-	state := core.RandomToken(16)
-	session.Values["oauth_reddit_state"] = state
-	if err := session.Save(r, w); err != nil {
-		http.Error(w, "Session error", http.StatusInternalServerError)
-		return
+// provider resolves the provider named by an /oauth/{provider}{suffix} path.
+func (h Handler) provider(r *http.Request, suffix string) (Provider, bool) {
+	rest := strings.TrimPrefix(r.URL.Path, "/oauth/")
+	name, ok := strings.CutSuffix(rest, suffix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return nil, false
 	}
-
-	authURL := "https://www.reddit.com/api/v1/authorize"
-	params := url.Values{}
-	params.Set("client_id", h.cfg.RedditClientID)
-	params.Set("response_type", "code")
-	params.Set("state", state)
-	params.Set("redirect_uri", h.cfg.BaseURL+"/oauth/reddit/callback")
-	params.Set("duration", "permanent")
-	params.Set("scope", "identity")
-	http.Redirect(w, r, authURL+"?"+params.Encode(), http.StatusFound)
+	return h.providers.Lookup(name)
 }
 
-// RedditCallback exchanges the code for a token and persists the verified profile.
-func (h Handler) RedditCallback(w http.ResponseWriter, r *http.Request) {
-	session, _ := h.deps.GetSession(r, "pin_session")
-	// Validate state to protect against CSRF in the OAuth callback.
-	state, _ := session.Values["oauth_reddit_state"].(string)
-	if state == "" || r.URL.Query().Get("state") != state {
-		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
-		return
-	}
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "Missing code", http.StatusBadRequest)
-		return
-	}
-
-	token, err := exchangeRedditToken(h.cfg, code)
-	if err != nil {
-		http.Error(w, "Reddit auth failed", http.StatusBadRequest)
-		return
-	}
-	_, profileURL, err := fetchRedditProfile(token, h.cfg.RedditUserAgent)
-	if err != nil {
-		http.Error(w, "Reddit profile fetch failed", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.addOrUpdateSocialProfile(r, domain.SocialProfile{
-		Label:    "Reddit",
-		URL:      profileURL,
-		Provider: "reddit",
-		Verified: true,
-	}); err != nil {
-		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
-		return
-	}
+// redirectURI returns the callback URL registered with the provider.
+func (h Handler) redirectURI(provider Provider) string {
+	return h.cfg.BaseURL + "/oauth/" + provider.Name() + "/callback"
+}
 
-	http.Redirect(w, r, "/settings/profile", http.StatusFound)
+// stateKey returns the session key holding a provider's pending state token.
+func stateKey(provider Provider) string {
+	return "oauth_" + provider.Name() + "_state"
 }
 
 // BlueskyConnect verifies a Bluesky handle using an app password.
@@ -198,7 +187,7 @@ func (h Handler) BlueskyConnect(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing handle or app password", http.StatusBadRequest)
 		return
 	}
-	did, err := verifyBlueskyHandle(h.cfg.BlueskyPDS, handle, appPassword)
+	did, err := verifyBlueskyHandle(r.Context(), h.deps.HTTPClient(), h.cfg.BlueskyPDS, handle, appPassword)
 	if err != nil {
 		http.Error(w, "Bluesky verification failed", http.StatusBadRequest)
 		return
@@ -220,129 +209,8 @@ func (h Handler) BlueskyConnect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/settings/profile", http.StatusFound)
 }
 
-// exchangeGitHubToken exchanges an OAuth code for a GitHub access token.
-func exchangeGitHubToken(cfg Config, code string) (string, error) {
-	payload := url.Values{}
-	payload.Set("client_id", cfg.GitHubClientID)
-	payload.Set("client_secret", cfg.GitHubClientSecret)
-	payload.Set("code", code)
-	payload.Set("redirect_uri", cfg.BaseURL+"/oauth/github/callback")
-
-	req, err := http.NewRequest(http.MethodPost, "https://github.com/login/oauth/access_token", strings.NewReader(payload.Encode()))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		AccessToken string `json:"access_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", err
-	}
-	if data.AccessToken == "" {
-		return "", errors.New("missing access token")
-	}
-	return data.AccessToken, nil
-}
-
-// fetchGitHubProfile returns the GitHub login and profile URL for a token.
-func fetchGitHubProfile(token string) (string, string, error) {
-	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/user", nil)
-	if err != nil {
-		return "", "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		Login string `json:"login"`
-		HTML  string `json:"html_url"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", "", err
-	}
-	if data.Login == "" || data.HTML == "" {
-		return "", "", errors.New("missing profile data")
-	}
-	return data.Login, data.HTML, nil
-}
-
-// exchangeRedditToken exchanges an OAuth code for a Reddit access token.
-func exchangeRedditToken(cfg Config, code string) (string, error) {
-	payload := url.Values{}
-	payload.Set("grant_type", "authorization_code")
-	payload.Set("code", code)
-	payload.Set("redirect_uri", cfg.BaseURL+"/oauth/reddit/callback")
-
-	req, err := http.NewRequest(http.MethodPost, "https://www.reddit.com/api/v1/access_token", strings.NewReader(payload.Encode()))
-	if err != nil {
-		return "", err
-	}
-	req.SetBasicAuth(cfg.RedditClientID, cfg.RedditClientSecret)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", cfg.RedditUserAgent)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		AccessToken string `json:"access_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", err
-	}
-	if data.AccessToken == "" {
-		return "", errors.New("missing access token")
-	}
-	return data.AccessToken, nil
-}
-
-// fetchRedditProfile returns the Reddit username and profile URL for a token.
-func fetchRedditProfile(token, userAgent string) (string, string, error) {
-	req, err := http.NewRequest(http.MethodGet, "https://oauth.reddit.com/api/v1/me", nil)
-	if err != nil {
-		return "", "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		Name string `json:"name"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", "", err
-	}
-	if data.Name == "" {
-		return "", "", errors.New("missing profile data")
-	}
-	return data.Name, "https://www.reddit.com/user/" + data.Name, nil
-}
-
 // verifyBlueskyHandle verifies a Bluesky handle and returns its DID.
-func verifyBlueskyHandle(pdsURL, handle, appPassword string) (string, error) {
+func verifyBlueskyHandle(ctx context.Context, client *http.Client, pdsURL, handle, appPassword string) (string, error) {
 	body := map[string]string{
 		"identifier": handle,
 		"password":   appPassword,
@@ -351,12 +219,12 @@ func verifyBlueskyHandle(pdsURL, handle, appPassword string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	req, err := http.NewRequest(http.MethodPost, pdsURL+"/xrpc/com.atproto.server.createSession", bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pdsURL+"/xrpc/com.atproto.server.createSession", bytes.NewReader(payload))
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -398,7 +266,7 @@ func (h Handler) updateATProtoProfile(r *http.Request, handle, did string) error
 		target = identityRecord.Handle
 	}
 	h.deps.AuditAttempt(r.Context(), current.ID, "atproto.update", target, nil)
-	err = h.deps.UpdateIdentity(r.Context(), identityRecord)
+	err = history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, identityRecord)
 	h.deps.AuditOutcome(r.Context(), current.ID, "atproto.update", target, err, nil)
 	return err
 }
@@ -442,7 +310,7 @@ func (h Handler) addOrUpdateSocialProfile(r *http.Request, profile domain.Social
 		target = profile.URL
 	}
 	h.deps.AuditAttempt(r.Context(), user.ID, "social.update", target, nil)
-	err = h.deps.UpdateIdentity(r.Context(), identityRecord)
+	err = history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), user.ID, identityRecord)
 	h.deps.AuditOutcome(r.Context(), user.ID, "social.update", target, err, nil)
 	return err
 }
@@ -0,0 +1,85 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"pin/internal/platform/outbound"
+)
+
+// redirectTransport sends every request to a test server whatever host it names.
+type redirectTransport struct {
+	target *url.URL
+}
+
+// RoundTrip rewrites the request's scheme and host to the test server.
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestGitHubExchangeAndProfile verifies the GitHub token exchange and profile fetch through an
+// injected transport.
+func TestGitHubExchangeAndProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			if err := r.ParseForm(); err != nil || r.FormValue("code") != "abc" || r.FormValue("client_secret") != "secret" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token-1"})
+		case "/user":
+			if r.Header.Get("Authorization") != "Bearer token-1" || r.UserAgent() != "pin-test" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"login": "octocat", "html_url": "https://github.com/octocat"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	client := outbound.New(outbound.Config{UserAgent: "pin-test", Transport: redirectTransport{target: target}})
+	ctx := context.Background()
+
+	provider := githubProvider{clientID: "id", clientSecret: "secret"}
+	token, err := provider.Exchange(ctx, client, "https://pin.example/oauth/github/callback", "abc")
+	if err != nil || token != "token-1" {
+		t.Fatalf("expected the access token, got %q, %v", token, err)
+	}
+	profile, err := provider.FetchProfile(ctx, client, token)
+	if err != nil || profile.Username != "octocat" || provider.ProfileURL(profile) != "https://github.com/octocat" {
+		t.Fatalf("unexpected profile %+v, %v", profile, err)
+	}
+}
+
+// TestVerifyBlueskyHandle verifies the app-password session check against a test PDS.
+func TestVerifyBlueskyHandle(t *testing.T) {
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if r.URL.Path != "/xrpc/com.atproto.server.createSession" || json.NewDecoder(r.Body).Decode(&body) != nil || body["password"] != "app-pass" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"handle": body["identifier"], "did": "did:plc:abc"})
+	}))
+	defer pds.Close()
+	ctx := context.Background()
+
+	client := outbound.New(outbound.Config{AllowPrivate: true})
+	did, err := verifyBlueskyHandle(ctx, client, pds.URL, "alice.bsky.social", "app-pass")
+	if err != nil || did != "did:plc:abc" {
+		t.Fatalf("expected the session DID, got %q, %v", did, err)
+	}
+	if _, err := verifyBlueskyHandle(ctx, outbound.New(outbound.Config{}), pds.URL, "alice.bsky.social", "app-pass"); err == nil {
+		t.Fatalf("expected a loopback PDS to be refused by default")
+	}
+}
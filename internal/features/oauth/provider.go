@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Provider verifies ownership of a social profile through an OAuth2 authorization code flow.
+type Provider interface {
+	// Name is the route segment under /oauth/ and the SocialProfile.Provider key.
+	Name() string
+	// Label is shown on the connect link and stored as the social profile label.
+	Label() string
+	// AuthorizeURL returns the URL the user is sent to for consent.
+	AuthorizeURL(ctx context.Context, client *http.Client, redirectURI, state string) (string, error)
+	// Exchange trades an authorization code for an access token.
+	Exchange(ctx context.Context, client *http.Client, redirectURI, code string) (string, error)
+	// FetchProfile returns the account the access token belongs to.
+	FetchProfile(ctx context.Context, client *http.Client, token string) (Profile, error)
+	// ProfileURL returns the canonical public URL of the account.
+	ProfileURL(profile Profile) string
+}
+
+// Profile is the account reported by a provider. URL is the provider's own profile link when
+// it reports one.
+type Profile struct {
+	Username string
+	URL      string
+}
+
+// Registry holds the configured providers by name, in configuration order.
+type Registry struct {
+	providers map[string]Provider
+	names     []string
+}
+
+// NewRegistry constructs a registry of providers; later providers replace earlier ones with
+// the same name.
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{providers: map[string]Provider{}}
+	for _, provider := range providers {
+		name := provider.Name()
+		if _, ok := registry.providers[name]; !ok {
+			registry.names = append(registry.names, name)
+		}
+		registry.providers[name] = provider
+	}
+	return registry
+}
+
+// RegistryFromConfig builds the registry of providers with credentials in cfg. No provider is
+// available without a base URL to build callback URLs from.
+func RegistryFromConfig(cfg Config) *Registry {
+	var providers []Provider
+	if cfg.BaseURL == "" {
+		return NewRegistry()
+	}
+	if cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" {
+		providers = append(providers, githubProvider{clientID: cfg.GitHubClientID, clientSecret: cfg.GitHubClientSecret})
+	}
+	if cfg.RedditClientID != "" && cfg.RedditClientSecret != "" {
+		providers = append(providers, redditProvider{clientID: cfg.RedditClientID, clientSecret: cfg.RedditClientSecret, userAgent: cfg.RedditUserAgent})
+	}
+	for _, provider := range cfg.Providers {
+		providers = append(providers, NewGenericProvider(provider))
+	}
+	return NewRegistry(providers...)
+}
+
+// Lookup returns the provider registered under name.
+func (r *Registry) Lookup(name string) (Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Providers returns the registered providers in configuration order.
+func (r *Registry) Providers() []Provider {
+	out := make([]Provider, 0, len(r.names))
+	for _, name := range r.names {
+		out = append(out, r.providers[name])
+	}
+	return out
+}
+
+// authorizeURL appends the authorization request parameters to endpoint.
+func authorizeURL(endpoint string, params url.Values) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// postTokenRequest sends a prepared token request and returns the access token from its JSON
+// response.
+func postTokenRequest(client *http.Client, req *http.Request) (string, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", err
+	}
+	if data.Error != "" {
+		return "", fmt.Errorf("token request failed: %s", data.Error)
+	}
+	if data.AccessToken == "" {
+		return "", errors.New("missing access token")
+	}
+	return data.AccessToken, nil
+}
+
+// getJSON fetches endpoint with the bearer token and decodes the JSON response into out.
+func getJSON(ctx context.Context, client *http.Client, endpoint, token string, header http.Header, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", strings.SplitN(endpoint, "?", 2)[0], resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,282 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"pin/internal/config"
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/platform/outbound"
+)
+
+// fakeAuthServer is a minimal OpenID Connect provider: discovery, an authorization endpoint
+// that approves immediately, a token endpoint and a userinfo endpoint whose "profile" claim is
+// on profileHost, or on the server itself when profileHost is empty.
+func fakeAuthServer(t *testing.T, profileHost string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 server.URL,
+				"authorization_endpoint": server.URL + "/authorize",
+				"token_endpoint":         server.URL + "/token",
+				"userinfo_endpoint":      server.URL + "/userinfo",
+			})
+		case "/authorize":
+			query := r.URL.Query()
+			if query.Get("client_id") != "client" || query.Get("response_type") != "code" || query.Get("scope") != "openid profile" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			target, _ := url.Parse(query.Get("redirect_uri"))
+			target.RawQuery = url.Values{"code": {"code-1"}, "state": {query.Get("state")}}.Encode()
+			http.Redirect(w, r, target.String(), http.StatusFound)
+		case "/token":
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "code-1" || r.PostForm.Get("client_secret") != "secret" ||
+				r.PostForm.Get("grant_type") != "authorization_code" || !strings.HasSuffix(r.PostForm.Get("redirect_uri"), "/oauth/forge/callback") {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token-1", "token_type": "Bearer"})
+		case "/userinfo":
+			if r.Header.Get("Authorization") != "Bearer token-1" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			profile := server.URL + "/alice"
+			if profileHost != "" {
+				profile = "https://" + profileHost + "/alice"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"sub":                "42",
+				"preferred_username": "alice",
+				"profile":            profile,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// forgeConfig returns a generic provider configuration pointing at the fake server.
+func forgeConfig(issuer string) config.OAuthProvider {
+	return config.OAuthProvider{
+		Name:         "forge",
+		Label:        "Forge",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Issuer:       issuer,
+		Scopes:       []string{"openid", "profile"},
+	}
+}
+
+// TestGenericProviderFlow verifies discovery, the authorization redirect, the code exchange and
+// the userinfo profile against a fake authorization server.
+func TestGenericProviderFlow(t *testing.T) {
+	server := fakeAuthServer(t, "")
+	client := outbound.New(outbound.Config{AllowPrivate: true})
+	ctx := context.Background()
+	redirectURI := "https://pin.example/oauth/forge/callback"
+	provider := NewGenericProvider(forgeConfig(server.URL + "/"))
+
+	authURL, err := provider.AuthorizeURL(ctx, client, redirectURI, "state-1")
+	if err != nil || !strings.HasPrefix(authURL, server.URL+"/authorize?") {
+		t.Fatalf("expected the discovered authorization endpoint, got %q, %v", authURL, err)
+	}
+	// Act as the browser: stop at the redirect back to the callback.
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	_ = resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || callback.Query().Get("state") != "state-1" {
+		t.Fatalf("expected a redirect back with the state, got %q", resp.Header.Get("Location"))
+	}
+
+	if _, err := provider.Exchange(ctx, client, redirectURI, "wrong"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected the token error to be reported, got %v", err)
+	}
+	token, err := provider.Exchange(ctx, client, redirectURI, callback.Query().Get("code"))
+	if err != nil || token != "token-1" {
+		t.Fatalf("expected the access token, got %q, %v", token, err)
+	}
+	profile, err := provider.FetchProfile(ctx, client, token)
+	if err != nil || profile.Username != "alice" {
+		t.Fatalf("unexpected profile %+v, %v", profile, err)
+	}
+	if got := provider.ProfileURL(profile); got != server.URL+"/alice" {
+		t.Fatalf("expected the profile claim, got %q", got)
+	}
+	templated := NewGenericProvider(config.OAuthProvider{Name: "forge", ProfileURL: "https://codeberg.org/{username}"})
+	if got := templated.ProfileURL(profile); got != "https://codeberg.org/alice" {
+		t.Fatalf("expected the profile URL template, got %q", got)
+	}
+
+	// A claim pointing off the provider's host is dropped.
+	foreign := fakeAuthServer(t, "attacker.example")
+	provider = NewGenericProvider(forgeConfig(foreign.URL))
+	profile, err = provider.FetchProfile(ctx, client, "token-1")
+	if err != nil || provider.ProfileURL(profile) != "" {
+		t.Fatalf("expected a foreign profile claim to be dropped, got %+v, %v", profile, err)
+	}
+}
+
+// TestRegistryFromConfig verifies only providers with credentials are registered, in order.
+func TestRegistryFromConfig(t *testing.T) {
+	registry := RegistryFromConfig(Config{
+		BaseURL:        "https://pin.example",
+		GitHubClientID: "id", GitHubClientSecret: "secret",
+		RedditClientID: "id",
+		Providers:      []config.OAuthProvider{forgeConfig("https://forge.example")},
+	})
+	var names []string
+	for _, provider := range registry.Providers() {
+		names = append(names, provider.Name())
+	}
+	if strings.Join(names, ",") != "github,forge" {
+		t.Fatalf("unexpected providers %v", names)
+	}
+	if _, ok := registry.Lookup("reddit"); ok {
+		t.Fatalf("expected reddit without a secret to be skipped")
+	}
+	if len(RegistryFromConfig(Config{GitHubClientID: "id", GitHubClientSecret: "secret"}).Providers()) != 0 {
+		t.Fatalf("expected no providers without a base URL")
+	}
+}
+
+// handlerDeps is an in-memory Dependencies for one logged-in identity.
+type handlerDeps struct {
+	store    sessions.Store
+	identity domain.Identity
+	client   *http.Client
+}
+
+// BaseURL returns the test base URL.
+func (d *handlerDeps) BaseURL(r *http.Request) string { return "https://pin.example" }
+
+// GetSession returns the named cookie session.
+func (d *handlerDeps) GetSession(r *http.Request, name string) (*sessions.Session, error) {
+	return d.store.Get(r, name)
+}
+
+// ValidateCSRF accepts any token.
+func (d *handlerDeps) ValidateCSRF(session *sessions.Session, token string) bool { return true }
+
+// CurrentUser returns the logged-in user.
+func (d *handlerDeps) CurrentUser(r *http.Request) (domain.User, error) {
+	return domain.User{ID: 1}, nil
+}
+
+// CurrentIdentity returns the stored identity.
+func (d *handlerDeps) CurrentIdentity(r *http.Request) (domain.Identity, error) {
+	return d.identity, nil
+}
+
+// GetIdentityByID returns the stored identity.
+func (d *handlerDeps) GetIdentityByID(ctx context.Context, id int) (domain.Identity, error) {
+	return d.identity, nil
+}
+
+// UpdateIdentity stores the identity.
+func (d *handlerDeps) UpdateIdentity(ctx context.Context, record domain.Identity) error {
+	d.identity = record
+	return nil
+}
+
+// CheckHandleCollision reports no collision.
+func (d *handlerDeps) CheckHandleCollision(ctx context.Context, handle string, excludeID int) error {
+	return nil
+}
+
+// CreateIdentityRevision discards the revision.
+func (d *handlerDeps) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	return 1, nil
+}
+
+// ListIdentityRevisions returns no revisions.
+func (d *handlerDeps) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	return nil, nil
+}
+
+// GetIdentityRevision reports a missing revision.
+func (d *handlerDeps) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	return domain.IdentityRevision{}, sql.ErrNoRows
+}
+
+// GetProfilePictureAlt returns no alt text.
+func (d *handlerDeps) GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error) {
+	return "", nil
+}
+
+// HTTPClient returns the client reaching the fake server.
+func (d *handlerDeps) HTTPClient() *http.Client { return d.client }
+
+// AuditAttempt discards the entry.
+func (d *handlerDeps) AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string) {
+}
+
+// AuditOutcome discards the entry.
+func (d *handlerDeps) AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string) {
+}
+
+// TestGenericRoutes verifies /oauth/{provider}/start and /callback save a verified social
+// profile, and that the state token is enforced.
+func TestGenericRoutes(t *testing.T) {
+	server := fakeAuthServer(t, "")
+	deps := &handlerDeps{
+		store:    sessions.NewCookieStore([]byte("test-secret")),
+		identity: domain.Identity{ID: 1, UserID: 1, Handle: "alice"},
+		client:   outbound.New(outbound.Config{AllowPrivate: true}),
+	}
+	handler := NewHandler(Config{BaseURL: "https://pin.example", Providers: []config.OAuthProvider{forgeConfig(server.URL)}}, deps)
+
+	rec := httptest.NewRecorder()
+	handler.Start(rec, httptest.NewRequest(http.MethodGet, "/oauth/forge/start", nil))
+	authURL, _ := url.Parse(rec.Header().Get("Location"))
+	if rec.Code != http.StatusFound || authURL.Query().Get("redirect_uri") != "https://pin.example/oauth/forge/callback" {
+		t.Fatalf("expected a redirect to the authorization endpoint, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	state := authURL.Query().Get("state")
+
+	callback := func(state string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/oauth/forge/callback?code=code-1&state="+url.QueryEscape(state), nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.Callback(rec, req)
+		return rec
+	}
+	if rec := callback("forged"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a mismatched state to be rejected, got %d", rec.Code)
+	}
+	if rec := callback(state); rec.Code != http.StatusFound {
+		t.Fatalf("expected the callback to redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	profiles := identity.DecodeSocialProfiles(deps.identity.SocialProfilesJSON)
+	if len(profiles) != 1 || profiles[0].Provider != "forge" || profiles[0].Label != "Forge" || !profiles[0].Verified || profiles[0].URL != server.URL+"/alice" {
+		t.Fatalf("unexpected social profiles %+v", profiles)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Start(rec, httptest.NewRequest(http.MethodGet, "/oauth/unknown/start", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected an unknown provider to 404, got %d", rec.Code)
+	}
+}
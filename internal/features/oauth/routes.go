@@ -2,6 +2,7 @@ package oauth
 
 import (
 	"net/http"
+	"strings"
 
 	"pin/internal/platform/transport"
 )
@@ -17,9 +18,15 @@ func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies, cf
 
 	handler := NewHandler(cfg, deps)
 
-	register("/oauth/github/start", http.HandlerFunc(requireLogin(handler.GitHubStart)))
-	register("/oauth/github/callback", http.HandlerFunc(requireLogin(handler.GitHubCallback)))
-	register("/oauth/reddit/start", http.HandlerFunc(requireLogin(handler.RedditStart)))
-	register("/oauth/reddit/callback", http.HandlerFunc(requireLogin(handler.RedditCallback)))
+	register("/oauth/", http.HandlerFunc(requireLogin(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/start"):
+			handler.Start(w, r)
+		case strings.HasSuffix(r.URL.Path, "/callback"):
+			handler.Callback(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})))
 	register("/settings/profile/social/bluesky", http.HandlerFunc(requireLogin(handler.BlueskyConnect)))
 }
@@ -2,12 +2,14 @@ package public
 
 import (
 	"context"
+	"crypto/ed25519"
 	"net/http"
 
 	"github.com/gorilla/sessions"
 	"pin/internal/config"
 	"pin/internal/domain"
 	"pin/internal/features/domains"
+	"pin/internal/features/history"
 	"pin/internal/features/profilepicture"
 	featuresettings "pin/internal/features/settings"
 )
@@ -17,6 +19,7 @@ type Dependencies interface {
 	domains.Store
 	domains.Protector
 	profilepicture.Store
+	history.Store
 	Config() config.Config
 	HasUser(ctx context.Context) (bool, error)
 	GetUserByID(ctx context.Context, id int) (domain.User, error)
@@ -26,6 +29,8 @@ type Dependencies interface {
 	Reserved() map[string]struct{}
 	RenderTemplate(w http.ResponseWriter, name string, data interface{}) error
 	BaseURL(r *http.Request) string
+	PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error)
+	HTTPClient() *http.Client
 	GetSession(r *http.Request, name string) (*sessions.Session, error)
 	CurrentUser(r *http.Request) (domain.User, error)
 	CurrentIdentity(r *http.Request) (domain.Identity, error)
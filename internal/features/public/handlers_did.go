@@ -0,0 +1,41 @@
+package public
+
+import (
+	"net/http"
+	"strings"
+
+	"pin/internal/features/identity"
+	"pin/internal/features/identity/did"
+)
+
+// IdentityDID serves the did:web document of a handle at /{handle}/did.json.
+func (h Handler) IdentityDID(w http.ResponseWriter, r *http.Request, handle string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if err != nil || !identity.MatchesIdentity(user, handle) {
+		http.NotFound(w, r)
+		return
+	}
+	// Match /.well-known/did.json, which prefers PIN_BASE_URL over the request host.
+	baseURL := h.deps.Config().BaseURL
+	if baseURL == "" {
+		baseURL = h.deps.BaseURL(r)
+	}
+	identity.WriteIdentityCacheHeaders(w)
+	did.Write(w, did.Document(baseURL, user, false))
+}
+
+// didHandleFromPath extracts the handle from a /{handle}/did.json path.
+func didHandleFromPath(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[1] != "did.json" {
+		return "", false
+	}
+	if strings.TrimSpace(parts[0]) == "" {
+		return "", false
+	}
+	return parts[0], true
+}
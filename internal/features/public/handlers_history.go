@@ -0,0 +1,49 @@
+package public
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pin/internal/features/history"
+	"pin/internal/features/identity"
+)
+
+// PublicHistory serves the public revision history for a handle as JSON.
+func (h Handler) PublicHistory(w http.ResponseWriter, r *http.Request, handle string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if err != nil || !identity.MatchesIdentity(user, handle) {
+		http.NotFound(w, r)
+		return
+	}
+	entries, err := history.NewService(h.deps).PublicHistory(r.Context(), h.deps.BaseURL(r), user.ID, history.DefaultLimit)
+	if err != nil {
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []history.PublicEntry{}
+	}
+	identity.WriteIdentityCacheHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"handle":    user.Handle,
+		"revisions": entries,
+	})
+}
+
+// historyHandleFromPath extracts the handle from a /{handle}/history.json path.
+func historyHandleFromPath(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[1] != "history.json" {
+		return "", false
+	}
+	if strings.TrimSpace(parts[0]) == "" {
+		return "", false
+	}
+	return parts[0], true
+}
@@ -0,0 +1,37 @@
+package public
+
+import (
+	"net/http"
+	"strings"
+
+	"pin/internal/features/identity"
+)
+
+// SSHKeys serves an identity's public SSH keys in authorized_keys format at /{handle}.keys.
+func (h Handler) SSHKeys(w http.ResponseWriter, r *http.Request, handle string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := h.deps.GetIdentityByHandle(r.Context(), handle)
+	if err != nil || !identity.MatchesIdentity(user, handle) {
+		http.NotFound(w, r)
+		return
+	}
+	publicUser, _ := identity.VisibleIdentity(user, false)
+	keys := identity.SSHAuthorizedKeys(identity.DecodeStringMap(publicUser.PublicKeysJSON)["ssh"])
+	identity.WriteIdentityCacheHeaders(w)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, key := range keys {
+		_, _ = w.Write([]byte(key + "\n"))
+	}
+}
+
+// sshKeysHandleFromPath extracts the handle from a /{handle}.keys path.
+func sshKeysHandleFromPath(path string) (string, bool) {
+	path = strings.Trim(path, "/")
+	if strings.Contains(path, "/") || len(path) <= len(".keys") || !strings.EqualFold(path[len(path)-len(".keys"):], ".keys") {
+		return "", false
+	}
+	return path[:len(path)-len(".keys")], true
+}
@@ -27,6 +27,18 @@ func (h Handler) Index(w http.ResponseWriter, r *http.Request) {
 			handler.ProfilePictureByHandle(w, r, handle)
 			return
 		}
+		if handle, ok := historyHandleFromPath(r.URL.Path); ok {
+			h.PublicHistory(w, r, handle)
+			return
+		}
+		if handle, ok := didHandleFromPath(r.URL.Path); ok {
+			h.IdentityDID(w, r, handle)
+			return
+		}
+		if handle, ok := sshKeysHandleFromPath(r.URL.Path); ok {
+			h.SSHKeys(w, r, handle)
+			return
+		}
 		if ext := identity.ExtensionFromPath(r.URL.Path); ext != "" {
 			handler := export.NewHandler(identitySource{deps: h.deps})
 			switch ext {
@@ -527,7 +539,7 @@ func (h Handler) Setup(w http.ResponseWriter, r *http.Request) {
 				goto renderSetup
 			}
 			h.deps.AuditOutcome(r.Context(), 0, "user.create", handle, nil, map[string]string{"source": "setup"})
-			domains.NewService(h.deps).EnsureServerDomainVerification(r.Context(), h.deps.Config().BaseURL, int(identityID), h.deps, func() string {
+			domains.NewService(h.deps, h.deps.HTTPClient()).EnsureServerDomainVerification(r.Context(), h.deps.Config().BaseURL, int(identityID), h.deps, func() string {
 				return domains.RandomTokenURL(12)
 			})
 
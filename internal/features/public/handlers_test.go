@@ -2,6 +2,8 @@ package public
 
 import (
 	"context"
+	"crypto/ed25519"
+	"database/sql"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -45,6 +47,10 @@ func (publicDeps) RenderTemplate(w http.ResponseWriter, name string, data interf
 }
 // BaseURL returns a fixed base URL for tests.
 func (publicDeps) BaseURL(r *http.Request) string { return "http://example.test" }
+// PINCSigningKey returns no key, so PINC documents are served unsigned.
+func (publicDeps) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) { return nil, nil }
+// HTTPClient returns the default client; these tests make no outbound requests.
+func (publicDeps) HTTPClient() *http.Client { return http.DefaultClient }
 // GetSession returns the session.
 func (publicDeps) GetSession(r *http.Request, name string) (*sessions.Session, error) {
 	store := sessions.NewCookieStore([]byte("test-secret"))
@@ -123,7 +129,7 @@ func (publicDeps) DeleteDomainVerification(ctx context.Context, identityID int,
 	return nil
 }
 // MarkDomainVerified returns domain verified.
-func (publicDeps) MarkDomainVerified(ctx context.Context, identityID int, domain string) error {
+func (publicDeps) MarkDomainVerified(ctx context.Context, identityID int, domain, method string) error {
 	return nil
 }
 
@@ -167,6 +173,24 @@ func (publicDeps) GetProfilePictureAlt(ctx context.Context, identityID int, pict
 	return "", nil
 }
 
+func (publicDeps) GetIdentityByID(ctx context.Context, id int) (domain.Identity, error) {
+	return domain.Identity{}, sql.ErrNoRows
+}
+
+func (publicDeps) UpdateIdentity(ctx context.Context, identity domain.Identity) error { return nil }
+
+func (publicDeps) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	return 0, nil
+}
+
+func (publicDeps) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	return nil, nil
+}
+
+func (publicDeps) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	return domain.IdentityRevision{}, sql.ErrNoRows
+}
+
 // TestIndexRedirectsToSetup verifies index redirects to setup behavior.
 func TestIndexRedirectsToSetup(t *testing.T) {
 	handler := Handler{deps: publicDeps{hasUser: false}}
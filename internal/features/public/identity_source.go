@@ -2,6 +2,7 @@ package public
 
 import (
 	"context"
+	"crypto/ed25519"
 	"net/http"
 
 	"pin/internal/domain"
@@ -28,7 +29,21 @@ func (s identitySource) ActiveProfilePictureAlt(ctx context.Context, user domain
 	return profilepicture.NewService(s.deps).ActiveAlt(ctx, user)
 }
 
+// DomainVerifications returns the identity's domain verification rows.
+func (s identitySource) DomainVerifications(ctx context.Context, user domain.Identity) []domain.DomainVerification {
+	rows, err := s.deps.ListDomainVerifications(ctx, user.ID)
+	if err != nil {
+		return nil
+	}
+	return rows
+}
+
 // BaseURL returns the base URL.
 func (s identitySource) BaseURL(r *http.Request) string {
 	return s.deps.BaseURL(r)
 }
+
+// PINCSigningKey returns the node key used to sign PINC exports.
+func (s identitySource) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	return s.deps.PINCSigningKey(ctx)
+}
@@ -0,0 +1,114 @@
+package relme
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"pin/internal/config"
+	"pin/internal/domain"
+	"pin/internal/features/history"
+	"pin/internal/features/identity"
+)
+
+type Dependencies interface {
+	history.Store
+	Config() config.Config
+	GetSession(r *http.Request, name string) (*sessions.Session, error)
+	ValidateCSRF(session *sessions.Session, token string) bool
+	CurrentUser(r *http.Request) (domain.User, error)
+	CurrentIdentity(r *http.Request) (domain.Identity, error)
+	GetOwnerIdentity(ctx context.Context) (domain.Identity, error)
+	HTTPClient() *http.Client
+	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
+	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
+}
+
+type Handler struct {
+	deps Dependencies
+	now  func() time.Time
+}
+
+// NewHandler constructs a new handler.
+func NewHandler(deps Dependencies) Handler {
+	return Handler{deps: deps, now: time.Now}
+}
+
+// Verify checks the current identity's links and social profiles for rel="me" backlinks and
+// saves the outcome.
+func (h Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, _ := h.deps.GetSession(r, "pin_session")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !h.deps.ValidateCSRF(session, r.FormValue("csrf_token")) {
+		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		return
+	}
+	current, err := h.deps.CurrentUser(r)
+	if err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	identityRecord, err := h.deps.CurrentIdentity(r)
+	if err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Backlink targets come only from PIN_BASE_URL: the request host is client-controlled.
+	baseURL := strings.TrimRight(h.deps.Config().BaseURL, "/")
+	if baseURL == "" {
+		http.Error(w, `Set PIN_BASE_URL to verify rel="me" links`, http.StatusBadRequest)
+		return
+	}
+	result := Verify(r.Context(), h.deps.HTTPClient(),
+		identity.DecodeLinks(identityRecord.LinksJSON),
+		identity.DecodeSocialProfiles(identityRecord.SocialProfilesJSON),
+		h.profileURLs(r.Context(), baseURL, identityRecord), h.now())
+	identityRecord.LinksJSON = identity.EncodeLinks(result.Links)
+	if payload, err := json.Marshal(result.Social); err == nil && len(result.Social) > 0 {
+		identityRecord.SocialProfilesJSON = string(payload)
+	}
+
+	meta := map[string]string{"checked": strconv.Itoa(result.Checked), "verified": strconv.Itoa(result.Verified)}
+	h.deps.AuditAttempt(r.Context(), current.ID, "profile.relme_verify", identityRecord.Handle, meta)
+	err = history.NewService(h.deps).Save(r.Context(), baseURL, current.ID, identityRecord)
+	h.deps.AuditOutcome(r.Context(), current.ID, "profile.relme_verify", identityRecord.Handle, err, meta)
+	if err != nil {
+		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"checked":  result.Checked,
+			"verified": result.Verified,
+			"errors":   result.Errors,
+		})
+		return
+	}
+	toast := strconv.Itoa(result.Verified) + " of " + strconv.Itoa(result.Checked) + ` links and profiles verified with rel="me"`
+	http.Redirect(w, r, "/settings/profile?toast="+url.QueryEscape(toast)+"#section-links", http.StatusSeeOther)
+}
+
+// profileURLs returns the URLs a rel="me" link may point at: the identity's profile page and,
+// for the owner, the site root.
+func (h Handler) profileURLs(ctx context.Context, baseURL string, record domain.Identity) []string {
+	targets := []string{baseURL + "/" + url.PathEscape(record.Handle)}
+	if owner, err := h.deps.GetOwnerIdentity(ctx); err == nil && owner.ID == record.ID {
+		targets = append(targets, baseURL+"/")
+	}
+	return targets
+}
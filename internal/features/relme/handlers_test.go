@@ -0,0 +1,139 @@
+package relme
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"pin/internal/config"
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	"pin/internal/platform/outbound"
+)
+
+// handlerDeps is an in-memory Dependencies for one logged-in owner identity.
+type handlerDeps struct {
+	cfg      config.Config
+	identity domain.Identity
+	client   *http.Client
+}
+
+// Config returns the test configuration.
+func (d *handlerDeps) Config() config.Config { return d.cfg }
+
+// GetSession returns a fresh session.
+func (d *handlerDeps) GetSession(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.NewSession(nil, name), nil
+}
+
+// ValidateCSRF accepts any token.
+func (d *handlerDeps) ValidateCSRF(session *sessions.Session, token string) bool { return true }
+
+// CurrentUser returns the logged-in user.
+func (d *handlerDeps) CurrentUser(r *http.Request) (domain.User, error) {
+	return domain.User{ID: 1}, nil
+}
+
+// CurrentIdentity returns the stored identity.
+func (d *handlerDeps) CurrentIdentity(r *http.Request) (domain.Identity, error) {
+	return d.identity, nil
+}
+
+// GetOwnerIdentity returns the stored identity.
+func (d *handlerDeps) GetOwnerIdentity(ctx context.Context) (domain.Identity, error) {
+	return d.identity, nil
+}
+
+// GetIdentityByID returns the stored identity.
+func (d *handlerDeps) GetIdentityByID(ctx context.Context, id int) (domain.Identity, error) {
+	return d.identity, nil
+}
+
+// UpdateIdentity stores the identity.
+func (d *handlerDeps) UpdateIdentity(ctx context.Context, record domain.Identity) error {
+	d.identity = record
+	return nil
+}
+
+// CheckHandleCollision reports no collision.
+func (d *handlerDeps) CheckHandleCollision(ctx context.Context, handle string, excludeID int) error {
+	return nil
+}
+
+// CreateIdentityRevision discards the revision.
+func (d *handlerDeps) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	return 1, nil
+}
+
+// ListIdentityRevisions returns no revisions.
+func (d *handlerDeps) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	return nil, nil
+}
+
+// GetIdentityRevision reports a missing revision.
+func (d *handlerDeps) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	return domain.IdentityRevision{}, sql.ErrNoRows
+}
+
+// GetProfilePictureAlt returns no alt text.
+func (d *handlerDeps) GetProfilePictureAlt(ctx context.Context, identityID int, pictureID int64) (string, error) {
+	return "", nil
+}
+
+// HTTPClient returns the client reaching the fake pages.
+func (d *handlerDeps) HTTPClient() *http.Client { return d.client }
+
+// AuditAttempt discards the entry.
+func (d *handlerDeps) AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string) {
+}
+
+// AuditOutcome discards the entry.
+func (d *handlerDeps) AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string) {
+}
+
+// TestVerifyIgnoresRequestHost verifies backlink targets come from PIN_BASE_URL rather than the
+// Host header, and that verification is refused without PIN_BASE_URL.
+func TestVerifyIgnoresRequestHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forged":
+			_, _ = io.WriteString(w, `<a rel="me" href="https://evil.example/">me</a>`)
+		case "/real":
+			_, _ = io.WriteString(w, `<a rel="me" href="https://pin.example/alice">me</a>`)
+		}
+	}))
+	defer server.Close()
+	links := `[{"label":"Forged","url":"` + server.URL + `/forged"},{"label":"Real","url":"` + server.URL + `/real"}]`
+	deps := &handlerDeps{
+		cfg:      config.Config{BaseURL: "https://pin.example"},
+		identity: domain.Identity{ID: 1, UserID: 1, Handle: "alice", LinksJSON: links},
+		client:   outbound.New(outbound.Config{AllowPrivate: true}),
+	}
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/settings/profile/rel-me/verify", strings.NewReader(url.Values{"csrf_token": {"x"}}.Encode()))
+		req.Host = "evil.example"
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		NewHandler(deps).Verify(rec, req)
+		return rec
+	}
+
+	if rec := post(); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	saved := identity.DecodeLinks(deps.identity.LinksJSON)
+	if len(saved) != 2 || saved[0].Verified || !saved[1].Verified {
+		t.Fatalf("expected only the link back to PIN_BASE_URL to verify, got %+v", saved)
+	}
+
+	deps.cfg.BaseURL = ""
+	if rec := post(); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected verification to be refused without PIN_BASE_URL, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,193 @@
+// Package relme verifies links and social profiles IndieWeb-style: a linked page that links back
+// to the identity's profile with rel="me" proves the page belongs to the same person.
+package relme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/platform/outbound"
+)
+
+// checkTimeout bounds a single page fetch so one slow host cannot stall a verification run.
+const checkTimeout = 15 * time.Second
+
+// maxConcurrentChecks caps how many pages a verification run fetches at once.
+const maxConcurrentChecks = 4
+
+// ErrNoBacklink is returned when a page has no rel="me" link back to the profile.
+var ErrNoBacklink = errors.New(`no rel="me" link back to the profile`)
+
+var (
+	tagPattern  = regexp.MustCompile(`(?is)<(?:a|link)\s[^>]*>`)
+	attrPattern = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+))`)
+)
+
+// MeLinks returns the href of every <a> and <link> element in page whose rel includes "me".
+func MeLinks(page string) []string {
+	var out []string
+	for _, tag := range tagPattern.FindAllString(page, -1) {
+		var rel, href string
+		for _, match := range attrPattern.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(match[2] + match[3] + match[4])
+			switch strings.ToLower(match[1]) {
+			case "rel":
+				rel = value
+			case "href":
+				href = strings.TrimSpace(value)
+			}
+		}
+		if href == "" {
+			continue
+		}
+		for _, token := range strings.Fields(rel) {
+			if strings.EqualFold(token, "me") {
+				out = append(out, href)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Check fetches pageURL and reports whether it links back to one of targets with rel="me".
+// Relative links are resolved against the final URL after redirects. A page larger than the
+// client's body cap is checked up to the cap.
+func Check(ctx context.Context, client *http.Client, pageURL string, targets []string) error {
+	parsed, err := url.Parse(strings.TrimSpace(pageURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", pageURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("fetching %s: %s", parsed.Host, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil && !errors.Is(err, outbound.ErrBodyTooLarge) {
+		return err
+	}
+	base := resp.Request.URL
+	for _, href := range MeLinks(string(body)) {
+		ref, err := base.Parse(href)
+		if err != nil {
+			continue
+		}
+		for _, target := range targets {
+			if sameProfileURL(ref, target) {
+				return nil
+			}
+		}
+	}
+	return ErrNoBacklink
+}
+
+// sameProfileURL compares a link with a profile URL by host and path, ignoring the scheme, a
+// trailing slash, the query and the fragment.
+func sameProfileURL(ref *url.URL, target string) bool {
+	want, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ref.Hostname(), want.Hostname()) &&
+		strings.TrimSuffix(ref.EscapedPath(), "/") == strings.TrimSuffix(want.EscapedPath(), "/")
+}
+
+// Result is the outcome of verifying an identity's links and social profiles.
+type Result struct {
+	Links   []domain.Link
+	Social  []domain.SocialProfile
+	Checked int
+	// Verified counts entries that passed, including ones verified before.
+	Verified int
+	// Errors maps each failed entry's URL to the reason.
+	Errors map[string]string
+}
+
+// Verify checks every link and every social profile not verified through OAuth, marking the
+// entries that link back to targets as verified at now and clearing verification on the rest.
+func Verify(ctx context.Context, client *http.Client, links []domain.Link, social []domain.SocialProfile, targets []string, now time.Time) Result {
+	result := Result{
+		Links:  append([]domain.Link(nil), links...),
+		Social: append([]domain.SocialProfile(nil), social...),
+		Errors: map[string]string{},
+	}
+	verifiedAt := now.UTC().Format(time.RFC3339)
+	var urls []string
+	for _, link := range result.Links {
+		urls = append(urls, link.URL)
+	}
+	for _, profile := range result.Social {
+		urls = append(urls, profile.URL)
+	}
+	errs := make([]error, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < maxConcurrentChecks && w < len(urls); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+				errs[i] = Check(checkCtx, client, urls[i], targets)
+				cancel()
+			}
+		}()
+	}
+	for i := range urls {
+		if i >= len(result.Links) && oauthVerified(result.Social[i-len(result.Links)]) {
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	record := func(verified *bool, at *string, pageURL string, err error) {
+		result.Checked++
+		if err != nil {
+			*verified, *at = false, ""
+			result.Errors[pageURL] = err.Error()
+			return
+		}
+		if !*verified || *at == "" {
+			*at = verifiedAt
+		}
+		*verified = true
+		result.Verified++
+	}
+	for i := range result.Links {
+		record(&result.Links[i].Verified, &result.Links[i].VerifiedAt, result.Links[i].URL, errs[i])
+	}
+	for i := range result.Social {
+		profile := &result.Social[i]
+		if !oauthVerified(*profile) {
+			record(&profile.Verified, &profile.VerifiedAt, profile.URL, errs[len(result.Links)+i])
+		}
+	}
+	return result
+}
+
+// oauthVerified reports whether a social profile was verified through an OAuth provider; those
+// entries keep their verification regardless of the page content.
+func oauthVerified(profile domain.SocialProfile) bool {
+	return profile.Verified && strings.TrimSpace(profile.Provider) != ""
+}
@@ -0,0 +1,154 @@
+package relme
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/platform/outbound"
+)
+
+// TestMeLinks verifies rel="me" anchors and link elements are found with any quoting and case.
+func TestMeLinks(t *testing.T) {
+	page := `<html><head>
+<link rel="me authn" href="https://pin.example/alice">
+<LINK HREF='https://other.example/' REL='stylesheet'>
+</head><body>
+<a href=/bob rel=me>Bob</a>
+<a rel="nofollow" href="https://pin.example/carol">Carol</a>
+<a class="u-url" rel="Me" href="https://pin.example/dave?x=1&amp;y=2">Dave</a>
+<area rel="me" href="https://pin.example/erin">
+</body></html>`
+	want := []string{"https://pin.example/alice", "/bob", "https://pin.example/dave?x=1&y=2"}
+	if got := MeLinks(page); !reflect.DeepEqual(got, want) {
+		t.Fatalf("MeLinks = %v, want %v", got, want)
+	}
+}
+
+// TestCheck verifies backlinks are matched after redirects and relative resolution, and that
+// pages without one or with an error status fail.
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			_, _ = io.WriteString(w, `<a rel="me" href="https://pin.example/alice/">me</a>`)
+		case "/relative":
+			_, _ = io.WriteString(w, `<link rel="me" href="/alice">`)
+		case "/none":
+			_, _ = io.WriteString(w, `<a href="https://pin.example/alice">not rel me</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := outbound.New(outbound.Config{AllowPrivate: true})
+	ctx := context.Background()
+	targets := []string{"https://pin.example/alice"}
+
+	if err := Check(ctx, client, server.URL+"/moved", targets); err != nil {
+		t.Fatalf("expected the redirected page to verify, got %v", err)
+	}
+	if err := Check(ctx, client, server.URL+"/relative", []string{server.URL + "/alice"}); err != nil {
+		t.Fatalf("expected a relative backlink to verify, got %v", err)
+	}
+	if err := Check(ctx, client, server.URL+"/none", targets); !errors.Is(err, ErrNoBacklink) {
+		t.Fatalf("expected ErrNoBacklink, got %v", err)
+	}
+	if err := Check(ctx, client, server.URL+"/missing", targets); err == nil || errors.Is(err, ErrNoBacklink) {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+	if err := Check(ctx, outbound.New(outbound.Config{}), server.URL+"/home", targets); !errors.Is(err, outbound.ErrBlockedAddress) {
+		t.Fatalf("expected a loopback page to be refused by default, got %v", err)
+	}
+}
+
+// TestVerify verifies entries are marked with a timestamp, failures are cleared and OAuth
+// verified profiles are not fetched.
+func TestVerify(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/good" {
+			_, _ = io.WriteString(w, `<a rel="me" href="https://pin.example/alice">me</a>`)
+			return
+		}
+		_, _ = io.WriteString(w, `<p>nothing here</p>`)
+	}))
+	defer server.Close()
+	client := outbound.New(outbound.Config{AllowPrivate: true})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	result := Verify(context.Background(), client,
+		[]domain.Link{
+			{Label: "Good", URL: server.URL + "/good"},
+			{Label: "Stale", URL: server.URL + "/bad", Verified: true, VerifiedAt: "2025-01-01T00:00:00Z"},
+		},
+		[]domain.SocialProfile{
+			{Label: "Mastodon", URL: server.URL + "/good"},
+			{Label: "GitHub", URL: "https://github.com/alice", Provider: "github", Verified: true},
+		},
+		[]string{"https://pin.example/alice"}, now)
+
+	if !result.Links[0].Verified || result.Links[0].VerifiedAt != "2025-06-01T12:00:00Z" {
+		t.Fatalf("expected the good link to be verified at now, got %+v", result.Links[0])
+	}
+	if result.Links[1].Verified || result.Links[1].VerifiedAt != "" {
+		t.Fatalf("expected the stale link to lose verification, got %+v", result.Links[1])
+	}
+	if !result.Social[0].Verified || result.Social[0].VerifiedAt == "" {
+		t.Fatalf("expected the social profile to be verified, got %+v", result.Social[0])
+	}
+	if !result.Social[1].Verified || result.Checked != 3 || result.Verified != 2 || len(result.Errors) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(fetched) != 3 {
+		t.Fatalf("expected the OAuth profile not to be fetched, got %v", fetched)
+	}
+}
+
+// TestVerifyBoundsConcurrency verifies a run never fetches more than maxConcurrentChecks pages
+// at once.
+func TestVerifyBoundsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, _ = io.WriteString(w, `<a rel="me" href="https://pin.example/alice">me</a>`)
+	}))
+	defer server.Close()
+	var links []domain.Link
+	for i := 0; i < 3*maxConcurrentChecks; i++ {
+		links = append(links, domain.Link{Label: "Link", URL: server.URL + "/" + strconv.Itoa(i)})
+	}
+
+	result := Verify(context.Background(), outbound.New(outbound.Config{AllowPrivate: true}), links, nil,
+		[]string{"https://pin.example/alice"}, time.Now())
+	if result.Verified != len(links) {
+		t.Fatalf("expected every link to verify, got %+v", result)
+	}
+	if peak > maxConcurrentChecks {
+		t.Fatalf("expected at most %d concurrent fetches, got %d", maxConcurrentChecks, peak)
+	}
+}
@@ -0,0 +1,20 @@
+package relme
+
+import (
+	"net/http"
+
+	"pin/internal/platform/transport"
+)
+
+// Register registers routes and handlers.
+func Register(mux *http.ServeMux, reg transport.Registrar, deps Dependencies) {
+	register := func(pattern string, handler http.Handler) {
+		reg.RegisterRoute(mux, pattern, handler)
+	}
+	requireLogin := func(next http.HandlerFunc) http.HandlerFunc {
+		return reg.RequireSession(next, "/login?next=/settings")
+	}
+
+	handler := NewHandler(deps)
+	register("/settings/profile/rel-me/verify", http.HandlerFunc(requireLogin(handler.Verify)))
+}
@@ -0,0 +1,47 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"pin/internal/domain"
+	"pin/internal/features/federation"
+)
+
+// checkATProto resolves a user's AT DID and reports whether its document points back at the AT handle.
+func (h Handler) checkATProto(w http.ResponseWriter, r *http.Request, current domain.User, userID int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, _ := h.deps.GetSession(r, "pin_session")
+	if !h.deps.ValidateCSRF(session, r.FormValue("csrf_token")) {
+		http.Error(w, "Invalid CSRF token", http.StatusBadRequest)
+		return
+	}
+	targetUser, err := h.deps.GetUserByID(r.Context(), userID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	targetIdentity, err := h.deps.GetIdentityByUserID(r.Context(), targetUser.ID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	meta := map[string]string{"atproto_handle": targetIdentity.ATProtoHandle, "atproto_did": targetIdentity.ATProtoDID}
+	h.deps.AuditAttempt(r.Context(), current.ID, "atproto.check", targetIdentity.Handle, meta)
+	err = federation.CheckATProtoHandle(r.Context(), h.resolver, targetIdentity)
+	h.deps.AuditOutcome(r.Context(), current.ID, "atproto.check", targetIdentity.Handle, err, meta)
+	toast := "AT handle confirmed: the DID document lists at://" + targetIdentity.ATProtoHandle + "."
+	switch {
+	case err == nil:
+	case errors.Is(err, federation.ErrATProtoNotConfigured), errors.Is(err, federation.ErrATProtoDIDMismatch), errors.Is(err, federation.ErrATProtoHandleMissing):
+		toast = "AT handle check failed: " + err.Error() + "."
+	default:
+		toast = "AT handle check failed: could not resolve the DID (" + err.Error() + ")."
+	}
+	http.Redirect(w, r, "/settings/admin/users/"+strconv.Itoa(targetUser.ID)+"/edit?toast="+url.QueryEscape(toast)+"#section-atproto", http.StatusFound)
+}
@@ -3,6 +3,7 @@ package users
 import (
 	"encoding/json"
 	"errors"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -20,6 +21,8 @@ type WalletEntry struct {
 type LinkEntry struct {
 	Label      string
 	URL        string
+	Verified   bool
+	VerifiedAt string
 	Visibility string
 }
 
@@ -28,9 +31,35 @@ type SocialEntry struct {
 	URL        string
 	Provider   string
 	Verified   bool
+	VerifiedAt string
 	Visibility string
 }
 
+// ProfileVisibilityFields lists the profile fields with their own visibility setting.
+var ProfileVisibilityFields = []string{
+	"display_name",
+	"bio",
+	"email",
+	"organization",
+	"job_title",
+	"birthdate",
+	"languages",
+	"phone",
+	"address",
+	"location",
+	"website",
+	"pronouns",
+	"timezone",
+	"atproto_handle",
+	"atproto_did",
+	"key_pgp",
+	"key_ssh",
+	"key_age",
+	"key_activitypub",
+	"key_nostr",
+	"nostr_relays",
+}
+
 // BuildWalletEntries builds wallet entries from the supplied inputs.
 func BuildWalletEntries(wallets map[string]string, visibility map[string]string) []WalletEntry {
 	if wallets == nil {
@@ -71,6 +100,8 @@ func BuildLinkEntries(links []domain.Link, visibility map[string]string) []LinkE
 		out = append(out, LinkEntry{
 			Label:      link.Label,
 			URL:        link.URL,
+			Verified:   link.Verified,
+			VerifiedAt: link.VerifiedAt,
 			Visibility: visibilityValue(visibility, visKey, nil),
 		})
 	}
@@ -96,6 +127,7 @@ func BuildSocialEntries(social []domain.SocialProfile, visibility map[string]str
 			URL:        profile.URL,
 			Provider:   profile.Provider,
 			Verified:   profile.Verified,
+			VerifiedAt: profile.VerifiedAt,
 			Visibility: visibilityValue(visibility, visKey, nil),
 		})
 	}
@@ -381,6 +413,36 @@ func VerifiedDomainsToText(jsonStr string) string {
 	return strings.Join(domains, "\n")
 }
 
+// ParseNostrForm checks that a nostr key is an npub or hex key and parses relay hints, one
+// ws:// or wss:// URL per line.
+func ParseNostrForm(key, relays string) ([]string, error) {
+	if key = strings.TrimSpace(key); key != "" {
+		if _, err := identity.ParseNostrKey(key); err != nil {
+			return nil, errors.New("Nostr key must be an npub or 64 hex characters")
+		}
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(relays), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		parsed, err := url.Parse(line)
+		if err != nil || (parsed.Scheme != "wss" && parsed.Scheme != "ws") || parsed.Host == "" {
+			return nil, errors.New("Nostr relays must be ws:// or wss:// URLs")
+		}
+		seen[line] = true
+		out = append(out, line)
+	}
+	return out, nil
+}
+
+// NostrRelaysToText renders relay hints as newline-delimited text.
+func NostrRelaysToText(jsonStr string) string {
+	return strings.Join(identity.DecodeStringSlice(jsonStr), "\n")
+}
+
 // visibilityValue returns a normalized visibility value with defaults applied.
 func visibilityValue(visibility map[string]string, field string, defaultPrivate map[string]bool) string {
 	val, ok := visibility[field]
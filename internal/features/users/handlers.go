@@ -17,6 +17,8 @@ import (
 	"pin/internal/config"
 	"pin/internal/domain"
 	"pin/internal/features/domains"
+	"pin/internal/features/federation"
+	"pin/internal/features/history"
 	"pin/internal/features/identity"
 	featuresettings "pin/internal/features/settings"
 	"pin/internal/platform/media"
@@ -24,6 +26,8 @@ import (
 
 type Dependencies interface {
 	featuresettings.Store
+	history.Store
+	BaseURL(r *http.Request) string
 	Config() config.Config
 	GetSession(r *http.Request, name string) (*sessions.Session, error)
 	EnsureCSRF(session *sessions.Session) string
@@ -39,20 +43,22 @@ type Dependencies interface {
 	ListDomainVerifications(ctx context.Context, identityID int) ([]domain.DomainVerification, error)
 	UpsertDomainVerification(ctx context.Context, identityID int, domainName, token string) error
 	DeleteDomainVerification(ctx context.Context, identityID int, domainName string) error
-	MarkDomainVerified(ctx context.Context, identityID int, domainName string) error
+	MarkDomainVerified(ctx context.Context, identityID int, domainName, method string) error
 	ProtectedDomain(ctx context.Context) string
+	HTTPClient() *http.Client
 	RenderTemplate(w http.ResponseWriter, name string, data interface{}) error
 	AuditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string)
 	AuditOutcome(ctx context.Context, actorID int, action, target string, err error, meta map[string]string)
 }
 
 type Handler struct {
-	deps Dependencies
+	deps     Dependencies
+	resolver federation.DIDResolver
 }
 
 // NewHandler constructs a new handler.
 func NewHandler(deps Dependencies) Handler {
-	return Handler{deps: deps}
+	return Handler{deps: deps, resolver: federation.NewDIDResolver(deps.HTTPClient(), "")}
 }
 
 // Users handles the HTTP request.
@@ -99,6 +105,11 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/settings/admin/server#section-users", http.StatusFound)
 		return
 	}
+	if strings.HasSuffix(path, "/atproto-check") {
+		id, _ := strconv.Atoi(strings.Trim(strings.TrimSuffix(path, "/atproto-check"), "/"))
+		h.checkATProto(w, r, current, id)
+		return
+	}
 	if strings.HasSuffix(path, "/edit") {
 		idStr := strings.TrimSuffix(path, "/edit")
 		id, _ := strconv.Atoi(strings.Trim(idStr, "/"))
@@ -144,10 +155,10 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 			"Wallets":               identity.DecodeStringMap(targetIdentity.WalletsJSON),
 			"WalletEntries":         BuildWalletEntries(identity.DecodeStringMap(targetIdentity.WalletsJSON), visibility),
 			"PublicKeys":            identity.DecodeStringMap(targetIdentity.PublicKeysJSON),
+			"NostrRelays":           NostrRelaysToText(targetIdentity.NostrRelaysJSON),
 			"VerifiedDomains":       VerifiedDomainsToText(targetIdentity.VerifiedDomainsJSON),
 			"DomainVerifications":   []domain.DomainVerification{},
-			"GitHubOAuthEnabled":    false,
-			"RedditOAuthEnabled":    false,
+			"OAuthProviders":        nil,
 			"BlueskyEnabled":        false,
 			"IsAdmin":               true,
 			"IsOwner":               targetUser.Role == "owner",
@@ -162,10 +173,14 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 			"ShowAppearanceNav":     showAppearanceNav,
 			"ProtectedDomain":       h.deps.ProtectedDomain(r.Context()),
 			"DomainVisibility":      DomainVisibilityMap(visibility),
+			"ATProtoCheckAction":    "/settings/admin/users/" + strconv.Itoa(targetUser.ID) + "/atproto-check",
+		}
+		if toast := r.URL.Query().Get("toast"); toast != "" {
+			data["Message"] = toast
 		}
 		if rows, err := h.deps.ListDomainVerifications(r.Context(), targetIdentity.ID); err == nil {
 			if len(rows) == 0 {
-				rows = domains.NewService(h.deps).SeedDomains(r.Context(), targetIdentity.ID, identity.DecodeStringSlice(targetIdentity.VerifiedDomainsJSON), func() string {
+				rows = domains.NewService(h.deps, h.deps.HTTPClient()).SeedDomains(r.Context(), targetIdentity.ID, identity.DecodeStringSlice(targetIdentity.VerifiedDomainsJSON), func() string {
 					return domains.RandomTokenURL(12)
 				})
 			}
@@ -198,28 +213,9 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 			email := strings.TrimSpace(r.FormValue("email"))
 			bio := strings.TrimSpace(r.FormValue("bio"))
 			links, linkVisibility := ParseLinksForm(r.Form["link_label"], r.Form["link_url"], r.Form["link_visibility"])
+			links = identity.MergeLinks(links, identity.DecodeLinks(targetIdentity.LinksJSON))
 			customFields := ParseCustomFieldsForm(r.Form["custom_key"], r.Form["custom_value"])
-			fieldVisibility := ParseVisibilityForm(r.Form, []string{
-				"display_name",
-				"bio",
-				"email",
-				"organization",
-				"job_title",
-				"birthdate",
-				"languages",
-				"phone",
-				"address",
-				"location",
-				"website",
-				"pronouns",
-				"timezone",
-				"atproto_handle",
-				"atproto_did",
-				"key_pgp",
-				"key_ssh",
-				"key_age",
-				"key_activitypub",
-			})
+			fieldVisibility := ParseVisibilityForm(r.Form, ProfileVisibilityFields)
 			customVisibility := ParseCustomVisibilityForm(r.Form["custom_key"], r.Form["custom_value"], r.Form["custom_visibility"])
 			social, socialVisibility := identity.ParseSocialForm(r.Form["social_label"], r.Form["social_url"], r.Form["social_visibility"])
 			social = identity.MergeSocialProfiles(social, socialProfiles)
@@ -243,11 +239,18 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 				"ssh":         strings.TrimSpace(r.FormValue("key_ssh")),
 				"age":         strings.TrimSpace(r.FormValue("key_age")),
 				"activitypub": strings.TrimSpace(r.FormValue("key_activitypub")),
+				"nostr":       strings.TrimSpace(r.FormValue("key_nostr")),
+			}
+			nostrRelays, err := ParseNostrForm(publicKeys["nostr"], r.FormValue("nostr_relays"))
+			if err != nil {
+				data["Message"] = err.Error()
+				renderEdit()
+				return
 			}
 			verifiedDomains := ParseVerifiedDomainsText(r.FormValue("verified_domains"))
 			domainVisibility := ParseVerifiedDomainVisibilityForm(r.Form["verified_domain"], r.Form["verified_domain_visibility"])
 			h.deps.AuditAttempt(r.Context(), current.ID, "domain.sync", targetIdentity.Handle, nil)
-			_, verified, err := domains.NewService(h.deps).CreateDomains(r.Context(), targetIdentity.ID, verifiedDomains, func() string {
+			_, verified, err := domains.NewService(h.deps, h.deps.HTTPClient()).CreateDomains(r.Context(), targetIdentity.ID, verifiedDomains, func() string {
 				return domains.RandomTokenURL(12)
 			})
 			if err != nil {
@@ -308,6 +311,7 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 			targetIdentity.Timezone = strings.TrimSpace(r.FormValue("timezone"))
 			targetIdentity.ATProtoHandle = strings.TrimSpace(r.FormValue("atproto_handle"))
 			targetIdentity.ATProtoDID = strings.TrimSpace(r.FormValue("atproto_did"))
+			targetIdentity.NostrRelaysJSON = identity.EncodeStringSlice(nostrRelays)
 			targetIdentity.LinksJSON = identity.EncodeLinks(links)
 			if customJSON, err := json.Marshal(customFields); err == nil {
 				targetIdentity.CustomFieldsJSON = string(customJSON)
@@ -343,7 +347,7 @@ func (h Handler) User(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 				return
 			}
-			if err := h.deps.UpdateIdentity(r.Context(), targetIdentity); err != nil {
+			if err := history.NewService(h.deps).Save(r.Context(), h.deps.BaseURL(r), current.ID, targetIdentity); err != nil {
 				h.deps.AuditOutcome(r.Context(), current.ID, "user.update", targetIdentity.Handle, err, nil)
 				http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 				return
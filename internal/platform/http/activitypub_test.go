@@ -0,0 +1,359 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/federation"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestActivityPubFollowAndUpdate drives a follow, accept, profile update and unfollow against a fake remote instance.
+func TestActivityPubFollowAndUpdate(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	identityID, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", DisplayName: "Alice"})
+	if err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	local := httptest.NewServer(pinhttp.Routes(srv))
+	defer local.Close()
+	// Test configs pin PIN_BASE_URL, so actor IDs differ from the listener address.
+	localActor := srv.Config().BaseURL + "/users/alice"
+
+	remote := newFakeInstance(t, local.URL)
+	defer remote.Close()
+
+	follow := `{"@context":"https://www.w3.org/ns/activitystreams","id":"` + remote.actorID + `#follows/1","type":"Follow","actor":"` + remote.actorID + `","object":"` + localActor + `"}`
+	if resp := remote.post(local.URL+"/users/alice/inbox", follow, false); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unsigned follow to be rejected, got %d", resp.StatusCode)
+	}
+	if resp := remote.post(local.URL+"/users/alice/inbox", follow, true); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected the signed follow to be accepted, got %d", resp.StatusCode)
+	}
+	if got := getCollection(t, local.URL+"/users/alice/followers"); got.TotalItems != 1 {
+		t.Fatalf("expected one follower, got %d", got.TotalItems)
+	}
+
+	worker := federation.NewWorker(federation.WorkerConfig{}, deps)
+	if err := worker.RunOnce(ctx); err != nil {
+		t.Fatalf("run worker: %v", err)
+	}
+	if got := remote.received(); len(got) != 1 || got[0].Type != "Accept" || got[0].Actor != localActor {
+		t.Fatalf("expected a signed Accept from alice, got %+v", got)
+	}
+
+	user, err := deps.GetIdentityByID(ctx, int(identityID))
+	if err != nil {
+		t.Fatalf("load identity: %v", err)
+	}
+	user.DisplayName = "Alice Liddell"
+	if err := deps.UpdateIdentity(ctx, user); err != nil {
+		t.Fatalf("update identity: %v", err)
+	}
+	remote.failNext(http.StatusServiceUnavailable)
+	if err := worker.RunOnce(ctx); err != nil {
+		t.Fatalf("run worker: %v", err)
+	}
+	if got := remote.received(); len(got) != 1 {
+		t.Fatalf("expected the failed update to stay queued, got %+v", got)
+	}
+	retries, err := deps.ListDueActivityPubDeliveries(ctx, time.Now().Add(2*time.Minute), 10)
+	if err != nil || len(retries) != 1 || retries[0].Attempts != 1 || retries[0].LastError == "" {
+		t.Fatalf("expected one delivery scheduled for retry, got %+v (%v)", retries, err)
+	}
+	if err := deps.RecordActivityPubDeliveryFailure(ctx, retries[0].ID, sql.NullTime{Time: time.Now(), Valid: true}, retries[0].LastError); err != nil {
+		t.Fatalf("reschedule delivery: %v", err)
+	}
+	if err := worker.RunOnce(ctx); err != nil {
+		t.Fatalf("run worker: %v", err)
+	}
+	got := remote.received()
+	if len(got) != 2 || got[1].Type != "Update" || !strings.Contains(string(got[1].Object), "Alice Liddell") {
+		t.Fatalf("expected the retried Update with the new name, got %+v", got)
+	}
+	if outbox := getCollection(t, local.URL+"/users/alice/outbox"); outbox.TotalItems != 1 {
+		t.Fatalf("expected the Update in the outbox, got %d items", outbox.TotalItems)
+	}
+	if err := worker.RunOnce(ctx); err != nil || len(remote.received()) != 2 {
+		t.Fatalf("expected an unchanged profile not to be re-published (%v)", err)
+	}
+
+	before, err := deps.GetActivityPubActor(ctx, int(identityID))
+	if err != nil || !strings.HasPrefix(before.PrivateKey, "enc:v1:") || !strings.HasPrefix(before.Ed25519PrivateKey, "enc:v1:") {
+		t.Fatalf("expected sealed private keys, got %+v (%v)", before, err)
+	}
+	user, err = deps.GetIdentityByID(ctx, int(identityID))
+	if err != nil {
+		t.Fatalf("load identity: %v", err)
+	}
+	if err := federation.NewService(deps).RotateKeys(ctx, user, srv.Config().BaseURL); err != nil {
+		t.Fatalf("rotate keys: %v", err)
+	}
+	after, err := deps.GetActivityPubActor(ctx, int(identityID))
+	if err != nil || after.PublicKeyPEM == before.PublicKeyPEM || !after.RotatedAt.Valid {
+		t.Fatalf("expected new keys after rotation, got %+v (%v)", after, err)
+	}
+	// The fake inbox verifies against the freshly fetched actor, so this proves the new key signs.
+	if err := worker.RunOnce(ctx); err != nil {
+		t.Fatalf("run worker: %v", err)
+	}
+	got = remote.received()
+	if len(got) != 3 || got[2].Type != "Update" || !strings.Contains(string(got[2].Object), after.Ed25519PublicKey) {
+		t.Fatalf("expected an Update announcing the rotated keys, got %+v", got)
+	}
+
+	undo := `{"@context":"https://www.w3.org/ns/activitystreams","id":"` + remote.actorID + `#undo/1","type":"Undo","actor":"` + remote.actorID + `","object":` + follow + `}`
+	if resp := remote.post(local.URL+"/users/alice/inbox", undo, true); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected the undo to be accepted, got %d", resp.StatusCode)
+	}
+	if got := getCollection(t, local.URL+"/users/alice/followers"); got.TotalItems != 0 {
+		t.Fatalf("expected no followers after undo, got %d", got.TotalItems)
+	}
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	if remote.fetches != 1 {
+		t.Fatalf("expected bob's actor to be fetched once and cached, got %d fetches", remote.fetches)
+	}
+}
+
+// TestActivityPubInboxRejectsCrossOriginActor verifies an actor document cannot claim an actor ID
+// or inbox on another server.
+func TestActivityPubInboxRejectsCrossOriginActor(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", DisplayName: "Alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	local := httptest.NewServer(pinhttp.Routes(srv))
+	defer local.Close()
+	localActor := srv.Config().BaseURL + "/users/alice"
+	victim := newFakeInstance(t, local.URL)
+	defer victim.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicKeyPem := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	var attacker *httptest.Server
+	attacker = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actorID, inbox := victim.actorID, attacker.URL+"/inbox"
+		if r.URL.Path == "/inbox-key" {
+			actorID = attacker.URL + "/inbox-key"
+			inbox = victim.URL + "/inbox"
+		}
+		w.Header().Set("Content-Type", "application/activity+json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":        actorID,
+			"type":      "Person",
+			"inbox":     inbox,
+			"publicKey": map[string]string{"id": attacker.URL + r.URL.Path, "owner": actorID, "publicKeyPem": publicKeyPem},
+		})
+	}))
+	defer attacker.Close()
+
+	for _, tc := range []struct{ name, actor, keyID string }{
+		{"foreign actor ID", victim.actorID, attacker.URL + "/key"},
+		{"foreign inbox", attacker.URL + "/inbox-key", attacker.URL + "/inbox-key"},
+	} {
+		follow := `{"@context":"https://www.w3.org/ns/activitystreams","id":"` + tc.actor + `#follows/1","type":"Follow","actor":"` + tc.actor + `","object":"` + localActor + `"}`
+		req, _ := http.NewRequest(http.MethodPost, local.URL+"/users/alice/inbox", strings.NewReader(follow))
+		req.Header.Set("Content-Type", "application/activity+json")
+		if err := federation.SignRequest(req, []byte(follow), tc.keyID, key); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: expected the follow to be rejected, got %d", tc.name, resp.StatusCode)
+		}
+	}
+	if got := getCollection(t, local.URL+"/users/alice/followers"); got.TotalItems != 0 {
+		t.Fatalf("expected no followers, got %d", got.TotalItems)
+	}
+}
+
+// fakeInstance is a minimal remote server with one actor and an inbox that verifies signatures.
+type fakeInstance struct {
+	*httptest.Server
+	t        *testing.T
+	actorID  string
+	key      *rsa.PrivateKey
+	localURL string
+	mu       sync.Mutex
+	inbox    []receivedActivity
+	failWith int
+	fetches  int
+}
+
+// receivedActivity is an activity delivered to the fake inbox.
+type receivedActivity struct {
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// newFakeInstance starts a fake instance that resolves the local actor's keys through localURL.
+func newFakeInstance(t *testing.T, localURL string) *fakeInstance {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	f := &fakeInstance{t: t, key: key, localURL: localURL}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/bob", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.fetches++
+		f.mu.Unlock()
+		der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		w.Header().Set("Content-Type", "application/activity+json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    f.actorID,
+			"type":  "Person",
+			"inbox": f.URL + "/inbox",
+			"publicKey": map[string]string{
+				"id":           f.actorID + "#main-key",
+				"owner":        f.actorID,
+				"publicKeyPem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			},
+		})
+	})
+	mux.HandleFunc("/inbox", f.receive)
+	f.Server = httptest.NewServer(mux)
+	f.actorID = f.URL + "/users/bob"
+	return f
+}
+
+// receive verifies a delivery against the sender's published key and records it.
+func (f *fakeInstance) receive(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	_, err := federation.VerifyRequest(r, body, func(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+		// Resolve the local actor through the test listener rather than its configured host.
+		path := keyID[strings.Index(keyID, "/users/"):]
+		path, _, _ = strings.Cut(path, "#")
+		resp, err := http.Get(f.localURL + path)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		var actor struct {
+			PublicKey struct {
+				ID           string `json:"id"`
+				PublicKeyPem string `json:"publicKeyPem"`
+			} `json:"publicKey"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&actor); err != nil {
+			return nil, err
+		}
+		if actor.PublicKey.ID != keyID {
+			f.t.Errorf("signed with %s, actor publishes %s", keyID, actor.PublicKey.ID)
+		}
+		return federation.ParsePublicKeyPEM(actor.PublicKey.PublicKeyPem)
+	})
+	if err != nil {
+		f.t.Errorf("delivery signature: %v", err)
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failWith != 0 {
+		status := f.failWith
+		f.failWith = 0
+		http.Error(w, "unavailable", status)
+		return
+	}
+	var activity receivedActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		f.t.Errorf("decode delivery: %v", err)
+	}
+	f.inbox = append(f.inbox, activity)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// failNext makes the next delivery fail with status.
+func (f *fakeInstance) failNext(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failWith = status
+}
+
+// received returns the activities delivered so far.
+func (f *fakeInstance) received() []receivedActivity {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]receivedActivity(nil), f.inbox...)
+}
+
+// post sends an activity from bob, optionally signed with bob's key.
+func (f *fakeInstance) post(target, body string, sign bool) *http.Response {
+	req, _ := http.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/activity+json")
+	if sign {
+		if err := federation.SignRequest(req, []byte(body), f.actorID+"#main-key", f.key); err != nil {
+			f.t.Fatalf("sign: %v", err)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		f.t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// collection is the part of an OrderedCollection the test inspects.
+type collection struct {
+	TotalItems int `json:"totalItems"`
+}
+
+// getCollection fetches an ActivityPub collection.
+func getCollection(t *testing.T, target string) collection {
+	t.Helper()
+	resp, err := http.Get(target)
+	if err != nil {
+		t.Fatalf("get %s: %v", target, err)
+	}
+	defer resp.Body.Close()
+	var out collection
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode %s: %v", target, err)
+	}
+	return out
+}
@@ -0,0 +1,178 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/identity"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+type apiErrorBody struct {
+	Error struct {
+		Status int    `json:"status"`
+		Code   string `json:"code"`
+		Fields []struct {
+			Path string `json:"path"`
+		} `json:"fields"`
+	} `json:"error"`
+}
+
+// TestIdentityAPI verifies token auth, PATCH with If-Match and structured validation errors.
+func TestIdentityAPI(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	handler := pinhttp.Routes(srv)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", DisplayName: "Alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	token, _, err := apitokens.NewService(deps).Create(ctx, int(userID), "ci", []string{apitokens.ScopeIdentityReadPrivate, apitokens.ScopeIdentityWrite}, 0)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	do := func(method, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/identities/alice", strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	if rec := do(http.MethodGet, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+	rec := do(http.MethodGet, "", auth)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	etag := rec.Header().Get("ETag")
+	var fetched struct {
+		Rev string `json:"rev"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &fetched); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if etag == "" || etag != identity.IdentityETag(fetched.Rev, "json") {
+		t.Fatalf("expected the export ETag for rev %q, got %q", fetched.Rev, etag)
+	}
+	// A bare rev is accepted in If-Match as well as the ETag.
+	if rec := do(http.MethodPatch, `{}`, map[string]string{"Authorization": "Bearer " + token, "If-Match": `"` + fetched.Rev + `"`}); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a bare rev, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPatch, `{"job_title":"Engineer","wallets":[{"label":"btc","address":"bc1q","visibility":"private"}]}`, map[string]string{
+		"Authorization": "Bearer " + token,
+		"If-Match":      etag,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var updated struct {
+		DisplayName string `json:"display_name"`
+		JobTitle    string `json:"job_title"`
+		Wallets     []struct {
+			Label      string `json:"label"`
+			Visibility string `json:"visibility"`
+		} `json:"wallets"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if updated.DisplayName != "Alice" || updated.JobTitle != "Engineer" || len(updated.Wallets) != 1 || updated.Wallets[0].Label != "BTC" || updated.Wallets[0].Visibility != "private" {
+		t.Fatalf("unexpected resource: %+v", updated)
+	}
+
+	// The old rev no longer matches.
+	rec = do(http.MethodPatch, `{"bio":"x"}`, map[string]string{"Authorization": "Bearer " + token, "If-Match": etag})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPatch, `{"handle":"bad handle","links":[{"label":"Site"}],"visibility":{"email":"secret"}}`, auth)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var apiErr apiErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	var paths []string
+	for _, field := range apiErr.Error.Fields {
+		paths = append(paths, field.Path)
+	}
+	if apiErr.Error.Code != "validation_failed" || strings.Join(paths, ",") != "handle,visibility.email,links[0].url" {
+		t.Fatalf("unexpected error body: %s", rec.Body.String())
+	}
+
+	rec = do(http.MethodPatch, `{"nickname":"al"}`, auth)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"path":"nickname"`) {
+		t.Fatalf("expected unknown field error, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestIdentityAPIPutKeepsVisibility verifies a PUT that leaves visibility out keeps the stored
+// settings instead of making every field public.
+func TestIdentityAPIPutKeepsVisibility(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	handler := pinhttp.Routes(srv)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{
+		UserID:         int(userID),
+		Handle:         "alice",
+		Phone:          "+1 555 0100",
+		WalletsJSON:    `{"BTC":"bc1q"}`,
+		VisibilityJSON: `[{"key":"phone","visibility":"private"},{"key":"wallet_BTC","visibility":"private"}]`,
+	}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	token, _, err := apitokens.NewService(deps).Create(ctx, int(userID), "ci", []string{apitokens.ScopeIdentityReadPrivate, apitokens.ScopeIdentityWrite}, 0)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/identities/alice", strings.NewReader(`{"handle":"alice","phone":"+1 555 0199","wallets":[{"label":"btc","address":"bc1q"}]}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var updated struct {
+		Phone      string            `json:"phone"`
+		Visibility map[string]string `json:"visibility"`
+		Wallets    []struct {
+			Visibility string `json:"visibility"`
+		} `json:"wallets"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if updated.Phone != "+1 555 0199" || updated.Visibility["phone"] != "private" || len(updated.Wallets) != 1 || updated.Wallets[0].Visibility != "private" {
+		t.Fatalf("expected stored visibility to be kept, got %s", rec.Body.String())
+	}
+}
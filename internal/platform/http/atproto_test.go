@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pin/internal/domain"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestAtprotoDIDByHost verifies subdomain hosts resolve to their identity's DID.
+func TestAtprotoDIDByHost(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	ownerID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(ownerID), Handle: "owner", ATProtoDID: "did:plc:owner"}); err != nil {
+		t.Fatalf("create owner identity: %v", err)
+	}
+	userID, err := deps.CreateUser(ctx, "user", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", ATProtoDID: "did:plc:alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	bobID, err := deps.CreateUser(ctx, "user", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(bobID), Handle: "bob"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	handler := pinhttp.Routes(srv)
+
+	for host, want := range map[string]string{
+		"example.test":         "did:plc:owner",
+		"alice.example.test":   "did:plc:alice",
+		"bob.example.test":     "",
+		"nobody.example.test":  "",
+		"alice.elsewhere.test": "did:plc:owner",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/.well-known/atproto-did", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if want == "" {
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("%s: expected 404, got %d", host, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Fatalf("%s: expected %s, got %d %q", host, want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pin/internal/domain"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestDIDDocuments verifies the owner and per-handle did:web routes and the capability listing.
+func TestDIDDocuments(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	ownerID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(ownerID), Handle: "owner"}); err != nil {
+		t.Fatalf("create owner identity: %v", err)
+	}
+	userID, err := deps.CreateUser(ctx, "user", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	handler := pinhttp.Routes(srv)
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var out map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec, out
+	}
+
+	rec, doc := get("/.well-known/did.json")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/did+json" || doc["id"] != "did:web:example.test" {
+		t.Fatalf("unexpected owner DID %d %v", rec.Code, doc)
+	}
+	rec, doc = get("/alice/did.json")
+	if rec.Code != http.StatusOK || doc["id"] != "did:web:example.test:alice" {
+		t.Fatalf("unexpected alice DID %d %v", rec.Code, doc)
+	}
+	if rec, _ := get("/nobody/did.json"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown handle, got %d", rec.Code)
+	}
+	_, capability := get("/.well-known/pinc")
+	if didInfo, ok := capability["did"].(map[string]interface{}); !ok || didInfo["method"] != "web" {
+		t.Fatalf("expected did:web in the capability document, got %v", capability["did"])
+	}
+}
@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestTypedKeyEndpoints verifies WKD lookups, the SSH .keys listing and PINC key fingerprints.
+func TestTypedKeyEndpoints(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.org", nil)
+	if err != nil {
+		t.Fatalf("new entity: %v", err)
+	}
+	var armored bytes.Buffer
+	writer, err := armor.Encode(&armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor: %v", err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	_ = writer.Close()
+	pgpKey, err := identity.ParseOpenPGPKey(armored.String())
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	sshKey, err := ssh.NewPublicKey(edPublic)
+	if err != nil {
+		t.Fatalf("ssh key: %v", err)
+	}
+	sshLine := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshKey)))
+
+	identities := []domain.Identity{
+		{
+			Handle:              "alice",
+			Email:               "alice@example.org",
+			VerifiedDomainsJSON: `["example.org"]`,
+			PublicKeysJSON:      identity.EncodeStringMap(map[string]string{"pgp": armored.String(), "ssh": sshLine + " alice@laptop"}),
+		},
+		{
+			// Same key on an unverified domain.
+			Handle:         "mallory",
+			Email:          "alice@example.net",
+			PublicKeysJSON: identity.EncodeStringMap(map[string]string{"pgp": armored.String()}),
+		},
+	}
+	for i, record := range identities {
+		role := "user"
+		if i == 0 {
+			role = "owner"
+		}
+		userID, err := deps.CreateUser(ctx, role, "hash", "totp", "")
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		record.UserID = int(userID)
+		if _, err := deps.CreateIdentity(ctx, record); err != nil {
+			t.Fatalf("create identity: %v", err)
+		}
+	}
+	handler := pinhttp.Routes(srv)
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	hash := identity.WKDHash("alice")
+	for _, target := range []string{
+		"http://example.org/.well-known/openpgpkey/hu/" + hash + "?l=alice",
+		"http://openpgpkey.example.org/.well-known/openpgpkey/example.org/hu/" + hash,
+	} {
+		rec := get(target)
+		if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), pgpKey.Packets) {
+			t.Fatalf("%s: expected the binary key, got %d", target, rec.Code)
+		}
+		if rec.Header().Get("Content-Type") != "application/octet-stream" {
+			t.Fatalf("%s: unexpected content type %q", target, rec.Header().Get("Content-Type"))
+		}
+	}
+	if rec := get("http://example.org/.well-known/openpgpkey/policy"); rec.Code != http.StatusOK {
+		t.Fatalf("expected a policy file, got %d", rec.Code)
+	}
+	if rec := get("http://example.org/.well-known/openpgpkey/hu/" + identity.WKDHash("bob")); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown local parts to 404, got %d", rec.Code)
+	}
+	if rec := get("http://example.net/.well-known/openpgpkey/hu/" + hash); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unverified domains to 404, got %d", rec.Code)
+	}
+	if rec := get("http://example.net/.well-known/openpgpkey/policy"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected no policy for unverified domains, got %d", rec.Code)
+	}
+
+	rec := get("/alice.keys")
+	if rec.Code != http.StatusOK || rec.Body.String() != sshLine+"\n" {
+		t.Fatalf("expected the SSH key without its comment, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("/nobody.keys"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown handles to 404, got %d", rec.Code)
+	}
+
+	rec = get("/alice.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected PINC JSON, got %d", rec.Code)
+	}
+	var payload struct {
+		Identity struct {
+			KeyFingerprints []identity.KeyFingerprint `json:"key_fingerprints"`
+		} `json:"identity"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode PINC: %v", err)
+	}
+	prints := payload.Identity.KeyFingerprints
+	if len(prints) != 2 || prints[0].Fingerprint != pgpKey.Fingerprint || prints[1].Fingerprint != ssh.FingerprintSHA256(sshKey) {
+		t.Fatalf("unexpected fingerprints %+v", prints)
+	}
+}
@@ -0,0 +1,130 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/mcp"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestMCPIdentityCredentials verifies API tokens scope MCP to their identity, gate private views and audit reads.
+func TestMCPIdentityCredentials(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	createIdentity := func(role, handle string) int {
+		userID, err := deps.CreateUser(ctx, role, "hash", "totp", "")
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		identityID, err := deps.CreateIdentity(ctx, domain.Identity{
+			UserID:         int(userID),
+			Handle:         handle,
+			Phone:          "+1 555 0100",
+			VisibilityJSON: `[{"key":"phone","visibility":"private"}]`,
+		})
+		if err != nil {
+			t.Fatalf("create identity: %v", err)
+		}
+		if err := deps.UpdateIdentityPrivateToken(ctx, int(identityID), "private-"+handle); err != nil {
+			t.Fatalf("set private token: %v", err)
+		}
+		return int(userID)
+	}
+	ownerID := createIdentity("owner", "alice")
+	memberID := createIdentity("user", "bob")
+	newToken := func(userID int, scopes ...string) string {
+		raw, _, err := apitokens.NewService(deps).Create(ctx, userID, "agent", scopes, 0)
+		if err != nil {
+			t.Fatalf("create token: %v", err)
+		}
+		return raw
+	}
+	memberToken := newToken(memberID, apitokens.ScopeMCP, apitokens.ScopeIdentityReadPrivate)
+	memberPublicOnly := newToken(memberID, apitokens.ScopeMCP)
+	memberNoMCP := newToken(memberID, apitokens.ScopeIdentityReadPrivate)
+	ownerToken := newToken(ownerID, apitokens.ScopeMCP)
+
+	handler := mcp.NewHandler(mcp.Config{Enabled: true, Token: "global", ReadOnly: true}, deps)
+	call := func(token, method, params string) mcpResponse {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp mcpResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %s response: %v", method, err)
+		}
+		return resp
+	}
+	listed := func(token string) string {
+		resp := call(token, "resources/list", `{}`)
+		if resp.Error != nil {
+			t.Fatalf("list resources: %+v", resp.Error)
+		}
+		return string(resp.Result)
+	}
+
+	if resp := call(memberNoMCP, "resources/list", `{}`); resp.Error == nil || resp.Error.Code != -32001 {
+		t.Fatalf("expected a token without the mcp scope to be rejected, got %+v", resp)
+	}
+	if got := listed(memberToken); strings.Contains(got, "identity://alice") || !strings.Contains(got, "identity://bob?view=private") {
+		t.Fatalf("expected the member token to list only bob with a private view, got %s", got)
+	}
+	if got := listed(ownerToken); !strings.Contains(got, "identity://alice") || !strings.Contains(got, "identity://bob") || strings.Contains(got, "view=private") {
+		t.Fatalf("expected the owner token to enumerate everyone without private views, got %s", got)
+	}
+	if got := listed("global"); !strings.Contains(got, "identity://bob") || strings.Contains(got, "view=private") {
+		t.Fatalf("expected the global token to enumerate public views, got %s", got)
+	}
+
+	resp := call(memberToken, "resources/read", `{"uri":"identity://bob?view=private"}`)
+	if resp.Error != nil || !strings.Contains(string(resp.Result), "555 0100") || !strings.Contains(string(resp.Result), "/p/") {
+		t.Fatalf("expected bob's private view, got %+v", resp)
+	}
+	if resp := call(memberToken, "resources/read", `{"uri":"identity://bob"}`); resp.Error != nil || strings.Contains(string(resp.Result), "555 0100") {
+		t.Fatalf("expected bob's public view to hide the phone, got %+v", resp)
+	}
+	if resp := call(memberPublicOnly, "resources/read", `{"uri":"identity://bob?view=private"}`); resp.Error == nil {
+		t.Fatalf("expected a private read without identity:read-private to fail")
+	}
+	if resp := call(memberToken, "resources/read", `{"uri":"identity://alice"}`); resp.Error == nil {
+		t.Fatalf("expected the member token not to reach alice")
+	}
+	if resp := call("global", "resources/read", `{"uri":"identity://bob?view=private"}`); resp.Error == nil {
+		t.Fatalf("expected the global token not to read private views")
+	}
+
+	logs, err := deps.ListAllAuditLogs(ctx)
+	if err != nil {
+		t.Fatalf("list audit logs: %v", err)
+	}
+	reads := 0
+	for _, entry := range logs {
+		if entry.Action == "mcp.resources.read" && entry.Target == "identity://bob?view=private" {
+			reads++
+		}
+	}
+	// One allowed and two denied private reads of bob.
+	if reads != 3 {
+		t.Fatalf("expected 3 audited private reads, got %d", reads)
+	}
+}
+
+type mcpResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
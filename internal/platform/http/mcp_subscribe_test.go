@@ -0,0 +1,88 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pin/internal/domain"
+	"pin/internal/features/history"
+	"pin/internal/features/mcp"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestMCPResourceSubscription verifies a subscribed identity's rev change is pushed over the session's SSE stream.
+func TestMCPResourceSubscription(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", DisplayName: "Alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	server := httptest.NewServer(mcp.NewHandler(mcp.Config{Enabled: true, ReadOnly: true, PollInterval: 10 * time.Millisecond}, deps))
+	defer server.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	sessionID := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`).Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatalf("expected a session id")
+	}
+	post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"identity://alice"}}`)
+
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK || !strings.HasPrefix(stream.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("unexpected stream response: %d %s", stream.StatusCode, stream.Header.Get("Content-Type"))
+	}
+
+	record, err := deps.GetIdentityByHandle(ctx, "alice")
+	if err != nil {
+		t.Fatalf("get identity: %v", err)
+	}
+	record.DisplayName = "Alice Liddell"
+	if err := history.NewService(deps).Save(ctx, "http://example.com", int(userID), record); err != nil {
+		t.Fatalf("save identity: %v", err)
+	}
+
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") && strings.Contains(line, "notifications/resources/updated") {
+			if !strings.Contains(line, `"uri":"identity://alice"`) {
+				t.Fatalf("unexpected notification: %s", line)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without an update notification: %v", scanner.Err())
+}
@@ -0,0 +1,179 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pin/internal/domain"
+	"pin/internal/features/apitokens"
+	"pin/internal/features/identity"
+	"pin/internal/features/mcp"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+type mcpToolResponse struct {
+	Result struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+// TestMCPTools verifies tools are hidden in read-only mode and edit identities with audit entries otherwise.
+func TestMCPTools(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	// Building the routes fills the reserved handle set.
+	pinhttp.Routes(srv)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice", DisplayName: "Alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	call := func(cfg mcp.Config, method, params string) mcpToolResponse {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		mcp.NewHandler(cfg, deps).ServeHTTP(rec, req)
+		var resp mcpToolResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %s response: %v", method, err)
+		}
+		return resp
+	}
+	readOnly := mcp.Config{Enabled: true, Token: "secret", ReadOnly: true}
+	writable := mcp.Config{Enabled: true, Token: "secret"}
+
+	if resp := call(readOnly, "tools/list", `{}`); resp.Error != nil || len(resp.Result.Tools) != 0 {
+		t.Fatalf("expected no tools in read-only mode, got %+v", resp)
+	}
+	if resp := call(readOnly, "tools/call", `{"name":"add_link","arguments":{"handle":"alice","label":"Blog","url":"https://example.com"}}`); resp.Error == nil || resp.Error.Code != -32601 {
+		t.Fatalf("expected tools/call to be rejected in read-only mode, got %+v", resp)
+	}
+	if resp := call(mcp.Config{Enabled: true}, "tools/call", `{"name":"add_link","arguments":{"handle":"alice","label":"Blog","url":"https://example.com"}}`); resp.Error == nil || resp.Error.Code != -32001 {
+		t.Fatalf("expected tools/call to require a token, got %+v", resp)
+	}
+	if resp := call(writable, "tools/list", `{}`); len(resp.Result.Tools) != 5 {
+		t.Fatalf("expected 5 tools, got %+v", resp.Result.Tools)
+	}
+
+	steps := []string{
+		`{"name":"update_identity_field","arguments":{"handle":"alice","field":"display_name","value":"Alice Liddell"}}`,
+		`{"name":"add_link","arguments":{"handle":"alice","label":"Blog","url":"https://example.com"}}`,
+		`{"name":"set_visibility","arguments":{"handle":"alice","field":"link:0","visibility":"private"}}`,
+	}
+	for _, params := range steps {
+		if resp := call(writable, "tools/call", params); resp.Error != nil || resp.Result.IsError {
+			t.Fatalf("tool call %s failed: %+v", params, resp)
+		}
+	}
+	if resp := call(writable, "tools/call", `{"name":"update_identity_field","arguments":{"handle":"alice","field":"handle","value":"settings"}}`); !resp.Result.IsError {
+		t.Fatalf("expected reserved handle to be rejected, got %+v", resp)
+	}
+	if resp := call(writable, "tools/call", `{"name":"select_profile_picture","arguments":{"handle":"alice","picture_id":99}}`); !resp.Result.IsError {
+		t.Fatalf("expected unknown picture to be rejected, got %+v", resp)
+	}
+	resp := call(writable, "tools/call", `{"name":"request_domain_verification","arguments":{"handle":"alice","domain":"https://Example.org/"}}`)
+	if resp.Result.IsError || len(resp.Result.Content) == 0 || !strings.Contains(resp.Result.Content[0].Text, "example.org/.well-known/pin-verify") {
+		t.Fatalf("unexpected domain verification result: %+v", resp)
+	}
+
+	record, err := deps.GetIdentityByHandle(ctx, "alice")
+	if err != nil {
+		t.Fatalf("get identity: %v", err)
+	}
+	if record.DisplayName != "Alice Liddell" {
+		t.Fatalf("expected display name update, got %q", record.DisplayName)
+	}
+	links := identity.DecodeLinks(record.LinksJSON)
+	if len(links) != 1 || links[0].URL != "https://example.com" {
+		t.Fatalf("expected added link, got %+v", links)
+	}
+	if identity.DecodeVisibilityMap(record.VisibilityJSON)["link:0"] != "private" {
+		t.Fatalf("expected link to be private, got %s", record.VisibilityJSON)
+	}
+	rows, err := deps.ListDomainVerifications(ctx, record.ID)
+	if err != nil || len(rows) != 1 || rows[0].Domain != "example.org" {
+		t.Fatalf("expected pending domain verification, got %+v (%v)", rows, err)
+	}
+
+	logs, err := deps.ListAllAuditLogs(ctx)
+	if err != nil {
+		t.Fatalf("list audit logs: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, entry := range logs {
+		if strings.Contains(entry.Metadata, `"via":"mcp"`) {
+			seen[entry.Action] = true
+		}
+	}
+	for _, action := range []string{"mcp.update_identity_field", "mcp.add_link", "mcp.set_visibility", "mcp.request_domain_verification"} {
+		if !seen[action] {
+			t.Fatalf("expected audit entry for %s, got %+v", action, seen)
+		}
+	}
+}
+
+// TestMCPToolsRecordCallerAsActor verifies a tool edit made with an admin's API token is
+// attributed to the admin, not to the identity's owner.
+func TestMCPToolsRecordCallerAsActor(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	pinhttp.Routes(srv)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	ownerID, err := deps.CreateUser(ctx, "user", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	identityID, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(ownerID), Handle: "alice"})
+	if err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	adminID, err := deps.CreateUser(ctx, "admin", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	token, _, err := apitokens.NewService(deps).Create(ctx, int(adminID), "agent", []string{apitokens.ScopeMCP, apitokens.ScopeIdentityWrite}, 0)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"add_link","arguments":{"handle":"alice","label":"Blog","url":"https://example.com"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mcp.NewHandler(mcp.Config{Enabled: true}, deps).ServeHTTP(rec, req)
+	var resp mcpToolResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error != nil || resp.Result.IsError {
+		t.Fatalf("tool call failed: %s", rec.Body.String())
+	}
+
+	revisions, err := deps.ListIdentityRevisions(ctx, int(identityID), 1)
+	if err != nil || len(revisions) != 1 {
+		t.Fatalf("expected a revision, got %+v (%v)", revisions, err)
+	}
+	if revisions[0].ActorID.Int64 != adminID {
+		t.Fatalf("expected the admin as actor, got %+v", revisions[0].ActorID)
+	}
+}
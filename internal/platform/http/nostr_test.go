@@ -0,0 +1,105 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pin/internal/domain"
+	"pin/internal/features/identity"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestNostrJSON verifies NIP-05 names, npub normalization, relay hints and key visibility.
+func TestNostrJSON(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	const (
+		aliceHex = "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e"
+		bobHex   = "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d"
+	)
+	identities := []struct {
+		role     string
+		identity domain.Identity
+	}{
+		{"owner", domain.Identity{
+			Handle:          "alice",
+			PublicKeysJSON:  identity.EncodeStringMap(map[string]string{"nostr": "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg"}),
+			NostrRelaysJSON: `["wss://relay.example"]`,
+		}},
+		{"user", domain.Identity{
+			Handle:          "bob",
+			PublicKeysJSON:  identity.EncodeStringMap(map[string]string{"nostr": bobHex}),
+			NostrRelaysJSON: `["wss://bob.example"]`,
+			VisibilityJSON:  identity.EncodeVisibilityMap(map[string]string{"nostr_relays": "private"}),
+		}},
+		{"user", domain.Identity{
+			Handle:         "carol",
+			PublicKeysJSON: identity.EncodeStringMap(map[string]string{"nostr": bobHex}),
+			VisibilityJSON: identity.EncodeVisibilityMap(map[string]string{"key_nostr": "private"}),
+		}},
+	}
+	for _, entry := range identities {
+		userID, err := deps.CreateUser(ctx, entry.role, "hash", "totp", "")
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		entry.identity.UserID = int(userID)
+		if _, err := deps.CreateIdentity(ctx, entry.identity); err != nil {
+			t.Fatalf("create identity %s: %v", entry.identity.Handle, err)
+		}
+	}
+	handler := pinhttp.Routes(srv)
+
+	type nostrJSON struct {
+		Names  map[string]string   `json:"names"`
+		Relays map[string][]string `json:"relays"`
+	}
+	lookup := func(query string) nostrJSON {
+		req := httptest.NewRequest(http.MethodGet, "/.well-known/nostr.json"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", query, rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Fatalf("%s: expected CORS header", query)
+		}
+		var out nostrJSON
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode %s: %v", query, err)
+		}
+		return out
+	}
+
+	doc := lookup("?name=Alice")
+	if len(doc.Names) != 1 || doc.Names["alice"] != aliceHex {
+		t.Fatalf("expected alice's npub as hex, got %+v", doc.Names)
+	}
+	if relays := doc.Relays[aliceHex]; len(relays) != 1 || relays[0] != "wss://relay.example" {
+		t.Fatalf("expected alice's relay hint, got %+v", doc.Relays)
+	}
+
+	doc = lookup("?name=bob")
+	if doc.Names["bob"] != bobHex || len(doc.Relays) != 0 {
+		t.Fatalf("expected bob without private relays, got %+v", doc)
+	}
+	if doc = lookup("?name=carol"); len(doc.Names) != 0 {
+		t.Fatalf("expected carol's private key to be hidden, got %+v", doc.Names)
+	}
+	if doc = lookup("?name=_"); doc.Names["_"] != aliceHex {
+		t.Fatalf("expected the root name to map to the owner, got %+v", doc.Names)
+	}
+
+	doc = lookup("")
+	if len(doc.Names) != 3 || doc.Names["alice"] != aliceHex || doc.Names["bob"] != bobHex || doc.Names["_"] != aliceHex {
+		t.Fatalf("expected every public name on the node, got %+v", doc.Names)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"pin/internal/features/admin"
+	"pin/internal/features/api"
+	"pin/internal/features/apitokens"
 	"pin/internal/features/auth"
 	"pin/internal/features/domains"
 	"pin/internal/features/federation"
@@ -14,6 +16,7 @@ import (
 	"pin/internal/features/passkeys"
 	"pin/internal/features/profilepicture"
 	"pin/internal/features/public"
+	"pin/internal/features/relme"
 	pinserver "pin/internal/platform/server"
 	"pin/internal/platform/wiring"
 )
@@ -37,23 +40,18 @@ func Routes(s *pinserver.Server) http.Handler {
 	auth.Register(mux, s, deps)
 	admin.Register(mux, s, deps)
 	domains.Register(mux, s, deps)
+	relme.Register(mux, s, deps)
 	passkeys.Register(mux, s, deps)
+	adminHandler := admin.NewHandler(deps)
+	apitokens.Register(mux, s, deps, adminHandler.Security)
+	api.Register(mux, s, deps)
 	invites.Register(mux, s, deps)
-	oauth.Register(mux, s, deps, oauth.Config{
-		BaseURL:            cfg.BaseURL,
-		GitHubClientID:     cfg.GitHubClientID,
-		GitHubClientSecret: cfg.GitHubClientSecret,
-		RedditClientID:     cfg.RedditClientID,
-		RedditClientSecret: cfg.RedditClientSecret,
-		RedditUserAgent:    cfg.RedditUserAgent,
-		BlueskyPDS:         cfg.BlueskyPDS,
-	})
+	oauth.Register(mux, s, deps, oauth.NewConfig(cfg))
 	mcp.Register(mux, s, deps, mcp.Config{
 		Enabled:  cfg.MCPEnabled,
 		Token:    cfg.MCPToken,
 		ReadOnly: cfg.MCPReadOnly,
 	})
-	adminHandler := admin.NewHandler(deps)
 	register("/settings/security/private-identity/regenerate", http.HandlerFunc(s.RequireSession(adminHandler.PrivateIdentityRegenerate, "/login?next=/settings")))
 
 	return s.WithSecurityHeaders(public.WithPrivateRateLimit(public.WithSetupRedirect(deps, mux)))
@@ -3,9 +3,11 @@ package http_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
 	"pin/internal/testutil"
 )
 
@@ -23,3 +25,17 @@ func TestRoutesSetupPage(t *testing.T) {
 		t.Fatalf("expected status 200, got %d", rec.Code)
 	}
 }
+
+// TestReservedSegmentsMatchRoutes verifies wiring.ReservedSegments lists exactly the segments the
+// router reserves, so servers that skip the router validate handles the same way.
+func TestReservedSegmentsMatchRoutes(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	routed := testutil.NewServer(t)
+	pinhttp.Routes(routed)
+	reserved := testutil.NewServer(t)
+	wiring.ReserveHandles(reserved)
+
+	if !reflect.DeepEqual(routed.Reserved(), reserved.Reserved()) {
+		t.Fatalf("router reserves %v, wiring.ReservedSegments has %v", routed.Reserved(), reserved.Reserved())
+	}
+}
@@ -0,0 +1,93 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"pin/internal/domain"
+	pinhttp "pin/internal/platform/http"
+	"pin/internal/platform/wiring"
+	"pin/internal/testutil"
+)
+
+// TestWebfingerResources verifies resource forms, host validation and rel filtering.
+func TestWebfingerResources(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	deps := wiring.NewDeps(srv)
+	ctx := context.Background()
+
+	userID, err := deps.CreateUser(ctx, "owner", "hash", "totp", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := deps.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	handler := pinhttp.Routes(srv)
+
+	type jrd struct {
+		Subject string              `json:"subject"`
+		Aliases []string            `json:"aliases"`
+		Links   []map[string]string `json:"links"`
+	}
+	lookup := func(query string) (*httptest.ResponseRecorder, jrd) {
+		req := httptest.NewRequest(http.MethodGet, "/.well-known/webfinger?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var out jrd
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatalf("decode %s: %v", query, err)
+			}
+		}
+		return rec, out
+	}
+
+	rec, doc := lookup("resource=" + url.QueryEscape("acct:alice@example.test"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected acct lookup to succeed, got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Type") != "application/jrd+json" || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	if doc.Subject != "acct:alice@example.test" || len(doc.Links) != 4 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	hrefs := map[string]string{}
+	for _, link := range doc.Links {
+		hrefs[link["rel"]] = link["href"]
+	}
+	if hrefs["http://webfinger.net/rel/profile-page"] != "http://example.test/alice" ||
+		hrefs["alternate"] != "http://example.test/alice.json" ||
+		hrefs["http://webfinger.net/rel/avatar"] != "http://example.test/alice/profile-picture" {
+		t.Fatalf("unexpected links %v", hrefs)
+	}
+
+	for _, resource := range []string{"https://example.test/alice", "http://example.test/users/alice"} {
+		if rec, doc := lookup("resource=" + url.QueryEscape(resource)); rec.Code != http.StatusOK || doc.Subject != "acct:alice@example.test" {
+			t.Fatalf("expected %s to resolve, got %d %+v", resource, rec.Code, doc)
+		}
+	}
+
+	if rec, doc := lookup("resource=" + url.QueryEscape("acct:alice@example.test") + "&rel=self&rel=" + url.QueryEscape("http://webfinger.net/rel/avatar")); rec.Code != http.StatusOK || len(doc.Links) != 2 {
+		t.Fatalf("expected two links after rel filtering, got %d %+v", rec.Code, doc.Links)
+	}
+
+	for query, want := range map[string]int{
+		"": http.StatusBadRequest,
+		"resource=" + url.QueryEscape("acct:alice"):               http.StatusBadRequest,
+		"resource=" + url.QueryEscape("mailto:alice@x.test"):      http.StatusBadRequest,
+		"resource=" + url.QueryEscape("acct:alice@other.test"):    http.StatusNotFound,
+		"resource=" + url.QueryEscape("https://other.test/alice"): http.StatusNotFound,
+		"resource=" + url.QueryEscape("acct:nobody@example.test"): http.StatusNotFound,
+	} {
+		if rec, _ := lookup(query); rec.Code != want {
+			t.Fatalf("query %q: expected %d, got %d", query, want, rec.Code)
+		}
+	}
+}
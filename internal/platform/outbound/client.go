@@ -0,0 +1,175 @@
+// Package outbound builds the HTTP client used for requests to hosts named by users or remote
+// servers: domain verification, OAuth provider calls and federation lookups and deliveries.
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// Defaults applied to zero Config fields.
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxBodyBytes = 1 << 20
+	DefaultMaxRedirects = 5
+	DefaultUserAgent    = "pin/1.0"
+)
+
+var (
+	// ErrBlockedAddress is returned for destinations outside the public internet.
+	ErrBlockedAddress = errors.New("destination address is not allowed")
+	// ErrTooManyRedirects is returned once a request exceeds the redirect cap.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrBodyTooLarge is returned while reading a response body past the size cap.
+	ErrBodyTooLarge = errors.New("response body too large")
+	// ErrUnsupportedScheme is returned for URLs other than http and https.
+	ErrUnsupportedScheme = errors.New("unsupported URL scheme")
+)
+
+// nonPublicPrefixes are special-purpose ranges not covered by the netip.Addr predicates.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Config controls the limits and destinations of an outbound client.
+type Config struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	// MaxRedirects caps followed redirects; a negative value disables following them.
+	MaxRedirects int
+	// UserAgent is sent when a request does not set its own.
+	UserAgent string
+	// AllowPrivate permits loopback, private, link-local and other non-public destinations.
+	AllowPrivate bool
+	// Transport sends requests instead of a guarded clone of http.DefaultTransport. Resolved
+	// addresses are only checked by the default transport; literal IPs are checked for any.
+	Transport http.RoundTripper
+}
+
+// New returns a client enforcing cfg's timeout, body size, redirect cap and address policy.
+func New(cfg Config) *http.Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = DefaultMaxRedirects
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	next := cfg.Transport
+	if next == nil {
+		next = guardedTransport(cfg.AllowPrivate)
+	}
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport{cfg: cfg, next: next},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+// IsPublic reports whether addr is a globally routable unicast address.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// guardedTransport clones the default transport, checking every dialed address after DNS
+// resolution so a public name cannot rebind to an internal address.
+func guardedTransport(allowPrivate bool) *http.Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !IsPublic(addr) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+			}
+			return nil
+		}
+	}
+	base.DialContext = dialer.DialContext
+	return base
+}
+
+// transport applies the scheme, literal address and User-Agent policy and caps response bodies.
+type transport struct {
+	cfg  Config
+	next http.RoundTripper
+}
+
+// RoundTrip checks req against the policy and sends it.
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedScheme, req.URL.Scheme)
+	}
+	if !t.cfg.AllowPrivate {
+		if addr, err := netip.ParseAddr(req.URL.Hostname()); err == nil && !IsPublic(addr) {
+			return nil, fmt.Errorf("%w: %s", ErrBlockedAddress, req.URL.Hostname())
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.cfg.UserAgent)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.cfg.MaxBodyBytes}
+	return resp, nil
+}
+
+// limitedBody fails reads once more than remaining bytes have been read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read reads up to the cap and reports ErrBodyTooLarge when the body continues past it.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,107 @@
+package outbound
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// TestIsPublic verifies which destination addresses are considered public.
+func TestIsPublic(t *testing.T) {
+	for raw, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := IsPublic(netip.MustParseAddr(raw)); got != want {
+			t.Fatalf("IsPublic(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+// TestClientBlocksPrivateDestinations verifies loopback targets are refused by literal address
+// and after name resolution unless private destinations are allowed.
+func TestClientBlocksPrivateDestinations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := New(Config{})
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected a loopback IP to be blocked, got %v", err)
+	}
+	byName := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := client.Get(byName); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected a name resolving to loopback to be blocked, got %v", err)
+	}
+	if _, err := client.Get("file:///etc/passwd"); !errors.Is(err, ErrUnsupportedScheme) {
+		t.Fatalf("expected a file URL to be refused, got %v", err)
+	}
+
+	resp, err := New(Config{AllowPrivate: true}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected private destinations to be allowed, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+// TestClientLimits verifies the body cap, the redirect cap and the default User-Agent.
+func TestClientLimits(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		switch r.URL.Path {
+		case "/large":
+			_, _ = io.WriteString(w, strings.Repeat("x", 64))
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+	client := New(Config{AllowPrivate: true, MaxBodyBytes: 16, MaxRedirects: 2, UserAgent: "pin-test"})
+
+	resp, err := client.Get(server.URL + "/large")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if !errors.Is(err, ErrBodyTooLarge) || len(body) != 16 {
+		t.Fatalf("expected the body to stop at 16 bytes, got %d bytes, %v", len(body), err)
+	}
+
+	if _, err := client.Get(server.URL + "/loop"); !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("expected the redirect cap to apply, got %v", err)
+	}
+	if len(userAgents) != 4 || userAgents[0] != "pin-test" {
+		t.Fatalf("expected 1 + 3 requests with the configured User-Agent, got %v", userAgents)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("User-Agent", "custom/1.0")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if userAgents[len(userAgents)-1] != "custom/1.0" {
+		t.Fatalf("expected a request's own User-Agent to be kept, got %q", userAgents[len(userAgents)-1])
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"log"
 )
 
 // auditStatus records status as an audit event.
@@ -33,7 +34,7 @@ func mergeAuditMeta(meta map[string]string, extra map[string]string) map[string]
 // auditAttempt records attempt as an audit event.
 func (s *Server) auditAttempt(ctx context.Context, actorID int, action, target string, meta map[string]string) {
 	meta = mergeAuditMeta(meta, map[string]string{"status": "attempt"})
-	_ = s.repos.Audit.WriteAuditLog(ctx, actorID, action, target, meta)
+	s.writeAudit(ctx, actorID, action, target, meta)
 }
 
 // auditOutcome records outcome as an audit event.
@@ -43,5 +44,13 @@ func (s *Server) auditOutcome(ctx context.Context, actorID int, action, target s
 	if err != nil {
 		meta["error"] = err.Error()
 	}
-	_ = s.repos.Audit.WriteAuditLog(ctx, actorID, action, target, meta)
+	s.writeAudit(ctx, actorID, action, target, meta)
+}
+
+// writeAudit stores an audit entry, logging it to stderr when it cannot be stored so it is not
+// lost silently.
+func (s *Server) writeAudit(ctx context.Context, actorID int, action, target string, meta map[string]string) {
+	if err := s.repos.Audit.WriteAuditLog(ctx, actorID, action, target, meta); err != nil {
+		log.Printf("audit log write failed (%v): actor=%d action=%s target=%q meta=%v", err, actorID, action, target, meta)
+	}
 }
@@ -2,11 +2,13 @@ package server
 
 import (
 	"context"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/sessions"
 	"pin/internal/domain"
 	"pin/internal/platform/core"
+	"pin/internal/platform/storage"
 )
 
 // EnsureCSRF ensures CSRF is initialized and available.
@@ -68,3 +70,13 @@ func (s *Server) AuditOutcome(ctx context.Context, actorID int, action, target s
 func (s *Server) BaseURL(r *http.Request) string {
 	return core.BaseURL(r)
 }
+
+// HTTPClient returns the guarded client for outbound requests to user-supplied hosts.
+func (s *Server) HTTPClient() *http.Client {
+	return s.client
+}
+
+// WriteBackup streams a consistent backup archive of the database and uploads.
+func (s *Server) WriteBackup(ctx context.Context, w io.Writer) error {
+	return storage.WriteBackup(ctx, s.db, s.cfg, w)
+}
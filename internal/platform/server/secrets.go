@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks values sealed by SealSecret so the format can evolve.
+const sealedPrefix = "enc:v1:"
+
+// ErrNoEncryptionKey is returned when neither PIN_ENCRYPTION_KEY nor PIN_SECRET_KEY is set.
+var ErrNoEncryptionKey = errors.New("no encryption key configured: set PIN_ENCRYPTION_KEY or PIN_SECRET_KEY")
+
+// ErrNotSealed is returned when OpenSecret is given a value SealSecret did not produce.
+var ErrNotSealed = errors.New("value is not sealed")
+
+// SealSecret encrypts plaintext for storage with AES-256-GCM.
+func (s *Server) SealSecret(plaintext []byte) (string, error) {
+	aead, err := s.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return sealedPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// OpenSecret decrypts a value produced by SealSecret.
+func (s *Server) OpenSecret(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return nil, ErrNotSealed
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decode sealed value: %w", err)
+	}
+	aead, err := s.secretCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < aead.NonceSize() {
+		return nil, errors.New("sealed value too short")
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("sealed value cannot be opened with the configured encryption key")
+	}
+	return plaintext, nil
+}
+
+// secretCipher builds the AEAD from PIN_ENCRYPTION_KEY, or from a key derived from PIN_SECRET_KEY.
+func (s *Server) secretCipher() (cipher.AEAD, error) {
+	key, err := s.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptionKey returns the 32-byte key used to seal secrets at rest. A secret generated at
+// startup is refused, since values sealed with it could not be opened after a restart.
+func (s *Server) encryptionKey() ([]byte, error) {
+	if value := strings.TrimSpace(s.cfg.EncryptionKey); value != "" {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			key, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+		}
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("PIN_ENCRYPTION_KEY must be a base64 32-byte key")
+		}
+		return key, nil
+	}
+	if len(s.cfg.SecretKey) == 0 || s.cfg.SecretGenerated {
+		return nil, ErrNoEncryptionKey
+	}
+	sum := sha256.Sum256(append([]byte("pin secrets at rest\x00"), s.cfg.SecretKey...))
+	return sum[:], nil
+}
@@ -15,6 +15,7 @@ import (
 	"pin/internal/contracts"
 	"pin/internal/platform/core"
 	"pin/internal/platform/media"
+	"pin/internal/platform/outbound"
 	sqlitestore "pin/internal/platform/storage/sqlite"
 )
 
@@ -26,6 +27,8 @@ type Server struct {
 	tmpl     *template.Template
 	reserved map[string]struct{}
 	repos    contracts.Repos
+	signing  *signingKeyCache
+	client   *http.Client
 }
 
 // NewServer configures dependencies and templates for handlers using the default SQLite-backed repositories.
@@ -88,6 +91,12 @@ func NewServerWithRepos(cfg config.Config, db *sql.DB, repos contracts.Repos, ex
 		tmpl:     tmpl,
 		reserved: map[string]struct{}{},
 		repos:    repos,
+		signing:  &signingKeyCache{},
+		client: outbound.New(outbound.Config{
+			Timeout:      cfg.OutboundTimeout,
+			UserAgent:    cfg.OutboundUserAgent,
+			AllowPrivate: cfg.AllowPrivateHosts,
+		}),
 	}, nil
 }
 
@@ -111,6 +120,14 @@ func (s *Server) RegisterRoute(mux *http.ServeMux, pattern string, handler http.
 	s.register(mux, pattern, handler)
 }
 
+// ReserveHandles marks path segments as reserved without registering routes, for servers that
+// never build the HTTP router.
+func (s *Server) ReserveHandles(segments ...string) {
+	for _, segment := range segments {
+		s.reserved[strings.ToLower(segment)] = struct{}{}
+	}
+}
+
 // WithSecurityHeaders wraps the handler with additional behavior.
 func (s *Server) WithSecurityHeaders(next http.Handler) http.Handler {
 	return s.withSecurityHeaders(next)
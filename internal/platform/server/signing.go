@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+const pincSigningKeySetting = "pinc_signing_key"
+
+// ErrNoSigningKey is returned by LoadPINCSigningKey when neither PIN_PINC_SIGNING_KEY nor a
+// stored key exists.
+var ErrNoSigningKey = errors.New("no PINC signing key configured or stored")
+
+// signingKeyCache memoizes the node signing key after first use.
+type signingKeyCache struct {
+	mu  sync.Mutex
+	key ed25519.PrivateKey
+}
+
+// PINCSigningKey returns the node Ed25519 key used to sign PINC exports.
+// PIN_PINC_SIGNING_KEY takes precedence; otherwise a key is generated once and stored, sealed,
+// in settings.
+func (s *Server) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	s.signing.mu.Lock()
+	defer s.signing.mu.Unlock()
+	if s.signing.key != nil {
+		return s.signing.key, nil
+	}
+	key, err := s.loadSigningKey(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	s.signing.key = key
+	return key, nil
+}
+
+// LoadPINCSigningKey caches the configured or stored signing key without creating one, for
+// servers running on a read-only database. It returns ErrNoSigningKey when there is none.
+func (s *Server) LoadPINCSigningKey(ctx context.Context) error {
+	s.signing.mu.Lock()
+	defer s.signing.mu.Unlock()
+	if s.signing.key != nil {
+		return nil
+	}
+	key, err := s.loadSigningKey(ctx, false)
+	if err != nil {
+		return err
+	}
+	s.signing.key = key
+	return nil
+}
+
+// loadSigningKey reads the configured or stored seed, creating one when absent if create is set.
+// Stored seeds are sealed with SealSecret; a plain seed from an older version is sealed in place
+// the next time the key is loaded with create set.
+func (s *Server) loadSigningKey(ctx context.Context, create bool) (ed25519.PrivateKey, error) {
+	if seed := strings.TrimSpace(s.cfg.PINCSigningKey); seed != "" {
+		key, err := decodeSigningSeed(seed)
+		if err != nil {
+			return nil, fmt.Errorf("PIN_PINC_SIGNING_KEY: %w", err)
+		}
+		return key, nil
+	}
+	stored, ok, err := s.repos.Settings.GetSetting(ctx, pincSigningKeySetting)
+	if err != nil {
+		return nil, err
+	}
+	stored = strings.TrimSpace(stored)
+	if ok && strings.HasPrefix(stored, sealedPrefix) {
+		seed, err := s.OpenSecret(stored)
+		if err != nil {
+			return nil, fmt.Errorf("stored PINC signing key: %w", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("stored PINC signing key must be a %d-byte seed", ed25519.SeedSize)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if ok && stored != "" {
+		key, err := decodeSigningSeed(stored)
+		if err != nil {
+			return nil, err
+		}
+		if create {
+			if err := s.storeSigningSeed(ctx, key.Seed()); err != nil {
+				log.Printf("PINC signing key left unsealed: %v", err)
+			}
+		}
+		return key, nil
+	}
+	if !create {
+		return nil, ErrNoSigningKey
+	}
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	if err := s.storeSigningSeed(ctx, seed); err != nil {
+		if !errors.Is(err, ErrNoEncryptionKey) {
+			return nil, err
+		}
+		// Without a configured key nothing can be sealed, so the key lives only as long as the process.
+		log.Printf("PINC signing key not stored: %v", err)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// storeSigningSeed seals seed and saves it in settings.
+func (s *Server) storeSigningSeed(ctx context.Context, seed []byte) error {
+	sealed, err := s.SealSecret(seed)
+	if err != nil {
+		return err
+	}
+	return s.repos.Settings.SetSetting(ctx, pincSigningKeySetting, sealed)
+}
+
+// decodeSigningSeed parses a base64 Ed25519 seed (standard or URL alphabet).
+func decodeSigningSeed(value string) (ed25519.PrivateKey, error) {
+	value = strings.TrimSpace(value)
+	seed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		seed, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key encoding")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a %d-byte seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
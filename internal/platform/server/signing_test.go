@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"pin/internal/features/identity"
+	pinserver "pin/internal/platform/server"
+	"pin/internal/testutil"
+)
+
+// TestPINCSigningKeyPersists verifies the generated signing key is stored and reused.
+func TestPINCSigningKeyPersists(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	ctx := context.Background()
+
+	key, err := srv.PINCSigningKey(ctx)
+	if err != nil {
+		t.Fatalf("signing key: %v", err)
+	}
+	again, err := srv.PINCSigningKey(ctx)
+	if err != nil {
+		t.Fatalf("signing key again: %v", err)
+	}
+	if !key.Equal(again) {
+		t.Fatalf("expected cached key to be reused")
+	}
+	stored, ok, err := srv.Repos().Settings.GetSetting(ctx, "pinc_signing_key")
+	if err != nil || !ok || stored == "" {
+		t.Fatalf("expected stored seed, got %q ok=%v err=%v", stored, ok, err)
+	}
+}
+
+// TestPINCSigningKeyStoredSealed verifies the stored seed is sealed rather than kept as plain
+// base64, and that a plain seed from an older version is sealed in place.
+func TestPINCSigningKeyStoredSealed(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	srv := testutil.NewServer(t)
+	ctx := context.Background()
+	settings := srv.Repos().Settings
+
+	key, err := srv.PINCSigningKey(ctx)
+	if err != nil {
+		t.Fatalf("signing key: %v", err)
+	}
+	stored, _, err := settings.GetSetting(ctx, "pinc_signing_key")
+	if err != nil {
+		t.Fatalf("get setting: %v", err)
+	}
+	if !strings.HasPrefix(stored, "enc:v1:") || strings.Contains(stored, base64.StdEncoding.EncodeToString(key.Seed())) {
+		t.Fatalf("expected a sealed seed, got %q", stored)
+	}
+	// A fresh server has an empty cache and must open the sealed seed.
+	reloaded, err := pinserver.NewServerWithRepos(srv.Config(), nil, srv.Repos(), identity.TemplateFuncs())
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if again, err := reloaded.PINCSigningKey(ctx); err != nil || !key.Equal(again) {
+		t.Fatalf("expected the sealed key to load again, got %v", err)
+	}
+
+	legacy := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	if err := settings.SetSetting(ctx, "pinc_signing_key", legacy); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+	migrated, err := pinserver.NewServerWithRepos(srv.Config(), nil, srv.Repos(), identity.TemplateFuncs())
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	legacyKey, err := migrated.PINCSigningKey(ctx)
+	if err != nil || base64.StdEncoding.EncodeToString(legacyKey.Seed()) != legacy {
+		t.Fatalf("expected the legacy seed to keep working, got %v", err)
+	}
+	stored, _, _ = settings.GetSetting(ctx, "pinc_signing_key")
+	if !strings.HasPrefix(stored, "enc:v1:") {
+		t.Fatalf("expected the legacy seed to be sealed, got %q", stored)
+	}
+}
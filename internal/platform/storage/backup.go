@@ -2,6 +2,11 @@ package storage
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -10,12 +15,36 @@ import (
 	"time"
 
 	"pin/internal/config"
+	sqlitestore "pin/internal/platform/storage/sqlite"
 )
 
-// BackupToZip returns to zip.
-func BackupToZip(cfg config.Config, destPath string) (string, error) {
+// ManifestName is the archive entry describing a backup.
+const ManifestName = "manifest.json"
+
+// Manifest records what a backup contains and how to verify it.
+type Manifest struct {
+	PINVersion    string         `json:"pin_version"`
+	SchemaVersion int            `json:"schema_version"`
+	CreatedAt     string         `json:"created_at"`
+	Files         []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a checksummed archive entry.
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupFilename returns the default archive name for a backup taken at t.
+func BackupFilename(t time.Time) string {
+	return fmt.Sprintf("pin-backup-%s.zip", t.UTC().Format("20060102-150405"))
+}
+
+// BackupToZip writes a consistent backup archive to destPath and returns the path written.
+func BackupToZip(ctx context.Context, db *sql.DB, cfg config.Config, destPath string) (string, error) {
 	if destPath == "" {
-		destPath = fmt.Sprintf("pin-backup-%s.zip", time.Now().UTC().Format("20060102-150405"))
+		destPath = BackupFilename(time.Now())
 	}
 	if filepath.Ext(destPath) != ".zip" {
 		destPath = destPath + ".zip"
@@ -24,51 +53,104 @@ func BackupToZip(cfg config.Config, destPath string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
+	if err := WriteBackup(ctx, db, cfg, out); err != nil {
+		_ = out.Close()
+		_ = os.Remove(destPath)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(destPath)
+		return "", err
+	}
+	return destPath, nil
+}
 
-	zipWriter := zip.NewWriter(out)
-	defer zipWriter.Close()
+// WriteBackup streams a backup archive to w. The database is snapshotted with
+// VACUUM INTO so WAL contents are included and the copy is never torn.
+func WriteBackup(ctx context.Context, db *sql.DB, cfg config.Config, w io.Writer) error {
+	tmpDir, err := os.MkdirTemp("", "pin-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, "identity.db")
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", snapshot); err != nil {
+		return fmt.Errorf("snapshot database: %w", err)
+	}
+	schemaVersion, err := sqlitestore.SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
 
-	if err := addFileToZip(zipWriter, cfg.DBPath, "identity.db"); err != nil {
-		return "", err
+	manifest := Manifest{
+		PINVersion:    config.Version,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	zipWriter := zip.NewWriter(w)
+	entry, err := addFileToZip(zipWriter, snapshot, "identity.db")
+	if err != nil {
+		return err
 	}
-	uploadsDir := cfg.UploadsDir
-	if uploadsDir != "" {
-		if err := addDirToZip(zipWriter, uploadsDir, "uploads"); err != nil {
-			return "", err
+	manifest.Files = append(manifest.Files, entry)
+	if cfg.UploadsDir != "" {
+		entries, err := addDirToZip(zipWriter, cfg.UploadsDir, "uploads")
+		if err != nil {
+			return err
 		}
+		manifest.Files = append(manifest.Files, entries...)
 	}
-	return destPath, nil
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestWriter, err := zipWriter.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := manifestWriter.Write(raw); err != nil {
+		return err
+	}
+	return zipWriter.Close()
 }
 
-// addFileToZip adds file to zip to the collection.
-func addFileToZip(zipWriter *zip.Writer, sourcePath, name string) error {
+// addFileToZip adds a file to the archive and returns its manifest entry.
+func addFileToZip(zipWriter *zip.Writer, sourcePath, name string) (ManifestFile, error) {
 	file, err := os.Open(sourcePath)
 	if err != nil {
-		return err
+		return ManifestFile{}, err
 	}
 	defer file.Close()
 	info, err := file.Stat()
 	if err != nil {
-		return err
+		return ManifestFile{}, err
 	}
 	header, err := zip.FileInfoHeader(info)
 	if err != nil {
-		return err
+		return ManifestFile{}, err
 	}
 	header.Name = name
+	header.Method = zip.Deflate
 	writer, err := zipWriter.CreateHeader(header)
 	if err != nil {
-		return err
+		return ManifestFile{}, err
 	}
-	_, err = io.Copy(writer, file)
-	return err
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(writer, hash), file)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Name: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
 }
 
-// addDirToZip adds dir to zip to the collection.
-func addDirToZip(zipWriter *zip.Writer, sourceDir, prefix string) error {
-	return filepath.WalkDir(sourceDir, func(path string, d os.DirEntry, err error) error {
+// addDirToZip adds every non-hidden file under sourceDir to the archive.
+func addDirToZip(zipWriter *zip.Writer, sourceDir, prefix string) ([]ManifestFile, error) {
+	var entries []ManifestFile
+	err := filepath.WalkDir(sourceDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
+			if os.IsNotExist(err) && path == sourceDir {
+				return filepath.SkipDir
+			}
 			return err
 		}
 		if d.IsDir() {
@@ -83,6 +165,12 @@ func addDirToZip(zipWriter *zip.Writer, sourceDir, prefix string) error {
 			return nil
 		}
 		name := filepath.ToSlash(filepath.Join(prefix, rel))
-		return addFileToZip(zipWriter, path, name)
+		entry, err := addFileToZip(zipWriter, path, name)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
 	})
+	return entries, err
 }
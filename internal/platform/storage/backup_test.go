@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"pin/internal/config"
+	sqlitestore "pin/internal/platform/storage/sqlite"
+)
+
+// TestWriteBackupIncludesWALAndManifest verifies write backup includes WAL and manifest behavior.
+func TestWriteBackupIncludesWALAndManifest(t *testing.T) {
+	cfg := testStorageConfig(t)
+	db, err := sql.Open("sqlite", cfg.DBPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		t.Fatalf("wal: %v", err)
+	}
+	if _, err := db.Exec("PRAGMA wal_autocheckpoint = 0"); err != nil {
+		t.Fatalf("disable checkpoint: %v", err)
+	}
+	if err := sqlitestore.InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO settings (key, value) VALUES ('marker', 'in-wal')`); err != nil {
+		t.Fatalf("insert marker: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBackup(context.Background(), db, cfg, &buf); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	var manifest Manifest
+	var snapshot []byte
+	for _, file := range reader.File {
+		src, err := file.Open()
+		if err != nil {
+			t.Fatalf("open entry: %v", err)
+		}
+		data, _ := io.ReadAll(src)
+		src.Close()
+		switch file.Name {
+		case ManifestName:
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatalf("decode manifest: %v", err)
+			}
+		case "identity.db":
+			snapshot = data
+		}
+	}
+	if manifest.PINVersion != config.Version || manifest.CreatedAt == "" || manifest.SchemaVersion != sqlitestore.LatestSchemaVersion() {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Name != "identity.db" || manifest.Files[0].Size != int64(len(snapshot)) {
+		t.Fatalf("expected identity.db in manifest, got %+v", manifest.Files)
+	}
+	if err := verifyManifest(findEntry(reader, ManifestName), reader.File); err != nil {
+		t.Fatalf("verify manifest: %v", err)
+	}
+
+	restoredPath := filepath.Join(t.TempDir(), "restored.db")
+	if err := os.WriteFile(restoredPath, snapshot, 0o644); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+	restored, err := sql.Open("sqlite", restoredPath)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer restored.Close()
+	var value string
+	if err := restored.QueryRow(`SELECT value FROM settings WHERE key = 'marker'`).Scan(&value); err != nil || value != "in-wal" {
+		t.Fatalf("expected WAL row in snapshot, got %q (%v)", value, err)
+	}
+}
+
+// findEntry returns the named archive entry.
+func findEntry(reader *zip.Reader, name string) *zip.File {
+	for _, file := range reader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pin/internal/config"
+	sqlitestore "pin/internal/platform/storage/sqlite"
+)
+
+// ErrDatabaseExists is returned when a restore would overwrite a database without force.
+var ErrDatabaseExists = errors.New("database already exists; pass --force to overwrite")
+
+// RestoreFromZip validates a backup archive and restores the database and uploads from it.
+func RestoreFromZip(cfg config.Config, zipPath string, force bool) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	dbEntry, uploads, manifest, err := classifyBackupEntries(reader.File)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		if err := verifyManifest(manifest, reader.File); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(cfg.DBPath); err == nil && !force {
+		return ErrDatabaseExists
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	dbDir := filepath.Dir(cfg.DBPath)
+	if err := os.MkdirAll(dbDir, 0o755); err != nil {
+		return err
+	}
+	tmpDB, err := extractToTemp(dbEntry, dbDir, ".identity-restore-*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpDB)
+	if err := verifyDatabase(tmpDB); err != nil {
+		return fmt.Errorf("backup database is invalid: %w", err)
+	}
+
+	// Everything replaced below is set aside rather than deleted until the new database is in
+	// place, so a failed rename leaves the old database, its WAL and its uploads as they were.
+	var swap *uploadsSwap
+	if cfg.UploadsDir != "" && len(uploads) > 0 {
+		if swap, err = restoreUploads(uploads, cfg.UploadsDir); err != nil {
+			return err
+		}
+	}
+	// Stale WAL/SHM files belong to the old database and must not be replayed onto the new one.
+	journal, err := setAsideJournal(cfg.DBPath)
+	if err != nil {
+		swap.rollback()
+		return err
+	}
+	if err := os.Rename(tmpDB, cfg.DBPath); err != nil {
+		journal.rollback()
+		swap.rollback()
+		return err
+	}
+	journal.discard()
+	return swap.discard()
+}
+
+// asideSuffix marks files and directories set aside during a restore.
+const asideSuffix = ".pre-restore"
+
+// setAside is a set of files moved out of the way, keyed by their original path.
+type setAside map[string]string
+
+// setAsideJournal moves the database's WAL and SHM files aside.
+func setAsideJournal(dbPath string) (setAside, error) {
+	moved := setAside{}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		original := dbPath + suffix
+		if err := os.Rename(original, original+asideSuffix); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			moved.rollback()
+			return nil, err
+		}
+		moved[original] = original + asideSuffix
+	}
+	return moved, nil
+}
+
+// rollback moves the files back into place.
+func (s setAside) rollback() {
+	for original, aside := range s {
+		_ = os.Rename(aside, original)
+	}
+}
+
+// discard deletes the files set aside.
+func (s setAside) discard() {
+	for _, aside := range s {
+		_ = os.Remove(aside)
+	}
+}
+
+// classifyBackupEntries checks archive layout and returns the database, upload and manifest entries.
+func classifyBackupEntries(files []*zip.File) (*zip.File, []*zip.File, *zip.File, error) {
+	var dbEntry, manifest *zip.File
+	var uploads []*zip.File
+	for _, file := range files {
+		name := file.Name
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if !safeArchiveName(name) {
+			return nil, nil, nil, fmt.Errorf("unsafe archive entry %q", name)
+		}
+		switch {
+		case name == "identity.db":
+			dbEntry = file
+		case name == ManifestName:
+			manifest = file
+		case strings.HasPrefix(name, "uploads/"):
+			uploads = append(uploads, file)
+		default:
+			return nil, nil, nil, fmt.Errorf("unexpected archive entry %q", name)
+		}
+	}
+	if dbEntry == nil {
+		return nil, nil, nil, errors.New("archive does not contain identity.db")
+	}
+	return dbEntry, uploads, manifest, nil
+}
+
+// verifyManifest checks that every archive entry is listed in the manifest with a matching checksum.
+func verifyManifest(manifestEntry *zip.File, files []*zip.File) error {
+	src, err := manifestEntry.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	var manifest Manifest
+	if err := json.NewDecoder(src).Decode(&manifest); err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	expected := make(map[string]ManifestFile, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		expected[entry.Name] = entry
+	}
+	for _, file := range files {
+		if file.Name == ManifestName || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		entry, ok := expected[file.Name]
+		if !ok {
+			return fmt.Errorf("archive entry %q is not listed in the manifest", file.Name)
+		}
+		delete(expected, file.Name)
+		sum, err := checksumEntry(file)
+		if err != nil {
+			return err
+		}
+		if sum != entry.SHA256 {
+			return fmt.Errorf("checksum mismatch for %q", file.Name)
+		}
+	}
+	for name := range expected {
+		return fmt.Errorf("manifest entry %q is missing from the archive", name)
+	}
+	return nil
+}
+
+// checksumEntry returns the hex SHA-256 of an archive entry's contents.
+func checksumEntry(file *zip.File) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// safeArchiveName rejects absolute paths and parent traversal in archive entries.
+func safeArchiveName(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean == name && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// extractToTemp writes an archive entry to a synced temp file in dir and returns its path.
+func extractToTemp(file *zip.File, dir, pattern string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	out, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// verifyDatabase opens a restored database and runs integrity and schema checks.
+func verifyDatabase(dbPath string) error {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return sqlitestore.CheckSchemaVersion(context.Background(), db)
+}
+
+// uploadsSwap is a restored uploads directory swapped in, with the previous one kept aside.
+type uploadsSwap struct {
+	dir      string
+	previous string
+}
+
+// rollback puts the previous uploads directory back. It is a no-op on a nil swap.
+func (s *uploadsSwap) rollback() {
+	if s == nil {
+		return
+	}
+	_ = os.RemoveAll(s.dir)
+	if s.previous != "" {
+		_ = os.Rename(s.previous, s.dir)
+	}
+}
+
+// discard deletes the previous uploads directory. It is a no-op on a nil swap.
+func (s *uploadsSwap) discard() error {
+	if s == nil || s.previous == "" {
+		return nil
+	}
+	return os.RemoveAll(s.previous)
+}
+
+// restoreUploads extracts uploads into a staging directory and swaps it into place, keeping the
+// previous directory aside until the swap is discarded or rolled back.
+func restoreUploads(files []*zip.File, uploadsDir string) (*uploadsSwap, error) {
+	parent := filepath.Dir(uploadsDir)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(parent, ".uploads-restore-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+	for _, file := range files {
+		rel := strings.TrimPrefix(file.Name, "uploads/")
+		target := filepath.Join(staging, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := extractFile(file, target); err != nil {
+			return nil, err
+		}
+	}
+
+	swap := &uploadsSwap{dir: uploadsDir}
+	if _, err := os.Stat(uploadsDir); err == nil {
+		swap.previous = fmt.Sprintf("%s%s-%s", uploadsDir, asideSuffix, time.Now().UTC().Format("20060102-150405"))
+		if err := os.Rename(uploadsDir, swap.previous); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(staging, uploadsDir); err != nil {
+		if swap.previous != "" {
+			_ = os.Rename(swap.previous, uploadsDir)
+		}
+		return nil, err
+	}
+	return swap, nil
+}
+
+// extractFile writes a single archive entry to target.
+func extractFile(file *zip.File, target string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
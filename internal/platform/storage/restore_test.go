@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"pin/internal/config"
+	sqlitestore "pin/internal/platform/storage/sqlite"
+)
+
+// TestRestoreFromZipRoundTrip verifies restore from zip round trip behavior.
+func TestRestoreFromZipRoundTrip(t *testing.T) {
+	source := testStorageConfig(t)
+	db, err := sql.Open("sqlite", source.DBPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := sqlitestore.InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO settings (key, value) VALUES ('marker', 'restored')`); err != nil {
+		t.Fatalf("insert marker: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(source.UploadsDir, "profile-pictures"), 0o755); err != nil {
+		t.Fatalf("mkdir uploads: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source.UploadsDir, "profile-pictures", "a.webp"), []byte("img"), 0o644); err != nil {
+		t.Fatalf("write upload: %v", err)
+	}
+	archive, err := BackupToZip(context.Background(), db, source, filepath.Join(t.TempDir(), "backup.zip"))
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	_ = db.Close()
+
+	target := testStorageConfig(t)
+	if err := RestoreFromZip(target, archive, false); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(target.UploadsDir, "profile-pictures", "a.webp")); err != nil || string(data) != "img" {
+		t.Fatalf("expected restored upload, got %q (%v)", data, err)
+	}
+	restored, err := sql.Open("sqlite", target.DBPath)
+	if err != nil {
+		t.Fatalf("open restored db: %v", err)
+	}
+	defer restored.Close()
+	var value string
+	if err := restored.QueryRow(`SELECT value FROM settings WHERE key = 'marker'`).Scan(&value); err != nil || value != "restored" {
+		t.Fatalf("expected restored marker, got %q (%v)", value, err)
+	}
+
+	if err := RestoreFromZip(target, archive, false); !errors.Is(err, ErrDatabaseExists) {
+		t.Fatalf("expected ErrDatabaseExists without force, got %v", err)
+	}
+	if err := RestoreFromZip(target, archive, true); err != nil {
+		t.Fatalf("forced restore: %v", err)
+	}
+}
+
+// TestRestoreFromZipRollsBackOnRenameFailure verifies a failed database rename leaves the old
+// WAL and uploads in place.
+func TestRestoreFromZipRollsBackOnRenameFailure(t *testing.T) {
+	source := testStorageConfig(t)
+	db, err := sql.Open("sqlite", source.DBPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := sqlitestore.InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	if err := os.MkdirAll(source.UploadsDir, 0o755); err != nil {
+		t.Fatalf("mkdir uploads: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source.UploadsDir, "new.webp"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("write upload: %v", err)
+	}
+	archive, err := BackupToZip(context.Background(), db, source, filepath.Join(t.TempDir(), "backup.zip"))
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	_ = db.Close()
+
+	// A non-empty directory at the database path makes the final rename fail.
+	target := testStorageConfig(t)
+	if err := os.MkdirAll(filepath.Join(target.DBPath, "blocker"), 0o755); err != nil {
+		t.Fatalf("mkdir blocker: %v", err)
+	}
+	if err := os.WriteFile(target.DBPath+"-wal", []byte("old wal"), 0o644); err != nil {
+		t.Fatalf("write wal: %v", err)
+	}
+	if err := os.MkdirAll(target.UploadsDir, 0o755); err != nil {
+		t.Fatalf("mkdir uploads: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target.UploadsDir, "old.webp"), []byte("old"), 0o644); err != nil {
+		t.Fatalf("write upload: %v", err)
+	}
+
+	if err := RestoreFromZip(target, archive, true); err == nil {
+		t.Fatalf("expected the restore to fail")
+	}
+	if data, err := os.ReadFile(target.DBPath + "-wal"); err != nil || string(data) != "old wal" {
+		t.Fatalf("expected the old WAL to be kept, got %q (%v)", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(target.UploadsDir, "old.webp")); err != nil || string(data) != "old" {
+		t.Fatalf("expected the old uploads to be kept, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(target.UploadsDir, "new.webp")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the restored uploads to be rolled back, got %v", err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(target.DBPath), "*"+asideSuffix+"*"))
+	if len(leftovers) != 0 {
+		t.Fatalf("expected nothing left aside, got %v", leftovers)
+	}
+}
+
+// TestRestoreFromZipRejectsInvalidArchives verifies restore from zip rejects invalid archives behavior.
+func TestRestoreFromZipRejectsInvalidArchives(t *testing.T) {
+	cases := map[string]map[string]string{
+		"missing db":    {"uploads/a.txt": "x"},
+		"path escape":   {"identity.db": "x", "uploads/../../evil": "x"},
+		"unknown entry": {"identity.db": "x", "notes.txt": "x"},
+		"corrupt db":    {"identity.db": "not a database"},
+		"bad checksum":  {"identity.db": "x", ManifestName: `{"files":[{"name":"identity.db","size":1,"sha256":"00"}]}`},
+		"unlisted file": {"identity.db": "x", "uploads/a.txt": "x", ManifestName: `{"files":[{"name":"identity.db","size":1,"sha256":"2d711642b726b04401627ca9fbac32f5c8530fb1903cc4db02258717921a4881"}]}`},
+	}
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "bad.zip")
+			writeTestZip(t, archive, entries)
+			cfg := testStorageConfig(t)
+			if err := RestoreFromZip(cfg, archive, false); err == nil {
+				t.Fatalf("expected restore to fail")
+			}
+			if _, err := os.Stat(cfg.DBPath); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected no database written, got %v", err)
+			}
+		})
+	}
+}
+
+// testStorageConfig returns a config rooted in a temp directory.
+func testStorageConfig(t *testing.T) config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	return config.Config{
+		DBPath:     filepath.Join(dir, "identity.db"),
+		UploadsDir: filepath.Join(dir, "uploads"),
+	}
+}
+
+// writeTestZip writes a zip archive with the given entries.
+func writeTestZip(t *testing.T, dest string, entries map[string]string) {
+	t.Helper()
+	out, err := os.Create(dest)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	for name, body := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create entry: %v", err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+}
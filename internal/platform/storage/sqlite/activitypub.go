@@ -0,0 +1,284 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// activityPubTables lists the per-identity ActivityPub tables cleared when an identity is deleted.
+var activityPubTables = []string{"activitypub_delivery", "activitypub_activity", "activitypub_follower", "activitypub_actor"}
+
+const activityPubActorColumns = "identity_id, public_key_pem, private_key_pem, ed25519_public_key, ed25519_private_key, base_url, published_hash, created_at, rotated_at"
+
+// GetActivityPubActor returns the actor key and publish state for an identity.
+func GetActivityPubActor(ctx context.Context, db *sql.DB, identityID int) (domain.ActivityPubActor, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+activityPubActorColumns+" FROM activitypub_actor WHERE identity_id = ?", identityID)
+	return scanActivityPubActor(row)
+}
+
+// ListActivityPubActors returns every identity that has an actor key.
+func ListActivityPubActors(ctx context.Context, db *sql.DB) ([]domain.ActivityPubActor, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+activityPubActorColumns+" FROM activitypub_actor ORDER BY identity_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actors []domain.ActivityPubActor
+	for rows.Next() {
+		actor, err := scanActivityPubActor(rows)
+		if err != nil {
+			return nil, err
+		}
+		actors = append(actors, actor)
+	}
+	return actors, rows.Err()
+}
+
+// CreateActivityPubActor stores an actor's keys unless the identity already has some.
+func CreateActivityPubActor(ctx context.Context, db *sql.DB, actor domain.ActivityPubActor) error {
+	createdAt := actor.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO activitypub_actor (identity_id, public_key_pem, private_key_pem, ed25519_public_key, ed25519_private_key, base_url, published_hash, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(identity_id) DO NOTHING`,
+		actor.IdentityID,
+		actor.PublicKeyPEM,
+		actor.PrivateKey,
+		actor.Ed25519PublicKey,
+		actor.Ed25519PrivateKey,
+		actor.BaseURL,
+		actor.PublishedHash,
+		createdAt.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// UpdateActivityPubActorKeys replaces an actor's keys, stamping rotated_at when rotatedAt is set.
+func UpdateActivityPubActorKeys(ctx context.Context, db *sql.DB, actor domain.ActivityPubActor) error {
+	res, err := db.ExecContext(
+		ctx,
+		"UPDATE activitypub_actor SET public_key_pem = ?, private_key_pem = ?, ed25519_public_key = ?, ed25519_private_key = ?, rotated_at = COALESCE(?, rotated_at) WHERE identity_id = ?",
+		actor.PublicKeyPEM,
+		actor.PrivateKey,
+		actor.Ed25519PublicKey,
+		actor.Ed25519PrivateKey,
+		nullTimeString(actor.RotatedAt),
+		actor.IdentityID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateActivityPubActorState records the base URL and actor document hash last published for an identity.
+func UpdateActivityPubActorState(ctx context.Context, db *sql.DB, identityID int, baseURL, publishedHash string) error {
+	_, err := db.ExecContext(ctx, "UPDATE activitypub_actor SET base_url = ?, published_hash = ? WHERE identity_id = ?", baseURL, publishedHash, identityID)
+	return err
+}
+
+// AddActivityPubFollower stores a follower, refreshing its inboxes when it already follows.
+func AddActivityPubFollower(ctx context.Context, db *sql.DB, follower domain.ActivityPubFollower) error {
+	createdAt := follower.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	_, err := db.ExecContext(
+		ctx,
+		`INSERT INTO activitypub_follower (identity_id, actor_id, inbox, shared_inbox, follow_id, created_at) VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT(identity_id, actor_id) DO UPDATE SET inbox = excluded.inbox, shared_inbox = excluded.shared_inbox, follow_id = excluded.follow_id`,
+		follower.IdentityID,
+		follower.ActorID,
+		follower.Inbox,
+		follower.SharedInbox,
+		follower.FollowID,
+		createdAt.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// RemoveActivityPubFollower deletes a follower of an identity.
+func RemoveActivityPubFollower(ctx context.Context, db *sql.DB, identityID int, actorID string) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM activitypub_follower WHERE identity_id = ? AND actor_id = ?", identityID, actorID)
+	return err
+}
+
+// ListActivityPubFollowers returns an identity's followers, oldest first.
+func ListActivityPubFollowers(ctx context.Context, db *sql.DB, identityID int) ([]domain.ActivityPubFollower, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, identity_id, actor_id, inbox, shared_inbox, follow_id, created_at FROM activitypub_follower WHERE identity_id = ? ORDER BY id", identityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var followers []domain.ActivityPubFollower
+	for rows.Next() {
+		var follower domain.ActivityPubFollower
+		var sharedInbox, followID sql.NullString
+		var created string
+		if err := rows.Scan(&follower.ID, &follower.IdentityID, &follower.ActorID, &follower.Inbox, &sharedInbox, &followID, &created); err != nil {
+			return nil, err
+		}
+		follower.SharedInbox = sharedInbox.String
+		follower.FollowID = followID.String
+		follower.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		followers = append(followers, follower)
+	}
+	return followers, rows.Err()
+}
+
+// CreateActivityPubActivity stores an activity and queues its delivery to each inbox in one transaction.
+func CreateActivityPubActivity(ctx context.Context, db *sql.DB, activity domain.ActivityPubActivity, inboxes []string) (int64, error) {
+	createdAt := activity.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	created := createdAt.UTC().Format(time.RFC3339)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO activitypub_activity (identity_id, activity_id, type, payload, public, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		activity.IdentityID,
+		activity.ActivityID,
+		activity.Type,
+		activity.Payload,
+		activity.Public,
+		created,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	for _, inbox := range inboxes {
+		if _, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO activitypub_delivery (identity_id, activity_id, inbox, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?)",
+			activity.IdentityID,
+			id,
+			inbox,
+			created,
+			created,
+		); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// ListActivityPubOutbox returns an identity's newest public activities and their total count.
+func ListActivityPubOutbox(ctx context.Context, db *sql.DB, identityID, limit int) ([]domain.ActivityPubActivity, int, error) {
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM activitypub_activity WHERE identity_id = ? AND public = 1", identityID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id, identity_id, activity_id, type, payload, public, created_at FROM activitypub_activity WHERE identity_id = ? AND public = 1 ORDER BY id DESC LIMIT ?", identityID, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var activities []domain.ActivityPubActivity
+	for rows.Next() {
+		var activity domain.ActivityPubActivity
+		var created string
+		if err := rows.Scan(&activity.ID, &activity.IdentityID, &activity.ActivityID, &activity.Type, &activity.Payload, &activity.Public, &created); err != nil {
+			return nil, 0, err
+		}
+		activity.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		activities = append(activities, activity)
+	}
+	return activities, total, rows.Err()
+}
+
+// ListDueActivityPubDeliveries returns pending deliveries whose next attempt is at or before now, oldest first.
+func ListDueActivityPubDeliveries(ctx context.Context, db *sql.DB, now time.Time, limit int) ([]domain.ActivityPubDelivery, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT d.id, d.identity_id, d.activity_id, d.inbox, a.payload, d.attempts, d.next_attempt_at, d.last_error, d.delivered_at, d.created_at
+        FROM activitypub_delivery d JOIN activitypub_activity a ON a.id = d.activity_id
+        WHERE d.delivered_at IS NULL AND d.next_attempt_at IS NOT NULL AND d.next_attempt_at <= ?
+        ORDER BY d.next_attempt_at, d.id LIMIT ?`,
+		now.UTC().Format(time.RFC3339),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.ActivityPubDelivery
+	for rows.Next() {
+		var delivery domain.ActivityPubDelivery
+		var next, delivered sql.NullString
+		var lastError sql.NullString
+		var created string
+		if err := rows.Scan(&delivery.ID, &delivery.IdentityID, &delivery.ActivityID, &delivery.Inbox, &delivery.Payload, &delivery.Attempts, &next, &lastError, &delivered, &created); err != nil {
+			return nil, err
+		}
+		delivery.NextAttemptAt = parseNullTime(next)
+		delivery.DeliveredAt = parseNullTime(delivered)
+		delivery.LastError = lastError.String
+		delivery.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkActivityPubDelivered records a successful delivery.
+func MarkActivityPubDelivered(ctx context.Context, db *sql.DB, id int, deliveredAt time.Time) error {
+	_, err := db.ExecContext(
+		ctx,
+		"UPDATE activitypub_delivery SET attempts = attempts + 1, delivered_at = ?, next_attempt_at = NULL, last_error = NULL WHERE id = ?",
+		deliveredAt.UTC().Format(time.RFC3339),
+		id,
+	)
+	return err
+}
+
+// RecordActivityPubDeliveryFailure records a failed attempt; a null next attempt gives the delivery up.
+func RecordActivityPubDeliveryFailure(ctx context.Context, db *sql.DB, id int, nextAttemptAt sql.NullTime, lastError string) error {
+	_, err := db.ExecContext(
+		ctx,
+		"UPDATE activitypub_delivery SET attempts = attempts + 1, next_attempt_at = ?, last_error = ? WHERE id = ?",
+		nullTimeString(nextAttemptAt),
+		lastError,
+		id,
+	)
+	return err
+}
+
+// scanActivityPubActor scans a single activitypub_actor row.
+func scanActivityPubActor(row interface{ Scan(...interface{}) error }) (domain.ActivityPubActor, error) {
+	var actor domain.ActivityPubActor
+	var edPublic, edPrivate, baseURL, publishedHash, rotated sql.NullString
+	var created string
+	if err := row.Scan(&actor.IdentityID, &actor.PublicKeyPEM, &actor.PrivateKey, &edPublic, &edPrivate, &baseURL, &publishedHash, &created, &rotated); err != nil {
+		return domain.ActivityPubActor{}, err
+	}
+	actor.Ed25519PublicKey = edPublic.String
+	actor.Ed25519PrivateKey = edPrivate.String
+	actor.BaseURL = baseURL.String
+	actor.PublishedHash = publishedHash.String
+	actor.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	actor.RotatedAt = parseNullTime(rotated)
+	return actor, nil
+}
@@ -0,0 +1,102 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"pin/internal/domain"
+)
+
+const apiTokenColumns = "id, user_id, name, token_hash, prefix, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// CreateAPIToken stores a hashed API token.
+func CreateAPIToken(ctx context.Context, db *sql.DB, token domain.APIToken) (int64, error) {
+	createdAt := token.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	res, err := db.ExecContext(
+		ctx,
+		"INSERT INTO api_token (user_id, name, token_hash, prefix, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.Prefix,
+		strings.Join(token.Scopes, " "),
+		createdAt.UTC().Format(time.RFC3339),
+		nullTimeString(token.ExpiresAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ListAPITokens returns a user's API tokens, newest first.
+func ListAPITokens(ctx context.Context, db *sql.DB, userID int) ([]domain.APIToken, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+apiTokenColumns+" FROM api_token WHERE user_id = ? ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetAPITokenByHash returns the API token with the given hash.
+func GetAPITokenByHash(ctx context.Context, db *sql.DB, tokenHash string) (domain.APIToken, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+apiTokenColumns+" FROM api_token WHERE token_hash = ? LIMIT 1", tokenHash)
+	return scanAPIToken(row)
+}
+
+// TouchAPIToken records when a token was last used.
+func TouchAPIToken(ctx context.Context, db *sql.DB, id int, usedAt time.Time) error {
+	_, err := db.ExecContext(ctx, "UPDATE api_token SET last_used_at = ? WHERE id = ?", usedAt.UTC().Format(time.RFC3339), id)
+	return err
+}
+
+// RevokeAPIToken marks a user's token as revoked.
+func RevokeAPIToken(ctx context.Context, db *sql.DB, userID, id int) error {
+	res, err := db.ExecContext(ctx, "UPDATE api_token SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL", time.Now().UTC().Format(time.RFC3339), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// scanAPIToken scans a single api_token row.
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (domain.APIToken, error) {
+	var token domain.APIToken
+	var scopes, created string
+	var expires, lastUsed, revoked sql.NullString
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.Prefix, &scopes, &created, &expires, &lastUsed, &revoked); err != nil {
+		return domain.APIToken{}, err
+	}
+	token.Scopes = strings.Fields(scopes)
+	token.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	token.ExpiresAt = parseNullTime(expires)
+	token.LastUsedAt = parseNullTime(lastUsed)
+	token.RevokedAt = parseNullTime(revoked)
+	return token, nil
+}
+
+// nullTimeString formats a nullable time for storage.
+func nullTimeString(value sql.NullTime) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.Time.UTC().Format(time.RFC3339)
+}
@@ -10,31 +10,46 @@ import (
 	"pin/internal/domain"
 )
 
+// domainVerificationColumns are the domain_verification columns read by scanDomainVerifications.
+const domainVerificationColumns = "id, identity_id, domain, token, verified_at, COALESCE(method,''), last_checked_at, COALESCE(last_error,''), failing_since, created_at"
+
 // ListDomainVerifications returns the domain verifications list in the SQLite store.
 func ListDomainVerifications(ctx context.Context, db *sql.DB, identityID int) ([]domain.DomainVerification, error) {
-	rows, err := db.QueryContext(ctx, "SELECT id, identity_id, domain, token, verified_at, created_at FROM domain_verification WHERE identity_id = ? ORDER BY domain", identityID)
+	rows, err := db.QueryContext(ctx, "SELECT "+domainVerificationColumns+" FROM domain_verification WHERE identity_id = ? ORDER BY domain", identityID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	return scanDomainVerifications(rows)
+}
 
+// ListVerifiedDomainVerifications returns every verified domain across identities in the SQLite store.
+func ListVerifiedDomainVerifications(ctx context.Context, db *sql.DB) ([]domain.DomainVerification, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+domainVerificationColumns+" FROM domain_verification WHERE verified_at IS NOT NULL ORDER BY identity_id, domain")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanDomainVerifications(rows)
+}
+
+// scanDomainVerifications reads rows selected with domainVerificationColumns.
+func scanDomainVerifications(rows *sql.Rows) ([]domain.DomainVerification, error) {
 	var out []domain.DomainVerification
 	for rows.Next() {
 		var row domain.DomainVerification
-		var verified sql.NullString
+		var verified, checked, failing sql.NullString
 		var created string
-		if err := rows.Scan(&row.ID, &row.IdentityID, &row.Domain, &row.Token, &verified, &created); err != nil {
+		if err := rows.Scan(&row.ID, &row.IdentityID, &row.Domain, &row.Token, &verified, &row.Method, &checked, &row.LastError, &failing, &created); err != nil {
 			return nil, err
 		}
-		if verified.Valid {
-			if parsed, err := time.Parse(time.RFC3339, verified.String); err == nil {
-				row.VerifiedAt = sql.NullTime{Time: parsed, Valid: true}
-			}
-		}
+		row.VerifiedAt = parseNullTime(verified)
+		row.LastCheckedAt = parseNullTime(checked)
+		row.FailingSince = parseNullTime(failing)
 		row.CreatedAt, _ = time.Parse(time.RFC3339, created)
 		out = append(out, row)
 	}
-	return out, nil
+	return out, rows.Err()
 }
 
 // UpsertDomainVerification returns domain verification.
@@ -53,9 +68,31 @@ func DeleteDomainVerification(ctx context.Context, db *sql.DB, identityID int, d
 	return err
 }
 
-// MarkDomainVerified returns domain verified.
-func MarkDomainVerified(ctx context.Context, db *sql.DB, identityID int, domain string) error {
-	_, err := db.ExecContext(ctx, "UPDATE domain_verification SET verified_at = ? WHERE identity_id = ? AND domain = ?", time.Now().UTC().Format(time.RFC3339), identityID, domain)
+// MarkDomainVerified records that domain was verified with method; this counts as a passing check.
+func MarkDomainVerified(ctx context.Context, db *sql.DB, identityID int, domain, method string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := db.ExecContext(ctx, "UPDATE domain_verification SET verified_at = ?, method = ?, last_checked_at = ?, last_error = NULL, failing_since = NULL WHERE identity_id = ? AND domain = ?", now, method, now, identityID, domain)
+	return err
+}
+
+// RecordDomainCheck stores the outcome of a re-check; lastError is empty when it passed.
+func RecordDomainCheck(ctx context.Context, db *sql.DB, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error {
+	_, err := db.ExecContext(
+		ctx,
+		"UPDATE domain_verification SET last_checked_at = ?, last_error = ?, failing_since = ? WHERE id = ?",
+		checkedAt.UTC().Format(time.RFC3339), lastError, nullTimeString(failingSince), id,
+	)
+	return err
+}
+
+// DemoteDomainVerification clears a domain's verification after its re-checks kept failing.
+// The token is kept so the owner can verify again.
+func DemoteDomainVerification(ctx context.Context, db *sql.DB, id int, checkedAt time.Time, lastError string) error {
+	_, err := db.ExecContext(
+		ctx,
+		"UPDATE domain_verification SET verified_at = NULL, method = NULL, failing_since = NULL, last_checked_at = ?, last_error = ? WHERE id = ?",
+		checkedAt.UTC().Format(time.RFC3339), lastError, id,
+	)
 	return err
 }
 
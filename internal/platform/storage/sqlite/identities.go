@@ -15,7 +15,7 @@ import (
 func GetIdentityByID(ctx context.Context, db *sql.DB, id int) (domain.Identity, error) {
 	row := db.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE id = ?`,
+		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), COALESCE(nostr_relays,'[]'), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE id = ?`,
 		id,
 	)
 	return scanIdentity(row)
@@ -25,7 +25,7 @@ func GetIdentityByID(ctx context.Context, db *sql.DB, id int) (domain.Identity,
 func GetIdentityByHandle(ctx context.Context, db *sql.DB, handle string) (domain.Identity, error) {
 	row := db.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE lower(handle) = lower(?)`,
+		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), COALESCE(nostr_relays,'[]'), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE lower(handle) = lower(?)`,
 		handle,
 	)
 	return scanIdentity(row)
@@ -35,7 +35,7 @@ func GetIdentityByHandle(ctx context.Context, db *sql.DB, handle string) (domain
 func GetIdentityByPrivateToken(ctx context.Context, db *sql.DB, token string) (domain.Identity, error) {
 	row := db.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE private_token = ?`,
+		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), COALESCE(nostr_relays,'[]'), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE private_token = ?`,
 		token,
 	)
 	return scanIdentity(row)
@@ -45,7 +45,7 @@ func GetIdentityByPrivateToken(ctx context.Context, db *sql.DB, token string) (d
 func GetIdentityByUserID(ctx context.Context, db *sql.DB, userID int) (domain.Identity, error) {
 	row := db.QueryRowContext(
 		ctx,
-		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE user_id = ?`,
+		`SELECT id, user_id, handle, COALESCE(email,''), COALESCE(display_name,''), COALESCE(bio,''), COALESCE(organization,''), COALESCE(job_title,''), COALESCE(birthdate,''), COALESCE(languages,''), COALESCE(phone,''), COALESCE(address,''), COALESCE(custom_fields,'{}'), COALESCE(visibility,''), COALESCE(private_token,''), COALESCE(links,'[]'), COALESCE(social_profiles,'[]'), COALESCE(wallets,'{}'), COALESCE(public_keys,'{}'), COALESCE(location,''), COALESCE(website,''), COALESCE(pronouns,''), COALESCE(verified_domains,'[]'), COALESCE(atproto_handle,''), COALESCE(atproto_did,''), COALESCE(timezone,''), COALESCE(nostr_relays,'[]'), profile_picture_id, COALESCE(updated_at,'') FROM identity WHERE user_id = ?`,
 		userID,
 	)
 	return scanIdentity(row)
@@ -55,7 +55,7 @@ func GetIdentityByUserID(ctx context.Context, db *sql.DB, userID int) (domain.Id
 func GetOwnerIdentity(ctx context.Context, db *sql.DB) (domain.Identity, error) {
 	row := db.QueryRowContext(
 		ctx,
-		`SELECT identity.id, identity.user_id, identity.handle, COALESCE(identity.email,''), COALESCE(identity.display_name,''), COALESCE(identity.bio,''), COALESCE(identity.organization,''), COALESCE(identity.job_title,''), COALESCE(identity.birthdate,''), COALESCE(identity.languages,''), COALESCE(identity.phone,''), COALESCE(identity.address,''), COALESCE(identity.custom_fields,'{}'), COALESCE(identity.visibility,''), COALESCE(identity.private_token,''), COALESCE(identity.links,'[]'), COALESCE(identity.social_profiles,'[]'), COALESCE(identity.wallets,'{}'), COALESCE(identity.public_keys,'{}'), COALESCE(identity.location,''), COALESCE(identity.website,''), COALESCE(identity.pronouns,''), COALESCE(identity.verified_domains,'[]'), COALESCE(identity.atproto_handle,''), COALESCE(identity.atproto_did,''), COALESCE(identity.timezone,''), identity.profile_picture_id, COALESCE(identity.updated_at,'') FROM identity JOIN user ON identity.user_id = user.id WHERE user.role = 'owner' ORDER BY identity.id LIMIT 1`,
+		`SELECT identity.id, identity.user_id, identity.handle, COALESCE(identity.email,''), COALESCE(identity.display_name,''), COALESCE(identity.bio,''), COALESCE(identity.organization,''), COALESCE(identity.job_title,''), COALESCE(identity.birthdate,''), COALESCE(identity.languages,''), COALESCE(identity.phone,''), COALESCE(identity.address,''), COALESCE(identity.custom_fields,'{}'), COALESCE(identity.visibility,''), COALESCE(identity.private_token,''), COALESCE(identity.links,'[]'), COALESCE(identity.social_profiles,'[]'), COALESCE(identity.wallets,'{}'), COALESCE(identity.public_keys,'{}'), COALESCE(identity.location,''), COALESCE(identity.website,''), COALESCE(identity.pronouns,''), COALESCE(identity.verified_domains,'[]'), COALESCE(identity.atproto_handle,''), COALESCE(identity.atproto_did,''), COALESCE(identity.timezone,''), COALESCE(identity.nostr_relays,'[]'), identity.profile_picture_id, COALESCE(identity.updated_at,'') FROM identity JOIN user ON identity.user_id = user.id WHERE user.role = 'owner' ORDER BY identity.id LIMIT 1`,
 	)
 	return scanIdentity(row)
 }
@@ -151,8 +151,8 @@ func CreateIdentity(ctx context.Context, db *sql.DB, identity domain.Identity) (
 	now := time.Now().UTC().Format(time.RFC3339)
 	res, err := db.ExecContext(
 		ctx,
-		`INSERT INTO identity (user_id, handle, email, display_name, bio, organization, job_title, birthdate, languages, phone, address, custom_fields, visibility, private_token, links, social_profiles, wallets, public_keys, location, website, pronouns, verified_domains, atproto_handle, atproto_did, timezone, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		identity.UserID, identity.Handle, identity.Email, identity.DisplayName, identity.Bio, identity.Organization, identity.JobTitle, identity.Birthdate, identity.Languages, identity.Phone, identity.Address, identity.CustomFieldsJSON, identity.VisibilityJSON, identity.PrivateToken, identity.LinksJSON, identity.SocialProfilesJSON, identity.WalletsJSON, identity.PublicKeysJSON, identity.Location, identity.Website, identity.Pronouns, identity.VerifiedDomainsJSON, identity.ATProtoHandle, identity.ATProtoDID, identity.Timezone, now,
+		`INSERT INTO identity (user_id, handle, email, display_name, bio, organization, job_title, birthdate, languages, phone, address, custom_fields, visibility, private_token, links, social_profiles, wallets, public_keys, location, website, pronouns, verified_domains, atproto_handle, atproto_did, timezone, nostr_relays, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		identity.UserID, identity.Handle, identity.Email, identity.DisplayName, identity.Bio, identity.Organization, identity.JobTitle, identity.Birthdate, identity.Languages, identity.Phone, identity.Address, identity.CustomFieldsJSON, identity.VisibilityJSON, identity.PrivateToken, identity.LinksJSON, identity.SocialProfilesJSON, identity.WalletsJSON, identity.PublicKeysJSON, identity.Location, identity.Website, identity.Pronouns, identity.VerifiedDomainsJSON, identity.ATProtoHandle, identity.ATProtoDID, identity.Timezone, identity.NostrRelaysJSON, now,
 	)
 	if err != nil {
 		return 0, err
@@ -164,8 +164,8 @@ func CreateIdentity(ctx context.Context, db *sql.DB, identity domain.Identity) (
 func UpdateIdentity(ctx context.Context, db *sql.DB, identity domain.Identity) error {
 	_, err := db.ExecContext(
 		ctx,
-		`UPDATE identity SET handle = ?, email = ?, display_name = ?, bio = ?, organization = ?, job_title = ?, birthdate = ?, languages = ?, phone = ?, address = ?, custom_fields = ?, visibility = ?, private_token = ?, links = ?, social_profiles = ?, wallets = ?, public_keys = ?, location = ?, website = ?, pronouns = ?, verified_domains = ?, atproto_handle = ?, atproto_did = ?, timezone = ?, profile_picture_id = ?, updated_at = ? WHERE id = ?`,
-		identity.Handle, identity.Email, identity.DisplayName, identity.Bio, identity.Organization, identity.JobTitle, identity.Birthdate, identity.Languages, identity.Phone, identity.Address, identity.CustomFieldsJSON, identity.VisibilityJSON, identity.PrivateToken, identity.LinksJSON, identity.SocialProfilesJSON, identity.WalletsJSON, identity.PublicKeysJSON, identity.Location, identity.Website, identity.Pronouns, identity.VerifiedDomainsJSON, identity.ATProtoHandle, identity.ATProtoDID, identity.Timezone, nullInt(identity.ProfilePictureID), time.Now().UTC().Format(time.RFC3339), identity.ID,
+		`UPDATE identity SET handle = ?, email = ?, display_name = ?, bio = ?, organization = ?, job_title = ?, birthdate = ?, languages = ?, phone = ?, address = ?, custom_fields = ?, visibility = ?, private_token = ?, links = ?, social_profiles = ?, wallets = ?, public_keys = ?, location = ?, website = ?, pronouns = ?, verified_domains = ?, atproto_handle = ?, atproto_did = ?, timezone = ?, nostr_relays = ?, profile_picture_id = ?, updated_at = ? WHERE id = ?`,
+		identity.Handle, identity.Email, identity.DisplayName, identity.Bio, identity.Organization, identity.JobTitle, identity.Birthdate, identity.Languages, identity.Phone, identity.Address, identity.CustomFieldsJSON, identity.VisibilityJSON, identity.PrivateToken, identity.LinksJSON, identity.SocialProfilesJSON, identity.WalletsJSON, identity.PublicKeysJSON, identity.Location, identity.Website, identity.Pronouns, identity.VerifiedDomainsJSON, identity.ATProtoHandle, identity.ATProtoDID, identity.Timezone, identity.NostrRelaysJSON, nullInt(identity.ProfilePictureID), time.Now().UTC().Format(time.RFC3339), identity.ID,
 	)
 	return err
 }
@@ -200,6 +200,14 @@ func CheckHandleCollision(ctx context.Context, db *sql.DB, handle string, exclud
 
 // DeleteIdentity deletes identity in the SQLite store.
 func DeleteIdentity(ctx context.Context, db *sql.DB, identityID int) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM identity_revision WHERE identity_id = ?", identityID); err != nil {
+		return err
+	}
+	for _, table := range activityPubTables {
+		if _, err := db.ExecContext(ctx, "DELETE FROM "+table+" WHERE identity_id = ?", identityID); err != nil {
+			return err
+		}
+	}
 	_, err := db.ExecContext(ctx, "DELETE FROM identity WHERE id = ?", identityID)
 	return err
 }
@@ -235,6 +243,7 @@ func scanIdentity(row *sql.Row) (domain.Identity, error) {
 		&identity.ATProtoHandle,
 		&identity.ATProtoDID,
 		&identity.Timezone,
+		&identity.NostrRelaysJSON,
 		&identity.ProfilePictureID,
 		&updatedAt,
 	); err != nil {
@@ -0,0 +1,77 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// CreateIdentityRevision stores an immutable identity snapshot.
+func CreateIdentityRevision(ctx context.Context, db *sql.DB, revision domain.IdentityRevision) (int64, error) {
+	createdAt := revision.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	res, err := db.ExecContext(
+		ctx,
+		"INSERT INTO identity_revision (identity_id, rev, snapshot, actor_id, note, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		revision.IdentityID,
+		revision.Rev,
+		revision.Snapshot,
+		nullInt(revision.ActorID),
+		revision.Note,
+		createdAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ListIdentityRevisions returns revisions for an identity, newest first.
+func ListIdentityRevisions(ctx context.Context, db *sql.DB, identityID, limit int) ([]domain.IdentityRevision, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := db.QueryContext(
+		ctx,
+		"SELECT id, identity_id, rev, snapshot, actor_id, COALESCE(note,''), created_at FROM identity_revision WHERE identity_id = ? ORDER BY id DESC LIMIT ?",
+		identityID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []domain.IdentityRevision
+	for rows.Next() {
+		var revision domain.IdentityRevision
+		var created string
+		if err := rows.Scan(&revision.ID, &revision.IdentityID, &revision.Rev, &revision.Snapshot, &revision.ActorID, &revision.Note, &created); err != nil {
+			return nil, err
+		}
+		revision.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetIdentityRevision returns a single revision scoped to its identity.
+func GetIdentityRevision(ctx context.Context, db *sql.DB, identityID, revisionID int) (domain.IdentityRevision, error) {
+	row := db.QueryRowContext(
+		ctx,
+		"SELECT id, identity_id, rev, snapshot, actor_id, COALESCE(note,''), created_at FROM identity_revision WHERE identity_id = ? AND id = ?",
+		identityID,
+		revisionID,
+	)
+	var revision domain.IdentityRevision
+	var created string
+	if err := row.Scan(&revision.ID, &revision.IdentityID, &revision.Rev, &revision.Snapshot, &revision.ActorID, &revision.Note, &created); err != nil {
+		return domain.IdentityRevision{}, err
+	}
+	revision.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	return revision, nil
+}
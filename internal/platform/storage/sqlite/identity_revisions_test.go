@@ -0,0 +1,41 @@
+package sqlitestore
+
+import (
+	"context"
+	"testing"
+
+	"pin/internal/domain"
+)
+
+// TestIdentityRevisionsAreImmutable verifies stored revisions reject updates.
+func TestIdentityRevisionsAreImmutable(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+	if _, err := Migrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for _, snapshot := range []string{`{"handle":"a"}`, `{"handle":"b"}`} {
+		if _, err := CreateIdentityRevision(ctx, db, domain.IdentityRevision{IdentityID: 1, Rev: "sha256:x", Snapshot: snapshot}); err != nil {
+			t.Fatalf("create revision: %v", err)
+		}
+	}
+	revisions, err := ListIdentityRevisions(ctx, db, 1, 10)
+	if err != nil {
+		t.Fatalf("list revisions: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Snapshot != `{"handle":"b"}` {
+		t.Fatalf("expected newest first, got %+v", revisions)
+	}
+
+	if _, err := db.ExecContext(ctx, "UPDATE identity_revision SET snapshot = '{}' WHERE id = ?", revisions[0].ID); err == nil {
+		t.Fatalf("expected update to be rejected")
+	}
+	got, err := GetIdentityRevision(ctx, db, 1, revisions[0].ID)
+	if err != nil {
+		t.Fatalf("get revision: %v", err)
+	}
+	if got.Snapshot != `{"handle":"b"}` {
+		t.Fatalf("revision changed: %s", got.Snapshot)
+	}
+}
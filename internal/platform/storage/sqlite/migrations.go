@@ -0,0 +1,377 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer binary.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// migration is a numbered forward-only schema change.
+type migration struct {
+	version int
+	name    string
+	stmts   []string
+}
+
+// migrations lists every schema change in order. Append new entries; never edit applied ones.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "baseline",
+		// The baseline stays idempotent so databases created before versioning adopt it cleanly.
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS user (
+                id INTEGER PRIMARY KEY,
+                role TEXT,
+                password_hash TEXT NOT NULL,
+                totp_secret TEXT NOT NULL,
+                theme_profile TEXT,
+                theme_custom_css_path TEXT,
+                theme_custom_css_inline TEXT,
+                updated_at TEXT
+            )`,
+			`CREATE TABLE IF NOT EXISTS identity (
+                id INTEGER PRIMARY KEY,
+                user_id INTEGER NOT NULL,
+                handle TEXT UNIQUE NOT NULL,
+                email TEXT,
+                display_name TEXT,
+                bio TEXT,
+                organization TEXT,
+                job_title TEXT,
+                birthdate TEXT,
+                languages TEXT,
+                phone TEXT,
+                address TEXT,
+                custom_fields TEXT,
+                visibility TEXT,
+                private_token TEXT,
+                links TEXT,
+                social_profiles TEXT,
+                wallets TEXT,
+                public_keys TEXT,
+                location TEXT,
+                website TEXT,
+                pronouns TEXT,
+                verified_domains TEXT,
+                atproto_handle TEXT,
+                atproto_did TEXT,
+                timezone TEXT,
+                profile_picture_id INTEGER,
+                updated_at TEXT,
+                UNIQUE(user_id)
+            )`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_identity_handle_nocase ON identity(lower(handle))`,
+			`CREATE TABLE IF NOT EXISTS settings (
+                key TEXT PRIMARY KEY,
+                value TEXT
+            )`,
+			`INSERT INTO settings (key, value) VALUES ('footer_link_about', '1') ON CONFLICT(key) DO NOTHING`,
+			`INSERT INTO settings (key, value) VALUES ('footer_link_login', '1') ON CONFLICT(key) DO NOTHING`,
+			`CREATE TABLE IF NOT EXISTS invite (
+                id INTEGER PRIMARY KEY,
+                token TEXT UNIQUE NOT NULL,
+                role TEXT NOT NULL,
+                created_by INTEGER NOT NULL,
+                created_at TEXT NOT NULL,
+                used_at TEXT,
+                used_by INTEGER,
+                used_by_name TEXT
+            )`,
+			`CREATE TABLE IF NOT EXISTS passkey (
+                id INTEGER PRIMARY KEY,
+                user_id INTEGER NOT NULL,
+                name TEXT NOT NULL,
+                credential_id TEXT NOT NULL UNIQUE,
+                credential_json TEXT NOT NULL,
+                created_at TEXT NOT NULL,
+                last_used_at TEXT
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_passkey_user ON passkey(user_id)`,
+			`CREATE TABLE IF NOT EXISTS domain_verification (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                domain TEXT NOT NULL,
+                token TEXT NOT NULL,
+                verified_at TEXT,
+                created_at TEXT NOT NULL,
+                UNIQUE(identity_id, domain)
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_domain_verification_identity ON domain_verification(identity_id)`,
+			`CREATE TABLE IF NOT EXISTS audit_log (
+                id INTEGER PRIMARY KEY,
+                actor_id INTEGER,
+                actor_name TEXT,
+                action TEXT NOT NULL,
+                target TEXT,
+                metadata TEXT,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at)`,
+			`CREATE TABLE IF NOT EXISTS profile_picture (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                filename TEXT NOT NULL,
+                alt_text TEXT,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_profile_picture_identity ON profile_picture(identity_id)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "identity_revisions",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS identity_revision (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                rev TEXT NOT NULL,
+                snapshot TEXT NOT NULL,
+                actor_id INTEGER,
+                note TEXT,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_identity_revision_identity ON identity_revision(identity_id, id)`,
+			`CREATE INDEX IF NOT EXISTS idx_identity_revision_rev ON identity_revision(identity_id, rev)`,
+			`CREATE TRIGGER IF NOT EXISTS identity_revision_immutable BEFORE UPDATE ON identity_revision
+            BEGIN
+                SELECT RAISE(ABORT, 'identity revisions are immutable');
+            END`,
+		},
+	},
+	{
+		version: 3,
+		name:    "api_tokens",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS api_token (
+                id INTEGER PRIMARY KEY,
+                user_id INTEGER NOT NULL,
+                name TEXT NOT NULL,
+                token_hash TEXT NOT NULL UNIQUE,
+                prefix TEXT NOT NULL,
+                scopes TEXT NOT NULL,
+                created_at TEXT NOT NULL,
+                expires_at TEXT,
+                last_used_at TEXT,
+                revoked_at TEXT
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_api_token_user ON api_token(user_id)`,
+		},
+	},
+	{
+		version: 4,
+		name:    "activitypub",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS activitypub_actor (
+                identity_id INTEGER PRIMARY KEY,
+                public_key_pem TEXT NOT NULL,
+                private_key_pem TEXT NOT NULL,
+                base_url TEXT,
+                published_hash TEXT,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE TABLE IF NOT EXISTS activitypub_follower (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                actor_id TEXT NOT NULL,
+                inbox TEXT NOT NULL,
+                shared_inbox TEXT,
+                follow_id TEXT,
+                created_at TEXT NOT NULL,
+                UNIQUE(identity_id, actor_id)
+            )`,
+			`CREATE TABLE IF NOT EXISTS activitypub_activity (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                activity_id TEXT NOT NULL UNIQUE,
+                type TEXT NOT NULL,
+                payload TEXT NOT NULL,
+                public INTEGER NOT NULL DEFAULT 0,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_activitypub_activity_identity ON activitypub_activity(identity_id, public, id)`,
+			`CREATE TABLE IF NOT EXISTS activitypub_delivery (
+                id INTEGER PRIMARY KEY,
+                identity_id INTEGER NOT NULL,
+                activity_id INTEGER NOT NULL,
+                inbox TEXT NOT NULL,
+                attempts INTEGER NOT NULL DEFAULT 0,
+                next_attempt_at TEXT,
+                last_error TEXT,
+                delivered_at TEXT,
+                created_at TEXT NOT NULL
+            )`,
+			`CREATE INDEX IF NOT EXISTS idx_activitypub_delivery_due ON activitypub_delivery(next_attempt_at)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "activitypub_key_rotation",
+		stmts: []string{
+			`ALTER TABLE activitypub_actor ADD COLUMN ed25519_public_key TEXT`,
+			`ALTER TABLE activitypub_actor ADD COLUMN ed25519_private_key TEXT`,
+			`ALTER TABLE activitypub_actor ADD COLUMN rotated_at TEXT`,
+		},
+	},
+	{
+		version: 6,
+		name:    "identity_nostr_relays",
+		stmts: []string{
+			`ALTER TABLE identity ADD COLUMN nostr_relays TEXT`,
+		},
+	},
+	{
+		version: 7,
+		name:    "domain_verification_method",
+		stmts: []string{
+			`ALTER TABLE domain_verification ADD COLUMN method TEXT`,
+		},
+	},
+	{
+		version: 8,
+		name:    "domain_verification_recheck",
+		stmts: []string{
+			`ALTER TABLE domain_verification ADD COLUMN last_checked_at TEXT`,
+			`ALTER TABLE domain_verification ADD COLUMN last_error TEXT`,
+			`ALTER TABLE domain_verification ADD COLUMN failing_since TEXT`,
+		},
+	},
+}
+
+// MigrationStatus describes a known migration and whether it has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LatestSchemaVersion returns the newest schema version known to this binary.
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the highest migration version recorded in the database.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	if err := ensureSchemaVersionTable(ctx, db); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// CheckSchemaVersion returns ErrSchemaTooNew when the database is ahead of this binary.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); version > latest {
+		return fmt.Errorf("%w (database at %d, binary supports %d)", ErrSchemaTooNew, version, latest)
+	}
+	return nil
+}
+
+// ListMigrationStatus returns every known migration with its applied state.
+func ListMigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureSchemaVersionTable(ctx, db); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		parsed, _ := time.Parse(time.RFC3339, appliedAt)
+		applied[version] = parsed
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		out = append(out, MigrationStatus{
+			Version:   m.version,
+			Name:      m.name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return out, nil
+}
+
+// Migrate applies pending migrations in order, each inside its own transaction, and returns the versions applied.
+func Migrate(ctx context.Context, db *sql.DB) ([]int, error) {
+	if err := CheckSchemaVersion(ctx, db); err != nil {
+		return nil, err
+	}
+	current, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var applied []int
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		applied = append(applied, m.version)
+	}
+	return applied, nil
+}
+
+// applyMigration runs a migration and records its version atomically.
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	for _, stmt := range m.stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version,
+		m.name,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensureSchemaVersionTable creates the migration bookkeeping table when missing.
+func ensureSchemaVersionTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (
+        version INTEGER PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TEXT NOT NULL
+    )`)
+	return err
+}
@@ -0,0 +1,100 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// TestMigrateRecordsVersionsAndIsIdempotent verifies migrate records versions and is idempotent behavior.
+func TestMigrateRecordsVersionsAndIsIdempotent(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+
+	applied, err := Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d migrations applied, got %v", len(migrations), applied)
+	}
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("schema version: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Fatalf("expected version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	applied, err = Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected no pending migrations, got %v", applied)
+	}
+	statuses, err := ListMigrationStatus(ctx, db)
+	if err != nil {
+		t.Fatalf("list status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt.IsZero() {
+			t.Fatalf("expected migration %d applied, got %+v", status.Version, status)
+		}
+	}
+}
+
+// TestMigrateAdoptsUnversionedDatabase verifies migrate adopts unversioned database behavior.
+func TestMigrateAdoptsUnversionedDatabase(t *testing.T) {
+	db := openMigrationTestDB(t)
+
+	// Simulate a database created before schema_version existed.
+	if _, err := db.Exec(`CREATE TABLE user (id INTEGER PRIMARY KEY, role TEXT, password_hash TEXT NOT NULL, totp_secret TEXT NOT NULL, theme_profile TEXT, theme_custom_css_path TEXT, theme_custom_css_inline TEXT, updated_at TEXT)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO user (id, role, password_hash, totp_secret) VALUES (1, 'owner', 'h', 's')`); err != nil {
+		t.Fatalf("insert legacy user: %v", err)
+	}
+	if err := InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM user`).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("expected legacy row preserved, got %d (%v)", count, err)
+	}
+}
+
+// TestMigrateRefusesNewerDatabase verifies migrate refuses newer database behavior.
+func TestMigrateRefusesNewerDatabase(t *testing.T) {
+	db := openMigrationTestDB(t)
+	ctx := context.Background()
+	if err := InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', '2099-01-01T00:00:00Z')`, LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	if _, err := Migrate(ctx, db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+	if err := InitDB(db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected InitDB to refuse newer schema, got %v", err)
+	}
+}
+
+// openMigrationTestDB opens a single-connection in-memory database.
+func openMigrationTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
@@ -3,6 +3,7 @@ package sqlitestore
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/go-webauthn/webauthn/webauthn"
 	"pin/internal/contracts"
@@ -25,6 +26,8 @@ func NewRepos(db *sql.DB) contracts.Repos {
 		Domains:         r,
 		ProfilePictures: r,
 		Settings:        r,
+		APITokens:       r,
+		ActivityPub:     r,
 	}
 }
 
@@ -138,6 +141,21 @@ func (r repos) DeleteIdentity(ctx context.Context, identityID int) error {
 	return DeleteIdentity(ctx, r.db, identityID)
 }
 
+// CreateIdentityRevision stores an identity revision in the SQLite store.
+func (r repos) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	return CreateIdentityRevision(ctx, r.db, revision)
+}
+
+// ListIdentityRevisions returns identity revisions, newest first, in the SQLite store.
+func (r repos) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	return ListIdentityRevisions(ctx, r.db, identityID, limit)
+}
+
+// GetIdentityRevision returns an identity revision by ID in the SQLite store.
+func (r repos) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	return GetIdentityRevision(ctx, r.db, identityID, revisionID)
+}
+
 // InvitesStore
 func (r repos) CreateInvite(ctx context.Context, token, role string, createdBy int) error {
 	return CreateInvite(ctx, r.db, token, role, createdBy)
@@ -228,9 +246,24 @@ func (r repos) DeleteDomainVerification(ctx context.Context, identityID int, dom
 	return DeleteDomainVerification(ctx, r.db, identityID, domain)
 }
 
-// MarkDomainVerified returns domain verified.
-func (r repos) MarkDomainVerified(ctx context.Context, identityID int, domain string) error {
-	return MarkDomainVerified(ctx, r.db, identityID, domain)
+// MarkDomainVerified records that domain was verified with method in the SQLite store.
+func (r repos) MarkDomainVerified(ctx context.Context, identityID int, domain, method string) error {
+	return MarkDomainVerified(ctx, r.db, identityID, domain, method)
+}
+
+// ListVerifiedDomainVerifications returns every verified domain across identities in the SQLite store.
+func (r repos) ListVerifiedDomainVerifications(ctx context.Context) ([]domain.DomainVerification, error) {
+	return ListVerifiedDomainVerifications(ctx, r.db)
+}
+
+// RecordDomainCheck stores the outcome of a domain re-check in the SQLite store.
+func (r repos) RecordDomainCheck(ctx context.Context, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error {
+	return RecordDomainCheck(ctx, r.db, id, checkedAt, lastError, failingSince)
+}
+
+// DemoteDomainVerification clears a domain's verification in the SQLite store.
+func (r repos) DemoteDomainVerification(ctx context.Context, id int, checkedAt time.Time, lastError string) error {
+	return DemoteDomainVerification(ctx, r.db, id, checkedAt, lastError)
 }
 
 // UpdateIdentityVerifiedDomains updates identity verified domains using the supplied data in the SQLite store.
@@ -320,3 +353,93 @@ func (r repos) SetSettings(ctx context.Context, values map[string]string) error
 func (r repos) DeleteSetting(ctx context.Context, key string) error {
 	return DeleteSetting(ctx, r.db, key)
 }
+
+// APITokensStore
+func (r repos) CreateAPIToken(ctx context.Context, token domain.APIToken) (int64, error) {
+	return CreateAPIToken(ctx, r.db, token)
+}
+
+// ListAPITokens returns a user's API tokens in the SQLite store.
+func (r repos) ListAPITokens(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	return ListAPITokens(ctx, r.db, userID)
+}
+
+// GetAPITokenByHash returns an API token by hash in the SQLite store.
+func (r repos) GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error) {
+	return GetAPITokenByHash(ctx, r.db, tokenHash)
+}
+
+// TouchAPIToken records API token use in the SQLite store.
+func (r repos) TouchAPIToken(ctx context.Context, id int, usedAt time.Time) error {
+	return TouchAPIToken(ctx, r.db, id, usedAt)
+}
+
+// RevokeAPIToken revokes an API token in the SQLite store.
+func (r repos) RevokeAPIToken(ctx context.Context, userID, id int) error {
+	return RevokeAPIToken(ctx, r.db, userID, id)
+}
+
+// ActivityPubStore
+func (r repos) GetActivityPubActor(ctx context.Context, identityID int) (domain.ActivityPubActor, error) {
+	return GetActivityPubActor(ctx, r.db, identityID)
+}
+
+// ListActivityPubActors returns every ActivityPub actor in the SQLite store.
+func (r repos) ListActivityPubActors(ctx context.Context) ([]domain.ActivityPubActor, error) {
+	return ListActivityPubActors(ctx, r.db)
+}
+
+// CreateActivityPubActor stores an ActivityPub actor key in the SQLite store.
+func (r repos) CreateActivityPubActor(ctx context.Context, actor domain.ActivityPubActor) error {
+	return CreateActivityPubActor(ctx, r.db, actor)
+}
+
+// UpdateActivityPubActorKeys replaces an actor's keys in the SQLite store.
+func (r repos) UpdateActivityPubActorKeys(ctx context.Context, actor domain.ActivityPubActor) error {
+	return UpdateActivityPubActorKeys(ctx, r.db, actor)
+}
+
+// UpdateActivityPubActorState records actor publish state in the SQLite store.
+func (r repos) UpdateActivityPubActorState(ctx context.Context, identityID int, baseURL, publishedHash string) error {
+	return UpdateActivityPubActorState(ctx, r.db, identityID, baseURL, publishedHash)
+}
+
+// AddActivityPubFollower stores a follower in the SQLite store.
+func (r repos) AddActivityPubFollower(ctx context.Context, follower domain.ActivityPubFollower) error {
+	return AddActivityPubFollower(ctx, r.db, follower)
+}
+
+// RemoveActivityPubFollower deletes a follower in the SQLite store.
+func (r repos) RemoveActivityPubFollower(ctx context.Context, identityID int, actorID string) error {
+	return RemoveActivityPubFollower(ctx, r.db, identityID, actorID)
+}
+
+// ListActivityPubFollowers returns an identity's followers in the SQLite store.
+func (r repos) ListActivityPubFollowers(ctx context.Context, identityID int) ([]domain.ActivityPubFollower, error) {
+	return ListActivityPubFollowers(ctx, r.db, identityID)
+}
+
+// CreateActivityPubActivity stores an activity and its deliveries in the SQLite store.
+func (r repos) CreateActivityPubActivity(ctx context.Context, activity domain.ActivityPubActivity, inboxes []string) (int64, error) {
+	return CreateActivityPubActivity(ctx, r.db, activity, inboxes)
+}
+
+// ListActivityPubOutbox returns an identity's public activities in the SQLite store.
+func (r repos) ListActivityPubOutbox(ctx context.Context, identityID, limit int) ([]domain.ActivityPubActivity, int, error) {
+	return ListActivityPubOutbox(ctx, r.db, identityID, limit)
+}
+
+// ListDueActivityPubDeliveries returns due deliveries in the SQLite store.
+func (r repos) ListDueActivityPubDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.ActivityPubDelivery, error) {
+	return ListDueActivityPubDeliveries(ctx, r.db, now, limit)
+}
+
+// MarkActivityPubDelivered records a successful delivery in the SQLite store.
+func (r repos) MarkActivityPubDelivered(ctx context.Context, id int, deliveredAt time.Time) error {
+	return MarkActivityPubDelivered(ctx, r.db, id, deliveredAt)
+}
+
+// RecordActivityPubDeliveryFailure records a failed delivery attempt in the SQLite store.
+func (r repos) RecordActivityPubDeliveryFailure(ctx context.Context, id int, nextAttemptAt sql.NullTime, lastError string) error {
+	return RecordActivityPubDeliveryFailure(ctx, r.db, id, nextAttemptAt, lastError)
+}
@@ -169,6 +169,16 @@ func DeleteUser(ctx context.Context, db *sql.DB, userID int) error {
 		_ = tx.Rollback()
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM identity_revision WHERE identity_id IN (SELECT id FROM identity WHERE user_id = ?)", userID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, table := range activityPubTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE identity_id IN (SELECT id FROM identity WHERE user_id = ?)", userID); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
 	if _, err := tx.ExecContext(ctx, "DELETE FROM identity WHERE user_id = ?", userID); err != nil {
 		_ = tx.Rollback()
 		return err
@@ -177,6 +187,10 @@ func DeleteUser(ctx context.Context, db *sql.DB, userID int) error {
 		_ = tx.Rollback()
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM api_token WHERE user_id = ?", userID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 	if _, err := tx.ExecContext(ctx, "DELETE FROM user WHERE id = ?", userID); err != nil {
 		_ = tx.Rollback()
 		return err
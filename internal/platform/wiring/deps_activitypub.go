@@ -0,0 +1,74 @@
+package wiring
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// ActivityPub actors, followers and deliveries.
+func (d Deps) GetActivityPubActor(ctx context.Context, identityID int) (domain.ActivityPubActor, error) {
+	return d.repos.ActivityPub.GetActivityPubActor(ctx, identityID)
+}
+
+// ListActivityPubActors returns every ActivityPub actor.
+func (d Deps) ListActivityPubActors(ctx context.Context) ([]domain.ActivityPubActor, error) {
+	return d.repos.ActivityPub.ListActivityPubActors(ctx)
+}
+
+// CreateActivityPubActor stores an ActivityPub actor key.
+func (d Deps) CreateActivityPubActor(ctx context.Context, actor domain.ActivityPubActor) error {
+	return d.repos.ActivityPub.CreateActivityPubActor(ctx, actor)
+}
+
+// UpdateActivityPubActorKeys replaces an ActivityPub actor's keys.
+func (d Deps) UpdateActivityPubActorKeys(ctx context.Context, actor domain.ActivityPubActor) error {
+	return d.repos.ActivityPub.UpdateActivityPubActorKeys(ctx, actor)
+}
+
+// UpdateActivityPubActorState records the base URL and hash an actor was last published with.
+func (d Deps) UpdateActivityPubActorState(ctx context.Context, identityID int, baseURL, publishedHash string) error {
+	return d.repos.ActivityPub.UpdateActivityPubActorState(ctx, identityID, baseURL, publishedHash)
+}
+
+// AddActivityPubFollower stores a follower.
+func (d Deps) AddActivityPubFollower(ctx context.Context, follower domain.ActivityPubFollower) error {
+	return d.repos.ActivityPub.AddActivityPubFollower(ctx, follower)
+}
+
+// RemoveActivityPubFollower deletes a follower.
+func (d Deps) RemoveActivityPubFollower(ctx context.Context, identityID int, actorID string) error {
+	return d.repos.ActivityPub.RemoveActivityPubFollower(ctx, identityID, actorID)
+}
+
+// ListActivityPubFollowers returns an identity's followers.
+func (d Deps) ListActivityPubFollowers(ctx context.Context, identityID int) ([]domain.ActivityPubFollower, error) {
+	return d.repos.ActivityPub.ListActivityPubFollowers(ctx, identityID)
+}
+
+// CreateActivityPubActivity stores an activity and queues its deliveries.
+func (d Deps) CreateActivityPubActivity(ctx context.Context, activity domain.ActivityPubActivity, inboxes []string) (int64, error) {
+	return d.repos.ActivityPub.CreateActivityPubActivity(ctx, activity, inboxes)
+}
+
+// ListActivityPubOutbox returns an identity's newest public activities and their total.
+func (d Deps) ListActivityPubOutbox(ctx context.Context, identityID, limit int) ([]domain.ActivityPubActivity, int, error) {
+	return d.repos.ActivityPub.ListActivityPubOutbox(ctx, identityID, limit)
+}
+
+// ListDueActivityPubDeliveries returns deliveries ready for another attempt.
+func (d Deps) ListDueActivityPubDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.ActivityPubDelivery, error) {
+	return d.repos.ActivityPub.ListDueActivityPubDeliveries(ctx, now, limit)
+}
+
+// MarkActivityPubDelivered records a successful delivery.
+func (d Deps) MarkActivityPubDelivered(ctx context.Context, id int, deliveredAt time.Time) error {
+	return d.repos.ActivityPub.MarkActivityPubDelivered(ctx, id, deliveredAt)
+}
+
+// RecordActivityPubDeliveryFailure records a failed delivery attempt.
+func (d Deps) RecordActivityPubDeliveryFailure(ctx context.Context, id int, nextAttemptAt sql.NullTime, lastError string) error {
+	return d.repos.ActivityPub.RecordActivityPubDeliveryFailure(ctx, id, nextAttemptAt, lastError)
+}
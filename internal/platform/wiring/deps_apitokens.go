@@ -0,0 +1,33 @@
+package wiring
+
+import (
+	"context"
+	"time"
+
+	"pin/internal/domain"
+)
+
+// API tokens.
+func (d Deps) CreateAPIToken(ctx context.Context, token domain.APIToken) (int64, error) {
+	return d.repos.APITokens.CreateAPIToken(ctx, token)
+}
+
+// ListAPITokens returns a user's API tokens by delegating to configured services.
+func (d Deps) ListAPITokens(ctx context.Context, userID int) ([]domain.APIToken, error) {
+	return d.repos.APITokens.ListAPITokens(ctx, userID)
+}
+
+// GetAPITokenByHash returns an API token by hash.
+func (d Deps) GetAPITokenByHash(ctx context.Context, tokenHash string) (domain.APIToken, error) {
+	return d.repos.APITokens.GetAPITokenByHash(ctx, tokenHash)
+}
+
+// TouchAPIToken records API token use.
+func (d Deps) TouchAPIToken(ctx context.Context, id int, usedAt time.Time) error {
+	return d.repos.APITokens.TouchAPIToken(ctx, id, usedAt)
+}
+
+// RevokeAPIToken revokes an API token.
+func (d Deps) RevokeAPIToken(ctx context.Context, userID, id int) error {
+	return d.repos.APITokens.RevokeAPIToken(ctx, userID, id)
+}
@@ -20,3 +20,8 @@ func (d Deps) CountAuditLogs(ctx context.Context) (int, error) {
 func (d Deps) ListAllAuditLogs(ctx context.Context) ([]domain.AuditLog, error) {
 	return d.repos.Audit.ListAllAuditLogs(ctx)
 }
+
+// WriteAuditLog records an audit entry by delegating to configured services.
+func (d Deps) WriteAuditLog(ctx context.Context, actorID int, action, target string, metadata map[string]string) error {
+	return d.repos.Audit.WriteAuditLog(ctx, actorID, action, target, metadata)
+}
@@ -2,6 +2,8 @@ package wiring
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"pin/internal/domain"
 )
@@ -21,9 +23,24 @@ func (d Deps) DeleteDomainVerification(ctx context.Context, identityID int, doma
 	return d.repos.Domains.DeleteDomainVerification(ctx, identityID, domainName)
 }
 
-// MarkDomainVerified returns domain verified.
-func (d Deps) MarkDomainVerified(ctx context.Context, identityID int, domainName string) error {
-	return d.repos.Domains.MarkDomainVerified(ctx, identityID, domainName)
+// MarkDomainVerified records that a domain was verified with method by delegating to configured services.
+func (d Deps) MarkDomainVerified(ctx context.Context, identityID int, domainName, method string) error {
+	return d.repos.Domains.MarkDomainVerified(ctx, identityID, domainName, method)
+}
+
+// ListVerifiedDomainVerifications returns every verified domain by delegating to configured services.
+func (d Deps) ListVerifiedDomainVerifications(ctx context.Context) ([]domain.DomainVerification, error) {
+	return d.repos.Domains.ListVerifiedDomainVerifications(ctx)
+}
+
+// RecordDomainCheck stores the outcome of a domain re-check by delegating to configured services.
+func (d Deps) RecordDomainCheck(ctx context.Context, id int, checkedAt time.Time, lastError string, failingSince sql.NullTime) error {
+	return d.repos.Domains.RecordDomainCheck(ctx, id, checkedAt, lastError, failingSince)
+}
+
+// DemoteDomainVerification clears a domain's verification by delegating to configured services.
+func (d Deps) DemoteDomainVerification(ctx context.Context, id int, checkedAt time.Time, lastError string) error {
+	return d.repos.Domains.DemoteDomainVerification(ctx, id, checkedAt, lastError)
 }
 
 // HasDomainVerification reports whether domain verification exists by delegating to configured services.
@@ -65,3 +65,18 @@ func (d Deps) CheckHandleCollision(ctx context.Context, handle string, excludeID
 func (d Deps) DeleteIdentity(ctx context.Context, identityID int) error {
 	return d.repos.Identities.DeleteIdentity(ctx, identityID)
 }
+
+// CreateIdentityRevision stores an identity revision by delegating to configured services.
+func (d Deps) CreateIdentityRevision(ctx context.Context, revision domain.IdentityRevision) (int64, error) {
+	return d.repos.Identities.CreateIdentityRevision(ctx, revision)
+}
+
+// ListIdentityRevisions returns identity revisions by delegating to configured services.
+func (d Deps) ListIdentityRevisions(ctx context.Context, identityID, limit int) ([]domain.IdentityRevision, error) {
+	return d.repos.Identities.ListIdentityRevisions(ctx, identityID, limit)
+}
+
+// GetIdentityRevision returns an identity revision by delegating to configured services.
+func (d Deps) GetIdentityRevision(ctx context.Context, identityID, revisionID int) (domain.IdentityRevision, error) {
+	return d.repos.Identities.GetIdentityRevision(ctx, identityID, revisionID)
+}
@@ -2,6 +2,8 @@ package wiring
 
 import (
 	"context"
+	"crypto/ed25519"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/sessions"
@@ -63,3 +65,28 @@ func (d Deps) BaseURL(r *http.Request) string {
 func (d Deps) Reserved() map[string]struct{} {
 	return d.srv.Reserved()
 }
+
+// WriteBackup streams a backup archive by delegating to configured services.
+func (d Deps) WriteBackup(ctx context.Context, w io.Writer) error {
+	return d.srv.WriteBackup(ctx, w)
+}
+
+// HTTPClient returns the outbound HTTP client by delegating to configured services.
+func (d Deps) HTTPClient() *http.Client {
+	return d.srv.HTTPClient()
+}
+
+// PINCSigningKey returns the node key used to sign PINC exports.
+func (d Deps) PINCSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	return d.srv.PINCSigningKey(ctx)
+}
+
+// SealSecret encrypts a secret for storage at rest.
+func (d Deps) SealSecret(plaintext []byte) (string, error) {
+	return d.srv.SealSecret(plaintext)
+}
+
+// OpenSecret decrypts a secret sealed by SealSecret.
+func (d Deps) OpenSecret(value string) ([]byte, error) {
+	return d.srv.OpenSecret(value)
+}
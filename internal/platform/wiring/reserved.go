@@ -0,0 +1,18 @@
+package wiring
+
+import (
+	pinserver "pin/internal/platform/server"
+)
+
+// ReservedSegments lists the first path segment of every route the HTTP router registers, so
+// handles cannot shadow them.
+var ReservedSegments = []string{
+	".well-known", "admin", "api", "health", "invite", "landing", "login", "logout", "mcp",
+	"oauth", "p", "passkeys", "settings", "setup", "static", "users",
+}
+
+// ReserveHandles reserves the router's path segments on a server that serves without it, such
+// as stdio MCP, so handle edits are validated the same way.
+func ReserveHandles(srv *pinserver.Server) {
+	srv.ReserveHandles(ReservedSegments...)
+}
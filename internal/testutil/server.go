@@ -26,6 +26,8 @@ func TestConfig(t *testing.T) config.Config {
 		AllowedExts:       map[string]bool{".png": true, ".webp": true},
 		BaseURL:           "http://example.test",
 		CookieSameSite:    http.SameSiteLaxMode,
+		// Tests talk to httptest listeners on loopback.
+		AllowPrivateHosts: true,
 	}
 }
 
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	_ "modernc.org/sqlite"
 	"pin/internal/config"
+	"pin/internal/features/backup"
+	"pin/internal/features/domains"
+	"pin/internal/features/federation"
 	"pin/internal/features/identity"
+	"pin/internal/features/mcp"
 	pinhttp "pin/internal/platform/http"
 	pinserver "pin/internal/platform/server"
 	"pin/internal/platform/storage"
 	sqlitestore "pin/internal/platform/storage/sqlite"
+	"pin/internal/platform/wiring"
 )
 
 // main is the program entry point.
@@ -23,32 +32,146 @@ func main() {
 		if err != nil {
 			log.Fatalf("config error: %v", err)
 		}
-		db, err := sql.Open("sqlite", cfg.DBPath)
-		if err != nil {
-			log.Fatalf("db open: %v", err)
-		}
+		db := openDB(cfg)
 		defer db.Close()
 		dest := ""
 		if len(os.Args) > 2 {
 			dest = os.Args[2]
 		}
-		if path, err := storage.BackupToZip(cfg, dest); err != nil {
+		if path, err := storage.BackupToZip(context.Background(), db, cfg, dest); err != nil {
 			log.Fatalf("backup failed: %v", err)
 		} else {
 			log.Printf("backup written to %s", path)
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatalf("config error: %v", err)
+		}
+		src := ""
+		force := false
+		for _, arg := range os.Args[2:] {
+			switch arg {
+			case "--force", "-force", "-f":
+				force = true
+			default:
+				src = arg
+			}
+		}
+		if src == "" {
+			log.Fatalf("usage: pin restore <backup.zip> [--force]")
+		}
+		if err := storage.RestoreFromZip(cfg, src, force); err != nil {
+			log.Fatalf("restore failed: %v", err)
+		}
+		log.Printf("restored %s into %s", src, cfg.DBPath)
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatalf("config error: %v", err)
+		}
+		db := openDB(cfg)
+		defer db.Close()
+		action := "status"
+		if len(os.Args) > 2 {
+			action = os.Args[2]
+		}
+		if err := runMigrate(db, action); err != nil {
+			log.Fatalf("migrate %s: %v", action, err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		if err := runMCP(os.Args[2:]); err != nil {
+			log.Fatalf("mcp: %v", err)
+		}
+		return
+	}
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
 
+	db := openDB(cfg)
+	defer db.Close()
+
+	if err := sqlitestore.InitDB(db); err != nil {
+		log.Fatalf("db init: %v", err)
+	}
+
+	srv, err := pinserver.NewServer(cfg, db, identity.TemplateFuncs())
+	if err != nil {
+		log.Fatalf("server init: %v", err)
+	}
+	// Create the signing key up front so a read-only `pin mcp` can load it later.
+	if _, err := srv.PINCSigningKey(context.Background()); err != nil {
+		log.Fatalf("signing key: %v", err)
+	}
+
+	startBackupScheduler(cfg, db, srv)
+	startActivityPubDelivery(cfg, srv)
+	startDomainRecheck(cfg, srv)
+
+	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
+	log.Printf("listening on %s", addr)
+	if err := http.ListenAndServe(addr, pinhttp.Routes(srv)); err != nil {
+		log.Fatalf("server error: %v", err)
+	}
+}
+
+// startBackupScheduler runs periodic backups in the background when an interval is configured.
+func startBackupScheduler(cfg config.Config, db *sql.DB, srv *pinserver.Server) {
+	if cfg.BackupInterval <= 0 {
+		return
+	}
+	scheduler := backup.NewScheduler(backup.Config{
+		Interval: cfg.BackupInterval,
+		Dir:      cfg.BackupDir,
+		Policy: backup.RetentionPolicy{
+			Keep:       cfg.BackupKeep,
+			KeepDaily:  cfg.BackupKeepDaily,
+			KeepWeekly: cfg.BackupKeepWeekly,
+		},
+	}, func(ctx context.Context, dest string) (string, error) {
+		return storage.BackupToZip(ctx, db, cfg, dest)
+	}, wiring.NewDeps(srv))
+	scheduler.Start(context.Background())
+	log.Printf("scheduled backups every %s to %s", cfg.BackupInterval, cfg.BackupDir)
+}
+
+// startActivityPubDelivery publishes profile updates to fediverse followers and drains the delivery queue.
+func startActivityPubDelivery(cfg config.Config, srv *pinserver.Server) {
+	if cfg.APDeliveryInterval <= 0 {
+		return
+	}
+	federation.NewWorker(federation.WorkerConfig{
+		Interval: cfg.APDeliveryInterval,
+		BaseURL:  cfg.BaseURL,
+	}, wiring.NewDeps(srv)).Start(context.Background())
+}
+
+// startDomainRecheck re-verifies verified domains and demotes those that keep failing past the grace period.
+func startDomainRecheck(cfg config.Config, srv *pinserver.Server) {
+	if cfg.DomainRecheck <= 0 {
+		return
+	}
+	domains.NewRechecker(domains.RecheckConfig{
+		Interval: cfg.DomainRecheck,
+		Grace:    cfg.DomainRecheckGrace,
+		BaseURL:  cfg.BaseURL,
+	}, wiring.NewDeps(srv)).Start(context.Background())
+}
+
+// openDB opens the configured SQLite database with the pragmas the server relies on.
+func openDB(cfg config.Config) *sql.DB {
 	db, err := sql.Open("sqlite", cfg.DBPath)
 	if err != nil {
 		log.Fatalf("db open: %v", err)
 	}
-	defer db.Close()
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
@@ -57,19 +180,126 @@ func main() {
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		log.Fatalf("db journal_mode: %v", err)
 	}
+	return db
+}
 
-	if err := sqlitestore.InitDB(db); err != nil {
-		log.Fatalf("db init: %v", err)
+// openDBReadOnly opens the configured SQLite database without write access.
+func openDBReadOnly(cfg config.Config) *sql.DB {
+	db, err := sql.Open("sqlite", "file:"+cfg.DBPath+"?mode=ro")
+	if err != nil {
+		log.Fatalf("db open: %v", err)
 	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		log.Fatalf("db busy_timeout: %v", err)
+	}
+	return db
+}
 
-	srv, err := pinserver.NewServer(cfg, db, identity.TemplateFuncs())
+// runMCP serves MCP over stdin/stdout for local agents. The database is opened
+// read-only unless PIN_MCP_READONLY is false, in which case the write tools work too.
+func runMCP(args []string) error {
+	stdio := false
+	for _, arg := range args {
+		switch arg {
+		case "--stdio", "-stdio":
+			stdio = true
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+	if !stdio {
+		return errors.New("usage: pin mcp --stdio")
+	}
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("server init: %v", err)
+		return err
+	}
+	return serveMCP(context.Background(), cfg, os.Stdin, os.Stdout)
+}
+
+// serveMCP opens the database for cfg and serves MCP over in and out until in is exhausted.
+func serveMCP(ctx context.Context, cfg config.Config, in io.Reader, out io.Writer) error {
+	var db *sql.DB
+	if cfg.MCPReadOnly {
+		db = openDBReadOnly(cfg)
+	} else {
+		db = openDB(cfg)
+		if err := sqlitestore.InitDB(db); err != nil {
+			return err
+		}
 	}
+	defer db.Close()
+	if err := sqlitestore.CheckSchemaVersion(ctx, db); err != nil {
+		return err
+	}
+	repos := sqlitestore.NewRepos(db)
+	if cfg.MCPReadOnly {
+		// Reads stay on the read-only handle; audit entries go through a writable one so every
+		// read still leaves a trail.
+		auditDB := openDB(cfg)
+		defer auditDB.Close()
+		repos.Audit = sqlitestore.NewRepos(auditDB).Audit
+	}
+	srv, err := pinserver.NewServerWithRepos(cfg, db, repos, identity.TemplateFuncs())
+	if err != nil {
+		return err
+	}
+	if cfg.MCPReadOnly {
+		// The signing key cannot be created over a read-only connection.
+		if err := srv.LoadPINCSigningKey(ctx); errors.Is(err, pinserver.ErrNoSigningKey) {
+			return errors.New("no PINC signing key yet: set PIN_PINC_SIGNING_KEY, start the web server once, or set PIN_MCP_READONLY=false")
+		} else if err != nil {
+			return err
+		}
+	}
+	// Tool edits validate handles against the router's path segments.
+	wiring.ReserveHandles(srv)
+	handler := mcp.NewHandler(mcp.Config{Enabled: true, ReadOnly: cfg.MCPReadOnly}, wiring.NewDeps(srv))
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost:" + cfg.Port
+	}
+	log.Printf("serving MCP on stdio for %s", cfg.DBPath)
+	return handler.ServeStdio(ctx, in, out, baseURL)
+}
 
-	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
-	log.Printf("listening on %s", addr)
-	if err := http.ListenAndServe(addr, pinhttp.Routes(srv)); err != nil {
-		log.Fatalf("server error: %v", err)
+// runMigrate prints migration status or applies pending migrations.
+func runMigrate(db *sql.DB, action string) error {
+	ctx := context.Background()
+	switch action {
+	case "status":
+		statuses, err := sqlitestore.ListMigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		current, err := sqlitestore.SchemaVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version: %d (binary supports %d)\n", current, sqlitestore.LatestSchemaVersion())
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-32s %s\n", status.Version, status.Name, state)
+		}
+		return sqlitestore.CheckSchemaVersion(ctx, db)
+	case "up":
+		applied, err := sqlitestore.Migrate(ctx, db)
+		for _, version := range applied {
+			log.Printf("applied migration %d", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Printf("schema is up to date")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q (expected status or up)", action)
 	}
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"pin/internal/domain"
+	"pin/internal/features/identity"
 	pinhttp "pin/internal/platform/http"
+	pinserver "pin/internal/platform/server"
+	sqlitestore "pin/internal/platform/storage/sqlite"
 	"pin/internal/testutil"
 )
 
@@ -15,3 +22,64 @@ func TestMainWiring(t *testing.T) {
 		t.Fatalf("expected router handler")
 	}
 }
+
+// TestServeMCPReadOnly verifies stdio MCP on a read-only database refuses to start without a
+// signing key and serves identity resources once one is stored.
+func TestServeMCPReadOnly(t *testing.T) {
+	testutil.ChdirRepoRoot(t)
+	ctx := context.Background()
+	cfg := testutil.TestConfig(t)
+	cfg.DBPath = filepath.Join(t.TempDir(), "identity.db")
+
+	db := openDB(cfg)
+	if err := sqlitestore.InitDB(db); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	userID, err := sqlitestore.CreateUser(ctx, db, "owner", "hash", "secret", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := sqlitestore.NewRepos(db).Identities.CreateIdentity(ctx, domain.Identity{UserID: int(userID), Handle: "alice"}); err != nil {
+		t.Fatalf("create identity: %v", err)
+	}
+	_ = db.Close()
+
+	cfg.MCPReadOnly = true
+	read := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"identity://alice"}}` + "\n"
+	var out strings.Builder
+	if err := serveMCP(ctx, cfg, strings.NewReader(read), &out); err == nil || !strings.Contains(err.Error(), "PIN_PINC_SIGNING_KEY") {
+		t.Fatalf("expected a missing signing key error, got %v", err)
+	}
+
+	db = openDB(cfg)
+	srv, err := pinserver.NewServer(cfg, db, identity.TemplateFuncs())
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if _, err := srv.PINCSigningKey(ctx); err != nil {
+		t.Fatalf("signing key: %v", err)
+	}
+	_ = db.Close()
+
+	out.Reset()
+	if err := serveMCP(ctx, cfg, strings.NewReader(read), &out); err != nil {
+		t.Fatalf("serve mcp: %v", err)
+	}
+	if !strings.Contains(out.String(), `"contents"`) || strings.Contains(out.String(), `"error"`) {
+		t.Fatalf("expected the identity resource, got %s", out.String())
+	}
+
+	db = openDB(cfg)
+	defer db.Close()
+	logs, err := sqlitestore.NewRepos(db).Audit.ListAllAuditLogs(ctx)
+	if err != nil {
+		t.Fatalf("list audit logs: %v", err)
+	}
+	audited := false
+	for _, entry := range logs {
+		audited = audited || (entry.Action == "mcp.resources.read" && entry.Target == "identity://alice")
+	}
+	if !audited {
+		t.Fatalf("expected the read-only read to be audited, got %+v", logs)
+	}
+}
@@ -309,6 +309,7 @@
             links.forEach((link) => {
                 const label = link.Label || link.label || "";
                 const url = link.URL || link.url || "";
+                const verified = link.Verified === true || link.verified === true;
                 if (!label || !url) {
                     return;
                 }
@@ -320,7 +321,12 @@
                 const left = document.createElement("span");
                 left.textContent = label;
                 const right = document.createElement("span");
-                right.textContent = ">";
+                if (verified) {
+                    right.className = "badge";
+                    right.textContent = "verified";
+                } else {
+                    right.textContent = ">";
+                }
                 a.appendChild(left);
                 a.appendChild(right);
                 li.appendChild(a);
@@ -506,7 +512,7 @@
                 if (currentMode === "public" && visibility === "private") {
                     return;
                 }
-                out.push({ Label: label, URL: url });
+                out.push({ Label: label, URL: url, Verified: row.dataset.verifiedUrl === url });
             });
             return out;
         }
@@ -527,7 +533,7 @@
                 if (currentMode === "public" && visibility === "private") {
                     return;
                 }
-                const verified = row.getAttribute("title") === "Verified profiles cannot be edited here.";
+                const verified = row.dataset.verifiedUrl === url;
                 out.push({ Label: label, URL: url, Verified: verified });
             });
             return out;
@@ -590,6 +596,7 @@
                 { id: "#key_ssh", visibility: "visibility_key_ssh", label: "ssh" },
                 { id: "#key_age", visibility: "visibility_key_age", label: "age" },
                 { id: "#key_activitypub", visibility: "visibility_key_activitypub", label: "activitypub" },
+                { id: "#key_nostr", visibility: "visibility_key_nostr", label: "nostr" },
             ];
             let found = false;
             keys.forEach((entry) => {
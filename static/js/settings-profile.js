@@ -675,9 +675,11 @@
             }
             const domain = row.dataset.domain;
             if (verifyButton) {
+                const methodSelect = row.querySelector(".domain-verify-method");
                 const res = await postForm("/settings/profile/verified-domains/verify", {
                     csrf_token: csrfToken,
                     domain,
+                    method: methodSelect ? methodSelect.value : "",
                 });
                 if (res.ok) {
                     window.location.reload();